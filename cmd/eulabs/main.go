@@ -2,8 +2,10 @@ package main
 
 import (
 	"context"
+	"crypto/subtle"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -17,7 +19,11 @@ import (
 	"github.com/waldrey/eulabs/internal/handlers"
 	"github.com/waldrey/eulabs/internal/infra/database"
 	"github.com/waldrey/eulabs/internal/infra/service"
+	"github.com/waldrey/eulabs/internal/middlewares"
+	"github.com/waldrey/eulabs/pkg/cursor"
+	"github.com/waldrey/eulabs/pkg/i18n"
 	_ "github.com/waldrey/eulabs/pkg/logger"
+	"github.com/waldrey/eulabs/pkg/requests"
 )
 
 // @title           Eulabs Products API
@@ -34,25 +40,118 @@ func main() {
 	}
 	db := configs.ConnectDatabase()
 
+	catalog, err := i18n.NewCatalog()
+	if err != nil {
+		log.Fatalf("failed load messages: %v\n", err)
+	}
+	if config.LocalesDir != "" {
+		if err := catalog.Load(config.LocalesDir); err != nil {
+			log.Fatalf("failed load messages: %v\n", err)
+		}
+	}
+
+	// the requests are cancelled, with their queries, when the shutdown does
+	// not wait for them any longer
+	requestsCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+
 	e := echo.New()
+	e.Server.BaseContext = func(net.Listener) context.Context {
+		return requestsCtx
+	}
+	e.Use(middleware.RequestID())
+	e.Use(i18n.Localize(catalog))
 	e.Use(middleware.Recover())
 	e.Pre(middleware.RemoveTrailingSlash())
+	e.Binder = &requests.Binder{}
+	e.HTTPErrorHandler = requests.HTTPErrorHandler
 
 	e.GET("/docs/*", echoSwagger.WrapHandler)
 	api := e.Group("api/v1/")
+	api.Use(middlewares.BodyLimitWithConfig(middlewares.BodyLimitConfig{
+		// imports are streamed to a file instead of read in memory
+		Skipper: isImport,
+		Limit:   config.BodyLimit,
+	}))
+	api.Use(middlewares.TimeoutWithConfig(middlewares.TimeoutConfig{
+		// downloads are streamed for as long as they take, synchronous imports
+		// and bulks are not cut short after committing part of their rows
+		Skipper: middlewares.AnySkipper(isDownload, isLongWrite),
+		Timeout: config.RequestTimeout,
+	}))
+	api.Use(requests.NegotiateWithConfig(requests.NegotiateConfig{
+		// downloads are sent in the media type of the file
+		Skipper: isDownload,
+	}))
+	idempotencyRepository := database.IdempotencyRepository(db)
+	api.Use(middlewares.IdempotencyWithConfig(middlewares.IdempotencyConfig{
+		// imports are streamed to the handler instead of held in memory
+		Skipper: isImport,
+		Store:   service.IdempotencyService(idempotencyRepository),
+	}))
 
 	// Handler Product
 	productRepository := database.ProductRepository(db)
-	productService := service.ProductService(productRepository)
-	productHandler := handlers.NewProductHandler(productService)
+	unitOfWork := database.NewUnitOfWork(db)
+	productService := service.ProductService(productRepository, unitOfWork)
+	if err := productService.LoadSuggestions(context.Background()); err != nil {
+		log.Printf("failed load suggestions index: %v\n", err)
+	}
+	priceListService := service.PriceListService(database.PriceListRepository(db), productRepository, unitOfWork)
+	if config.JobsDir == "" {
+		log.Fatal("JOBS_DIR is not set, the background jobs need a persistent directory")
+	}
+	jobService := service.JobService(database.JobRepository(db), config.JobsDir, config.JobWorkers)
+	productHandler := handlers.NewProductHandler(productService, priceListService, jobService, cursor.NewSigner(config.CursorSecret), handlers.ProductHandlerConfig{
+		RequireIfMatch: config.RequireIfMatch,
+		CacheControl:   config.CacheControl,
+	})
+	productHandler.RegisterJobs(jobService)
 
 	productRoutes := api.Group("products")
 	productRoutes.POST("", productHandler.Create)
+	productRoutes.POST("/bulk", productHandler.Bulk)
+	productRoutes.POST("/import", productHandler.Import)
 	productRoutes.GET("", productHandler.List)
+	productRoutes.GET("/export", productHandler.Export)
+	productRoutes.POST("/export", productHandler.ExportJob)
+	productRoutes.GET("/search", productHandler.Search)
+	productRoutes.GET("/suggest", productHandler.Suggest)
+	productRoutes.GET("/stats", productHandler.Stats)
+	productRoutes.GET("/trash", productHandler.Trash)
 	productRoutes.GET("/:id", productHandler.FindOne)
 	productRoutes.DELETE("/:id", productHandler.Delete)
 	productRoutes.PUT("/:id", productHandler.UpdatePut)
 	productRoutes.PATCH("/:id", productHandler.UpdatePatch)
+	productRoutes.POST("/:id/restore", productHandler.Restore)
+	productRoutes.GET("/:id/prices", productHandler.FindPrices)
+	productRoutes.PUT("/:id/prices", productHandler.ReplacePrices)
+
+	// Handler Exchange Rate
+	exchangeRateHandler := handlers.NewExchangeRateHandler(priceListService)
+
+	exchangeRateRoutes := api.Group("exchange-rates")
+	exchangeRateRoutes.GET("", exchangeRateHandler.List)
+	exchangeRateRoutes.PUT("", exchangeRateHandler.Upload, adminKeyAuth(config.AdminAPIKey))
+
+	// Handler Job
+	jobHandler := handlers.NewJobHandler(jobService)
+
+	jobRoutes := api.Group("jobs")
+	jobRoutes.GET("/:id", jobHandler.Find)
+	jobRoutes.GET("/:id/result", jobHandler.Result)
+
+	if err := jobService.Start(); err != nil {
+		log.Fatalf("failed start job runner: %v", err)
+	}
+
+	// trashed products are kept for good when there is no retention period
+	purges := []service.Purge{service.ExpiredKeysPurge(idempotencyRepository)}
+	if config.TrashRetentionDays > 0 {
+		purges = append(purges, service.TrashPurge(productRepository, time.Duration(config.TrashRetentionDays)*24*time.Hour))
+	}
+	retention := service.RetentionService(purges...)
+	retention.Start()
 
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer stop()
@@ -67,8 +166,41 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer cancel()
 	if err := e.Shutdown(ctx); err != nil {
-		log.Fatalf("could not gracefully shutdown server: %v", err)
+		cancelRequests()
+		log.Printf("could not gracefully shutdown server: %v", err)
+	}
+
+	// running jobs not done in time are queued again for the next start
+	if err := jobService.Shutdown(ctx); err != nil {
+		log.Printf("job runner stopped before the jobs finished: %v", err)
+	}
+
+	if err := retention.Shutdown(ctx); err != nil {
+		log.Printf("purges stopped before they finished: %v", err)
 	}
 
 	log.Print("server stopped")
 }
+
+var (
+	// isDownload matches the routes answering in their own media types
+	// instead of the ones of requests.Render
+	isDownload = middlewares.RouteSkipper(http.MethodGet, "/api/v1/products/export", "/api/v1/jobs/:id/result")
+	// isLongWrite matches the routes writing many products, committed as
+	// they go
+	isLongWrite = middlewares.RouteSkipper(http.MethodPost, "/api/v1/products/import", "/api/v1/products/bulk")
+	isImport    = middlewares.RouteSkipper(http.MethodPost, "/api/v1/products/import")
+)
+
+// adminKeyAuth only lets through requests bearing the admin API key, every
+// request is refused while the key is not configured.
+func adminKeyAuth(key string) echo.MiddlewareFunc {
+	return middleware.KeyAuthWithConfig(middleware.KeyAuthConfig{
+		Validator: func(auth string, c echo.Context) (bool, error) {
+			return key != "" && subtle.ConstantTimeCompare([]byte(auth), []byte(key)) == 1, nil
+		},
+		ErrorHandler: func(err error, c echo.Context) error {
+			return requests.NewProblem(http.StatusUnauthorized, "unauthorized", "invalid or missing API key")
+		},
+	})
+}
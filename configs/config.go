@@ -3,9 +3,12 @@ package configs
 import (
 	"fmt"
 	"log"
+	"math"
+	"time"
 
 	"github.com/spf13/viper"
 	"github.com/waldrey/eulabs/internal/entity"
+	"github.com/waldrey/eulabs/pkg/money"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
@@ -20,6 +23,35 @@ type conf struct {
 	DBPassword    string `mapstructure:"DB_PASSWORD"`
 	DBName        string `mapstructure:"DB_NAME"`
 	WebServerPort string `mapstructure:"WEB_SERVER_PORT"`
+	CursorSecret  string `mapstructure:"CURSOR_SECRET"`
+	AdminAPIKey   string `mapstructure:"ADMIN_API_KEY"`
+
+	// RequireIfMatch refuses product writes without an If-Match header
+	RequireIfMatch bool `mapstructure:"REQUIRE_IF_MATCH"`
+
+	// CacheControl is sent on product reads, e.g. "public, max-age=60"
+	CacheControl string `mapstructure:"CACHE_CONTROL"`
+
+	// JobsDir keeps the uploads and results of background jobs, it is
+	// required and must survive restarts for the interrupted jobs to resume,
+	// so a temporary directory will not do
+	JobsDir    string `mapstructure:"JOBS_DIR"`
+	JobWorkers int    `mapstructure:"JOB_WORKERS"`
+
+	// BodyLimit is the largest request body accepted in bytes, the imports are
+	// streamed and have no limit
+	BodyLimit int64 `mapstructure:"BODY_LIMIT"`
+
+	// RequestTimeout is the deadline of each request, like 30s, zero disables it
+	RequestTimeout time.Duration `mapstructure:"REQUEST_TIMEOUT"`
+
+	// TrashRetentionDays is how long deleted products stay in the trash before
+	// they are purged for good, zero keeps them until purged by hand
+	TrashRetentionDays int `mapstructure:"TRASH_RETENTION_DAYS"`
+
+	// LocalesDir has catalog files adding languages or replacing the built-in
+	// messages, see i18n.Catalog
+	LocalesDir string `mapstructure:"LOCALES_DIR"`
 }
 
 func LoadConfig() (*conf, error) {
@@ -28,6 +60,10 @@ func LoadConfig() (*conf, error) {
 	viper.SetConfigType("env")
 	viper.SetConfigFile(".env")
 	viper.AutomaticEnv()
+	viper.SetDefault("JOB_WORKERS", 1)
+	viper.SetDefault("REQUEST_TIMEOUT", 30*time.Second)
+	viper.SetDefault("BODY_LIMIT", 4<<20)
+	viper.SetDefault("TRASH_RETENTION_DAYS", 0)
 	err := viper.ReadInConfig()
 	if err != nil {
 		panic(err)
@@ -59,5 +95,55 @@ func ConnectDatabase() *gorm.DB {
 }
 
 func migrateDatabase(db *gorm.DB) error {
-	return db.AutoMigrate(&entity.Product{})
+	err := db.AutoMigrate(&entity.Product{}, &entity.ProductPrice{}, &entity.ExchangeRate{}, &entity.IdempotencyKey{}, &entity.Job{})
+	if err != nil {
+		return err
+	}
+
+	err = migrateProductPrices(db)
+	if err != nil {
+		return err
+	}
+
+	// keyset pagination walks products ordered by created_at and id
+	if !db.Migrator().HasIndex(&entity.Product{}, "idx_products_created_at_id") {
+		err = db.Exec("CREATE INDEX idx_products_created_at_id ON products (created_at, id)").Error
+		if err != nil {
+			return err
+		}
+	}
+
+	// one explicit price per product and currency
+	if !db.Migrator().HasIndex(&entity.ProductPrice{}, "idx_product_prices_currency") {
+		err = db.Exec("CREATE UNIQUE INDEX idx_product_prices_currency ON product_prices (product_id, price_currency)").Error
+		if err != nil {
+			return err
+		}
+	}
+
+	// full-text search, other drivers fall back to the in-memory index
+	if db.Dialector.Name() == "mysql" && !db.Migrator().HasIndex(&entity.Product{}, "idx_products_fulltext") {
+		return db.Exec("CREATE FULLTEXT INDEX idx_products_fulltext ON products (name, description)").Error
+	}
+
+	return nil
+}
+
+// migrateProductPrices moves the legacy float price column into minor units
+// of the default currency and drops it.
+func migrateProductPrices(db *gorm.DB) error {
+	if !db.Migrator().HasColumn(&entity.Product{}, "price") {
+		return nil
+	}
+
+	factor := math.Pow10(money.Exponent(money.DefaultCurrency))
+	err := db.Exec(
+		"UPDATE products SET price_amount = ROUND(price * ?), price_currency = ? WHERE price_currency IS NULL OR price_currency = ''",
+		factor, money.DefaultCurrency,
+	).Error
+	if err != nil {
+		return err
+	}
+
+	return db.Migrator().DropColumn(&entity.Product{}, "price")
 }
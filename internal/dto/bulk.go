@@ -0,0 +1,62 @@
+package dto
+
+import (
+	"encoding/json"
+
+	"github.com/waldrey/eulabs/internal/entity"
+)
+
+const (
+	BulkCreate = "create"
+	BulkUpdate = "update"
+	BulkDelete = "delete"
+
+	MaxBulkOperations = 1000
+)
+
+type BulkProductsRequest struct {
+	Operations []BulkProductOperation `json:"operations" validate:"required,min=1,max=1000"`
+}
+
+// BulkProductOperation creates, updates or deletes a product. Product is a
+// CreateProductRequest for create and an UpdateProductRequest for update, a
+// version other than zero is the If-Match of the update or delete.
+type BulkProductOperation struct {
+	Op      string          `json:"op" validate:"required,oneof=create update delete"`
+	ID      int             `json:"id" validate:"required_unless=Op create,excluded_if=Op create,gte=0"`
+	Version uint            `json:"version"`
+	Product json.RawMessage `json:"product" validate:"required_unless=Op delete" swaggertype:"object"`
+
+	// Index is the position of the operation in the request
+	Index int `json:"-"`
+
+	// Create and Update are the decoded product, set by DecodeProduct
+	Create CreateProductRequest `json:"-" validate:"-"`
+	Update UpdateProductRequest `json:"-" validate:"-"`
+}
+
+// DecodeProduct reads the product of a create or update operation.
+func (o *BulkProductOperation) DecodeProduct() error {
+	switch o.Op {
+	case BulkCreate:
+		return json.Unmarshal(o.Product, &o.Create)
+	case BulkUpdate:
+		return json.Unmarshal(o.Product, &o.Update)
+	}
+
+	return nil
+}
+
+// BulkProductResult is the outcome of one operation, Err is set by the service
+// and turned into the status, code and error by the handler.
+type BulkProductResult struct {
+	Index   int                 `json:"index"`
+	Op      string              `json:"op"`
+	Status  int                 `json:"status"`
+	Product *entity.Product     `json:"product,omitempty"`
+	Code    string              `json:"code,omitempty"`
+	Error   string              `json:"error,omitempty"`
+	Errors  []entity.FieldError `json:"errors,omitempty"`
+
+	Err error `json:"-"`
+}
@@ -0,0 +1,17 @@
+package dto
+
+const (
+	ExportCSV    = "csv"
+	ExportNDJSON = "ndjson"
+	ExportXLSX   = "xlsx"
+)
+
+// ExportColumns are the columns of the CSV and XLSX exports, the price is in
+// the major unit of its currency.
+var ExportColumns = []string{"id", "name", "description", "price", "currency", "version", "created_at", "updated_at"}
+
+type ExportProductsQuery struct {
+	Format string `query:"format" validate:"required,oneof=csv ndjson xlsx"`
+	Sort   string `query:"sort" validate:"omitempty,sortable"`
+	ProductFilterQuery
+}
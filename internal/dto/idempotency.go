@@ -0,0 +1,10 @@
+package dto
+
+import "net/http"
+
+// StoredResponse is the response replayed for a retried Idempotency-Key.
+type StoredResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
@@ -0,0 +1,51 @@
+package dto
+
+// MaxImportErrors caps the row errors reported by an import, the failed rows
+// are still counted.
+const MaxImportErrors = 1000
+
+// ImportRow is a product read from an import file, Errors holds the reasons
+// it cannot be imported.
+type ImportRow struct {
+	Line    int
+	Product CreateProductRequest
+	Errors  []ImportRowError
+}
+
+// ImportSource streams the rows of an import file, Next returns io.EOF after
+// the last row.
+type ImportSource interface {
+	Next() (ImportRow, error)
+}
+
+type ImportRowError struct {
+	Line    int    `json:"line"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportResult counts the rows of an import, on a dry run created, updated
+// and unchanged tell what would have been written.
+type ImportResult struct {
+	DryRun          bool             `json:"dry_run"`
+	Rows            int              `json:"rows"`
+	Created         int              `json:"created"`
+	Updated         int              `json:"updated"`
+	Unchanged       int              `json:"unchanged"`
+	Failed          int              `json:"failed"`
+	Errors          []ImportRowError `json:"errors"`
+	ErrorsTruncated bool             `json:"errors_truncated,omitempty"`
+}
+
+// Fail counts a row that cannot be imported, keeping its errors while under
+// MaxImportErrors.
+func (r *ImportResult) Fail(errors []ImportRowError) {
+	r.Failed++
+	for _, err := range errors {
+		if len(r.Errors) >= MaxImportErrors {
+			r.ErrorsTruncated = true
+			return
+		}
+		r.Errors = append(r.Errors, err)
+	}
+}
@@ -0,0 +1,40 @@
+package dto
+
+import (
+	"encoding/json"
+
+	"github.com/waldrey/eulabs/internal/entity"
+)
+
+const (
+	JobImportProducts = "import_products"
+	JobExportProducts = "export_products"
+)
+
+// JobRequest is a job to enqueue, its result is downloaded with ResultType as
+// media type and ResultName as file name.
+type JobRequest struct {
+	Kind       string
+	Params     interface{}
+	ResultType string
+	ResultName string
+}
+
+// JobResponse is the status of a job, the result URL is only set once it
+// succeeded.
+type JobResponse struct {
+	entity.Job
+	Summary   json.RawMessage `json:"summary,omitempty" swaggertype:"object"`
+	ResultURL string          `json:"result_url,omitempty"`
+}
+
+// ImportJobParams are the parameters of a JobImportProducts job.
+type ImportJobParams struct {
+	Format string `json:"format"`
+	DryRun bool   `json:"dry_run"`
+}
+
+// ExportJobSummary is the summary of a JobExportProducts job.
+type ExportJobSummary struct {
+	Rows int64 `json:"rows"`
+}
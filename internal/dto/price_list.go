@@ -0,0 +1,93 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/waldrey/eulabs/internal/entity"
+	"github.com/waldrey/eulabs/pkg/money"
+)
+
+// CurrencyQuery asks for the product prices in another currency, the handlers
+// fall back to the Accept-Currency header.
+type CurrencyQuery struct {
+	Currency string `query:"currency" validate:"omitempty,currency"`
+}
+
+type FindProductQuery struct {
+	ProductFieldsQuery
+	CurrencyQuery
+}
+
+// ProductPricesRequest replaces the explicit prices of a product, one per
+// currency.
+type ProductPricesRequest struct {
+	Prices []money.Money `json:"prices" validate:"unique=Currency,dive,gt=0"`
+}
+
+type ExchangeRateRequest struct {
+	Base    string      `json:"base" validate:"required,currency"`
+	Quote   string      `json:"quote" validate:"required,currency,nefield=Base"`
+	Rate    json.Number `json:"rate" validate:"required,rate" swaggertype:"string"`
+	RatedAt time.Time   `json:"rated_at"`
+}
+
+type SaveExchangeRatesRequest struct {
+	Rates []ExchangeRateRequest `json:"rates" validate:"required,min=1,dive"`
+}
+
+type ExchangeRateResponse struct {
+	Base    string    `json:"base"`
+	Quote   string    `json:"quote"`
+	Rate    string    `json:"rate"`
+	RatedAt time.Time `json:"rated_at"`
+}
+
+const (
+	PriceSourceList         = "price_list"
+	PriceSourceExchangeRate = "exchange_rate"
+)
+
+// PriceConversion tells how a price in the requested currency was obtained,
+// rate and rated_at are only set when an exchange rate was applied.
+type PriceConversion struct {
+	Source   string      `json:"source"`
+	Original money.Money `json:"original"`
+	Rate     string      `json:"rate,omitempty"`
+	RatedAt  *time.Time  `json:"rated_at,omitempty"`
+}
+
+// ConvertedProduct is a product with its price in the requested currency,
+// PriceConversion is nil when the product was already in that currency.
+type ConvertedProduct struct {
+	entity.Product
+	PriceConversion *PriceConversion `json:"price_conversion,omitempty"`
+}
+
+// SparseConvertedProduct works like SparseProduct, the conversion is kept
+// along with the price.
+func SparseConvertedProduct(product ConvertedProduct, fields []string) interface{} {
+	if len(fields) == 0 {
+		return product
+	}
+
+	values := SparseProduct(product.Product, fields).(map[string]interface{})
+	if _, ok := values["price"]; ok && product.PriceConversion != nil {
+		values["price_conversion"] = product.PriceConversion
+	}
+
+	return values
+}
+
+func SparseConvertedProducts(products []ConvertedProduct, fields []string) interface{} {
+	if len(fields) == 0 {
+		return products
+	}
+
+	values := make([]interface{}, 0, len(products))
+	for _, product := range products {
+		values = append(values, SparseConvertedProduct(product, fields))
+	}
+
+	return values
+}
@@ -1,26 +1,347 @@
 package dto
 
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/waldrey/eulabs/internal/entity"
+	"github.com/waldrey/eulabs/pkg/money"
+	"github.com/waldrey/eulabs/pkg/optional"
+	"github.com/waldrey/eulabs/pkg/rsql"
+)
+
 type CreateProductRequest struct {
-	Name        string  `json:"name" validate:"required"`
-	Description string  `json:"description" validate:"required"`
-	Price       float64 `json:"price" validate:"required,gt=0"`
+	Name        string      `json:"name" validate:"required"`
+	Description string      `json:"description" validate:"required"`
+	Price       money.Money `json:"price" validate:"required,gt=0"`
 }
 
 type PutProductRequest struct {
-	Name        string  `json:"name" validate:"required"`
-	Description string  `json:"description" validate:"required"`
-	Price       float64 `json:"price" validate:"required,gt=0"`
+	Name        string      `json:"name" validate:"required"`
+	Description string      `json:"description" validate:"required"`
+	Price       money.Money `json:"price" validate:"required,gt=0"`
 }
 
+// UpdateProductRequest is a partial update, only the fields sent are applied
+// and validated. A field sent as null is cleared.
 type UpdateProductRequest struct {
-	Name        *string  `json:"name"`
-	Description *string  `json:"description"`
-	Price       *float64 `json:"price"`
+	Name        optional.Field[string]      `json:"name" swaggertype:"string"`
+	Description optional.Field[string]      `json:"description" swaggertype:"string"`
+	Price       optional.Field[money.Money] `json:"price" validate:"omitnil,gt=0" swaggertype:"object"`
+}
+
+// ProductDocument is the product as seen by JSON Merge Patch and JSON Patch
+// documents, only its editable fields.
+type ProductDocument struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Price       money.Money `json:"price"`
+}
+
+// Validators let clients and caches revalidate a read, see RFC 9110.
+type Validators struct {
+	ETag         string
+	LastModified time.Time
+}
+
+// Precondition is the If-Match of a write, it matches any version when Any is
+// set and otherwise only the listed versions.
+type Precondition struct {
+	Any      bool
+	Versions []uint
+}
+
+// AnyVersion is the precondition of an unconditional write.
+var AnyVersion = Precondition{Any: true}
+
+func (p Precondition) Matches(version uint) bool {
+	return p.Any || slices.Contains(p.Versions, version)
 }
 
 type ProductResponse struct {
-	Id          int     `json:"id"`
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
+	Id          int         `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Price       money.Money `json:"price"`
+}
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// ProductSortableFields is the allow-list of fields accepted by the sort parameter.
+var ProductSortableFields = []string{"id", "name", "price", "created_at", "updated_at"}
+
+// PageQuery is the offset pagination shared by the listings.
+type PageQuery struct {
+	Page     int `query:"page" validate:"omitempty,gte=1"`
+	PageSize int `query:"page_size" validate:"omitempty,gte=1"`
+}
+
+// Normalize fills the pagination defaults and caps the page size to MaxPageSize.
+func (q *PageQuery) Normalize() {
+	if q.Page <= 0 {
+		q.Page = 1
+	}
+
+	if q.PageSize <= 0 {
+		q.PageSize = DefaultPageSize
+	}
+
+	if q.PageSize > MaxPageSize {
+		q.PageSize = MaxPageSize
+	}
+}
+
+func (q PageQuery) Offset() int {
+	return (q.Page - 1) * q.PageSize
+}
+
+type ListProductsQuery struct {
+	Cursor string `query:"cursor"`
+	Sort   string `query:"sort" validate:"omitempty,sortable"`
+	PageQuery
+	ProductFilterQuery
+	ProductFieldsQuery
+	CurrencyQuery
+}
+
+// ProductFilterQuery holds the filters shared by every endpoint reading a set
+// of products, prices are in the major unit of money.DefaultCurrency and only
+// match the products priced in it.
+type ProductFilterQuery struct {
+	MinPrice      float64 `query:"min_price" validate:"omitempty,gt=0"`
+	MaxPrice      float64 `query:"max_price" validate:"omitempty,gt=0,gtefield=MinPrice"`
+	NameContains  string  `query:"name_contains" validate:"omitempty,max=255"`
+	CreatedAfter  string  `query:"created_after" validate:"omitempty,rfc3339"`
+	CreatedBefore string  `query:"created_before" validate:"omitempty,rfc3339,gtdatefield=CreatedAfter"`
+	Filter        string  `query:"filter"`
+
+	// Expression is the parsed Filter, set by ParseFilter
+	Expression rsql.Node `json:"-"`
+}
+
+// CreatedRange parses the validated created_after and created_before dates, a
+// date not sent is zero.
+func (q ProductFilterQuery) CreatedRange() (time.Time, time.Time) {
+	after, _ := ParseDateTime(q.CreatedAfter)
+	before, _ := ParseDateTime(q.CreatedBefore)
+
+	return after, before
+}
+
+// ParseDateTime reads a RFC 3339 date time of the query, an empty one is zero.
+func ParseDateTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
+
+// ProductFilterFields is the allow-list of entity.Product fields accepted by
+// the filter parameter.
+var ProductFilterFields = rsql.Schema{
+	"id":          {Column: "id", Kind: rsql.Number},
+	"name":        {Column: "name", Kind: rsql.String},
+	"description": {Column: "description", Kind: rsql.String},
+	"price": {
+		Column:    "price_amount",
+		Kind:      rsql.Number,
+		Parse:     parsePrice,
+		Scope:     "price_currency = ?",
+		ScopeArgs: []interface{}{money.DefaultCurrency},
+	},
+	"created_at": {Column: "created_at", Kind: rsql.Time},
+	"updated_at": {Column: "updated_at", Kind: rsql.Time},
+}
+
+// parsePrice reads filter prices in the major unit of the default currency,
+// comparing them against the stored minor units of the products priced in it.
+func parsePrice(value string) (interface{}, error) {
+	price, err := money.Parse(value, money.DefaultCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	return price.Amount, nil
+}
+
+// ParseFilter parses and validates the RSQL expression of Filter, errors are
+// returned as *rsql.Error pointing at the offending token.
+func (q *ProductFilterQuery) ParseFilter() error {
+	if q.Filter == "" {
+		return nil
+	}
+
+	expression, err := rsql.Parse(q.Filter)
+	if err != nil {
+		return err
+	}
+
+	if err := ProductFilterFields.Validate(expression); err != nil {
+		return err
+	}
+
+	q.Expression = expression
+	return nil
+}
+
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// ParseSort splits a sort expression like "price,-created_at" in its fields,
+// a leading "-" means descending order.
+func ParseSort(sort string) []SortField {
+	var fields []SortField
+	for _, field := range strings.Split(sort, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		desc := strings.HasPrefix(field, "-")
+		fields = append(fields, SortField{
+			Field: strings.TrimPrefix(field, "-"),
+			Desc:  desc,
+		})
+	}
+
+	return fields
+}
+
+// ListTrashQuery pages through the trashed products, the last deleted first.
+type ListTrashQuery struct {
+	PageQuery
+}
+
+const (
+	DefaultSearchLimit = 20
+	SnippetSize        = 160
+)
+
+type SearchProductsQuery struct {
+	Q     string `query:"q" validate:"required,max=255"`
+	Limit int    `query:"limit" validate:"omitempty,gte=1,lte=100"`
+}
+
+type ProductSearchResult struct {
+	Product    entity.Product    `json:"product"`
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights"`
+}
+
+const DefaultSuggestLimit = 10
+
+type SuggestProductsQuery struct {
+	Prefix string `query:"prefix" validate:"required,max=100"`
+	Limit  int    `query:"limit" validate:"omitempty,gte=1,lte=20"`
+}
+
+type ProductSuggestion struct {
+	Id   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+const (
+	DefaultStatsBuckets = 10
+	// MaxStatsBuckets caps the histogram, asked either by buckets or bucket_size
+	MaxStatsBuckets = 50
+)
+
+// ProductStatsQuery aggregates the products priced in Currency, the default
+// currency when not sent, prices of different currencies are never mixed.
+type ProductStatsQuery struct {
+	Buckets    int     `query:"buckets" validate:"omitempty,gte=1"`
+	BucketSize float64 `query:"bucket_size" validate:"omitempty,gt=0"`
+	Currency   string  `query:"currency" validate:"omitempty,currency"`
+	ProductFilterQuery
+}
+
+type ProductStatsResponse struct {
+	Count       int64                 `json:"count"`
+	MinPrice    money.Money           `json:"min_price"`
+	MaxPrice    money.Money           `json:"max_price"`
+	AvgPrice    money.Money           `json:"avg_price"`
+	MedianPrice money.Money           `json:"median_price"`
+	Histogram   []PriceBucketResponse `json:"histogram"`
+}
+
+type PriceBucketResponse struct {
+	From  money.Money `json:"from"`
+	To    money.Money `json:"to"`
+	Count int64       `json:"count"`
+}
+
+var ErrUnknownField = entity.Invalid("unknown_field", "unknown field")
+
+// ProductFields is the allow-list of fields accepted by the fields parameter.
+var ProductFields = []string{"id", "name", "description", "price", "created_at", "updated_at"}
+
+type ProductFieldsQuery struct {
+	Fields string `query:"fields"`
+}
+
+// ParseFields splits the fields parameter, a nil slice means every field.
+func (q ProductFieldsQuery) ParseFields() ([]string, error) {
+	var fields []string
+	for _, field := range strings.Split(q.Fields, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" || slices.Contains(fields, field) {
+			continue
+		}
+
+		if !slices.Contains(ProductFields, field) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownField, field)
+		}
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+// SparseProduct keeps only the requested fields of the product, the full
+// product is returned when no field was requested.
+func SparseProduct(product entity.Product, fields []string) interface{} {
+	if len(fields) == 0 {
+		return product
+	}
+
+	values := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		switch field {
+		case "id":
+			values[field] = product.ID
+		case "name":
+			values[field] = product.Name
+		case "description":
+			values[field] = product.Description
+		case "price":
+			values[field] = product.Price
+		case "created_at":
+			values[field] = product.CreatedAt
+		case "updated_at":
+			values[field] = product.UpdatedAt
+		}
+	}
+
+	return values
+}
+
+func SparseProducts(products []entity.Product, fields []string) interface{} {
+	if len(fields) == 0 {
+		return products
+	}
+
+	values := make([]interface{}, 0, len(products))
+	for _, product := range products {
+		values = append(values, SparseProduct(product, fields))
+	}
+
+	return values
 }
@@ -0,0 +1,62 @@
+package dto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/waldrey/eulabs/internal/entity"
+	"github.com/waldrey/eulabs/pkg/money"
+	"github.com/waldrey/eulabs/pkg/rsql"
+	"gorm.io/gorm"
+)
+
+func TestGivenKnownFields_WhenIParseFields_ThenShouldReceiveThemWithoutDuplicates(t *testing.T) {
+	fields, err := ProductFieldsQuery{Fields: "id, name,price,name"}.ParseFields()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"id", "name", "price"}, fields)
+}
+
+func TestGivenAnUnknownField_WhenIParseFields_ThenShouldReceiveAnError(t *testing.T) {
+	_, err := ProductFieldsQuery{Fields: "id,deleted_at"}.ParseFields()
+	assert.ErrorIs(t, err, ErrUnknownField)
+	assert.EqualError(t, err, "unknown field: deleted_at")
+}
+
+func TestGivenRequestedFields_WhenICallSparseProduct_ThenShouldReceiveOnlyThoseFields(t *testing.T) {
+	product := entity.Product{Model: gorm.Model{ID: 7}, Name: "Macbook Pro", Description: "O poderoso computador da Apple", Price: money.New(2300000, "BRL")}
+
+	assert.Equal(t, map[string]interface{}{
+		"id":    uint(7),
+		"name":  "Macbook Pro",
+		"price": money.New(2300000, "BRL"),
+	}, SparseProduct(product, []string{"id", "name", "price"}))
+	assert.Equal(t, product, SparseProduct(product, nil))
+}
+
+func TestGivenASortExpression_WhenIParseSort_ThenShouldReceiveTheFieldsInOrder(t *testing.T) {
+	assert.Equal(t, []SortField{
+		{Field: "price"},
+		{Field: "created_at", Desc: true},
+	}, ParseSort("price, -created_at,"))
+}
+
+func TestGivenAPriceFilter_WhenIParseFilter_ThenShouldOnlyCompareProductsInTheDefaultCurrency(t *testing.T) {
+	query := ProductFilterQuery{Filter: "price=gt=100"}
+	assert.NoError(t, query.ParseFilter())
+
+	sql, args, err := rsql.Where(query.Expression)
+	assert.NoError(t, err)
+	assert.Equal(t, "(price_currency = ? AND price_amount > ?)", sql)
+	assert.Equal(t, []interface{}{"BRL", int64(10000)}, args)
+}
+
+func TestGivenAPageQuery_WhenINormalizeIt_ThenShouldFillTheDefaultsAndCapThePageSize(t *testing.T) {
+	query := PageQuery{}
+	query.Normalize()
+	assert.Equal(t, PageQuery{Page: 1, PageSize: DefaultPageSize}, query)
+
+	query = PageQuery{Page: 3, PageSize: 500}
+	query.Normalize()
+	assert.Equal(t, PageQuery{Page: 3, PageSize: MaxPageSize}, query)
+	assert.Equal(t, 2*MaxPageSize, query.Offset())
+}
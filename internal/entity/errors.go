@@ -0,0 +1,108 @@
+package entity
+
+import "errors"
+
+// Kind classifies the domain errors by what the client can do about them,
+// the API answers each kind with its own status.
+type Kind int
+
+const (
+	// KindInvalid is a malformed request, like a patch that does not parse
+	KindInvalid Kind = iota + 1
+	KindNotFound
+	KindConflict
+	// KindValidation is a well formed request refused by the domain rules
+	KindValidation
+	// KindPrecondition is a write over a resource changed since it was read
+	KindPrecondition
+)
+
+// Error is the error returned by the services and repositories for the
+// failures a client may cause. Code is stable and machine readable, Message
+// is meant for people and may change.
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Fields  []FieldError
+	Err     error
+}
+
+// FieldError tells which field of a request was refused and why, Field is
+// the path of the field as sent, like rates[0].base. Param is the param of
+// the rule, like the 0 of gt=0. Message is left to the API when empty, it is
+// written from Code and Param in the language of the client.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+func NewError(kind Kind, code string, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func Invalid(code string, message string) *Error {
+	return NewError(KindInvalid, code, message)
+}
+
+func NotFound(code string, message string) *Error {
+	return NewError(KindNotFound, code, message)
+}
+
+func Conflict(code string, message string) *Error {
+	return NewError(KindConflict, code, message)
+}
+
+func Validation(code string, message string) *Error {
+	return NewError(KindValidation, code, message)
+}
+
+func Precondition(code string, message string) *Error {
+	return NewError(KindPrecondition, code, message)
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches the errors of the same code, so a copy made by Wrap or
+// WithFields is still the sentinel it was made from.
+func (e *Error) Is(target error) bool {
+	other, ok := target.(*Error)
+	return ok && other.Code == e.Code
+}
+
+// Wrap returns a copy of the error caused by err, errors.Is matches both.
+func (e *Error) Wrap(err error) *Error {
+	wrapped := *e
+	wrapped.Err = err
+	return &wrapped
+}
+
+// WithFields returns a copy of the error refusing the fields.
+func (e *Error) WithFields(fields ...FieldError) *Error {
+	copied := *e
+	copied.Fields = append(append([]FieldError(nil), e.Fields...), fields...)
+	return &copied
+}
+
+// KindOf returns the kind of the domain error in the chain of err, zero when
+// there is none.
+func KindOf(err error) Kind {
+	var domainErr *Error
+	if errors.As(err, &domainErr) {
+		return domainErr.Kind
+	}
+
+	return 0
+}
@@ -0,0 +1,17 @@
+package entity
+
+import "time"
+
+// IdempotencyKey records the first request sent with an Idempotency-Key and
+// the response given to it. StatusCode is zero while that request is still
+// being handled.
+type IdempotencyKey struct {
+	ID          uint   `gorm:"primarykey"`
+	Key         string `gorm:"column:idempotency_key;size:255;uniqueIndex"`
+	Fingerprint string `gorm:"size:64"`
+	StatusCode  int    `gorm:"not null;default:0"`
+	Headers     string `gorm:"type:text"`
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time `gorm:"index"`
+}
@@ -0,0 +1,37 @@
+package entity
+
+import "time"
+
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+var ErrJobNotFound = NotFound("job_not_found", "job not found")
+
+// Job is a long import or export run in the background. Params holds the
+// JSON parameters of its kind, the uploaded input and the result are files
+// kept by the runner.
+type Job struct {
+	ID         uint       `json:"id" gorm:"primarykey"`
+	Kind       string     `json:"kind" gorm:"size:32"`
+	Status     string     `json:"status" gorm:"size:16;index"`
+	Params     string     `json:"-" gorm:"type:text"`
+	HasInput   bool       `json:"-"`
+	ResultType string     `json:"-" gorm:"size:255"`
+	ResultName string     `json:"-" gorm:"size:255"`
+	Progress   int64      `json:"progress"`
+	Summary    string     `json:"-" gorm:"type:text"`
+	Error      string     `json:"error,omitempty" gorm:"type:text"`
+	Attempts   int        `json:"attempts"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+func (j *Job) Finished() bool {
+	return j.Status == JobSucceeded || j.Status == JobFailed
+}
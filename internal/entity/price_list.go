@@ -0,0 +1,26 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/waldrey/eulabs/pkg/money"
+	"gorm.io/gorm"
+)
+
+// ProductPrice is an explicit price of the product in a currency other than
+// its own, it wins over converting the product price by an exchange rate.
+type ProductPrice struct {
+	gorm.Model `swaggerignore:"true"`
+	ProductID  uint        `json:"product_id"`
+	Price      money.Money `json:"price" gorm:"embedded;embeddedPrefix:price_"`
+}
+
+// ExchangeRate converts one unit of the base currency into Rate units of the
+// quote currency, the rate is kept as a decimal string to stay exact.
+type ExchangeRate struct {
+	gorm.Model `swaggerignore:"true"`
+	Base       string    `json:"base" gorm:"size:3;uniqueIndex:idx_exchange_rates_pair"`
+	Quote      string    `json:"quote" gorm:"size:3;uniqueIndex:idx_exchange_rates_pair"`
+	Rate       string    `json:"rate" gorm:"type:decimal(24,12)"`
+	RatedAt    time.Time `json:"rated_at"`
+}
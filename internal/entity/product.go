@@ -1,25 +1,37 @@
 package entity
 
 import (
-	"errors"
-
+	"github.com/waldrey/eulabs/pkg/money"
 	"gorm.io/gorm"
 )
 
 var (
-	ErrInvalidName        = errors.New("invalid name")
-	ErrInvalidDescription = errors.New("invalid description")
-	ErrInvalidPrice       = errors.New("invalid price")
+	ErrProductNotFound    = NotFound("product_not_found", "product not found")
+	ErrInvalidName        = invalidField("invalid_name", "invalid name", "name", "required", "")
+	ErrInvalidDescription = invalidField("invalid_description", "invalid description", "description", "required", "")
+	ErrInvalidPrice       = invalidField("invalid_price", "invalid price", "price", "gt", "0")
+	ErrInvalidCurrency    = invalidField("invalid_currency", "invalid currency", "price.currency", "currency", "")
+	ErrVersionConflict    = Precondition("version_conflict", "product version conflict")
 )
 
+// invalidField refuses the field by the validation tag it breaks, its message
+// is written by the API.
+func invalidField(code string, message string, field string, tag string, param string) *Error {
+	return Validation(code, message).WithFields(FieldError{Field: field, Code: tag, Param: param})
+}
+
 type Product struct {
 	gorm.Model  `swaggerignore:"true"`
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Price       money.Money `json:"price" gorm:"embedded;embeddedPrefix:price_"`
+
+	// Version is bumped by every write, a write made over an older version
+	// fails with ErrVersionConflict
+	Version uint `json:"version" gorm:"not null;default:1"`
 }
 
-func NewProduct(name string, description string, price float64) (*Product, error) {
+func NewProduct(name string, description string, price money.Money) (*Product, error) {
 	product := &Product{
 		Name:        name,
 		Description: description,
@@ -43,9 +55,13 @@ func (p *Product) IsValid() error {
 		return ErrInvalidDescription
 	}
 
-	if p.Price <= 0 {
+	if p.Price.Amount <= 0 {
 		return ErrInvalidPrice
 	}
 
+	if !money.IsValidCurrency(p.Price.Currency) {
+		return ErrInvalidCurrency
+	}
+
 	return nil
 }
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/waldrey/eulabs/pkg/money"
 )
 
 func TestGivenAnEmptyPrice_WhenCreateANewProduct_ThenShouldReceiveAnError(t *testing.T) {
@@ -27,12 +28,12 @@ func TestGivenAnEmptyDescription_WhenCreateANewProduct_ThenShouldReceiveAnError(
 }
 
 func TestGivenAnEmptyDescription_WhenICallNewProductFunc_ThenShouldReceiveAnError(t *testing.T) {
-	_, err := NewProduct("Lego McLaren F1", "", 1400.00)
+	_, err := NewProduct("Lego McLaren F1", "", money.New(140000, "BRL"))
 	assert.Error(t, err, "invalid description")
 }
 
 func TestGivenAnEmptyName_WhenICallNewProductFunc_ThenShouldReceiveAnError(t *testing.T) {
-	_, err := NewProduct("", "A poderosa McLaren F1 de 2022", 1400.00)
+	_, err := NewProduct("", "A poderosa McLaren F1 de 2022", money.New(140000, "BRL"))
 	assert.Error(t, err, "invalid name")
 }
 
@@ -40,17 +41,22 @@ func TestGivenAValidParams_WhenICallNewProduct_ThenShouldReceiveCreateProductWit
 	product := Product{
 		Name:        "Macbook Pro",
 		Description: "O poderoso computador da Apple",
-		Price:       23000.00,
+		Price:       money.New(2300000, "BRL"),
 	}
-	assert.Equal(t, 23000.0, product.Price)
+	assert.Equal(t, money.New(2300000, "BRL"), product.Price)
 	assert.Equal(t, "O poderoso computador da Apple", product.Description)
 	assert.Nil(t, product.IsValid())
 }
 
 func TestGivenAValidParams_WhenICallNewProductFunc_ThenShouldReceiveCreateProductWithAllParams(t *testing.T) {
-	product, err := NewProduct("Lego McLaren F1", "A poderosa McLaren F1 de 2022", 1400.00)
+	product, err := NewProduct("Lego McLaren F1", "A poderosa McLaren F1 de 2022", money.New(140000, "BRL"))
 	assert.Nil(t, err)
 	assert.Equal(t, "Lego McLaren F1", product.Name)
 	assert.Equal(t, "A poderosa McLaren F1 de 2022", product.Description)
-	assert.Equal(t, 1400.00, product.Price)
+	assert.Equal(t, money.New(140000, "BRL"), product.Price)
+}
+
+func TestGivenAnUnknownCurrency_WhenICallNewProductFunc_ThenShouldReceiveAnError(t *testing.T) {
+	_, err := NewProduct("Lego McLaren F1", "A poderosa McLaren F1 de 2022", money.New(140000, "XYZ"))
+	assert.ErrorIs(t, err, ErrInvalidCurrency)
 }
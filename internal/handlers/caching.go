@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/waldrey/eulabs/internal/dto"
+	"github.com/waldrey/eulabs/internal/entity"
+	"github.com/waldrey/eulabs/pkg/requests"
+)
+
+// notModified writes the caching headers of a product read and tells whether
+// the copy held by the client is still current.
+func (h *ProductHandler) notModified(c echo.Context, validators dto.Validators) bool {
+	header := c.Response().Header()
+	if h.CacheControl != "" {
+		header.Set("Cache-Control", h.CacheControl)
+	}
+	requests.AddVary(header, "Accept", "Accept-Currency")
+
+	if validators.ETag != "" {
+		header.Set("ETag", validators.ETag)
+	}
+
+	if !validators.LastModified.IsZero() {
+		header.Set("Last-Modified", validators.LastModified.UTC().Format(http.TimeFormat))
+	}
+
+	return requests.NotModified(c.Request().Header, validators.ETag, validators.LastModified)
+}
+
+func productValidators(product entity.Product) dto.Validators {
+	return dto.Validators{
+		ETag:         productETag(product),
+		LastModified: product.UpdatedAt,
+	}
+}
+
+// convertedValidators revalidates reads with converted prices, they also
+// depend on the exchange rates so the weak tag hashes the response itself.
+func convertedValidators(response interface{}) dto.Validators {
+	body, err := json.Marshal(response)
+	if err != nil {
+		return dto.Validators{}
+	}
+
+	sum := sha256.Sum256(body)
+	return dto.Validators{ETag: `W/"` + hex.EncodeToString(sum[:16]) + `"`}
+}
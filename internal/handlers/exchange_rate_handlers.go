@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/waldrey/eulabs/internal/dto"
+	"github.com/waldrey/eulabs/internal/infra/service"
+	"github.com/waldrey/eulabs/pkg/requests"
+	"github.com/waldrey/eulabs/tools"
+)
+
+type ExchangeRateHandler struct {
+	PriceList service.PriceListInterface
+	Validator *validator.Validate
+}
+
+func NewExchangeRateHandler(priceList service.PriceListInterface) *ExchangeRateHandler {
+	return &ExchangeRateHandler{
+		PriceList: priceList,
+		Validator: tools.NewValidator(),
+	}
+}
+
+// List Exchange Rates godoc
+// @Summary      List exchange rates
+// @Description  Exchange rates used to convert product prices without an explicit price in the requested currency
+// @Tags         Exchange Rates
+// @Accept       json
+// @Produce      json,xml,application/msgpack,text/csv
+// @Success      200       {object}  requests.TypeSuccessResponse
+// @Failure      500       {object}  requests.Problem
+// @Router       /exchange-rates [get]
+func (h *ExchangeRateHandler) List(c echo.Context) error {
+	rates, err := h.PriceList.ExchangeRates(c.Request().Context())
+	if err != nil {
+		log.Print("Unknown error getting exchange rates in database")
+		return err
+	}
+
+	successResponse := requests.SuccessDataResponse(rates)
+	return requests.Render(c, http.StatusOK, successResponse)
+}
+
+// Upload Exchange Rates godoc
+// @Summary      Upload exchange rates
+// @Description  Insert or replace exchange rates, one unit of base is worth rate units of quote. Requires the admin API key.
+// @Tags         Exchange Rates
+// @Accept       json,xml,application/msgpack
+// @Produce      json,xml,application/msgpack
+// @Param        Authorization  header    string  true  "Bearer admin API key"
+// @Param        request     body      dto.SaveExchangeRatesRequest  true  "exchange rates request"
+// @Success      200       {object}  requests.TypeSuccessResponse
+// @Failure      400       {object}  requests.Problem
+// @Failure      401       {object}  requests.Problem
+// @Failure      413       {object}  requests.Problem
+// @Failure      422       {object}  requests.Problem
+// @Failure      500       {object}  requests.Problem
+// @Router       /exchange-rates [put]
+func (h *ExchangeRateHandler) Upload(c echo.Context) error {
+	log.Print("PUT exchange rates request initialization")
+
+	var request dto.SaveExchangeRatesRequest
+	if err := c.Bind(&request); err != nil {
+		return invalidBody(err)
+	}
+
+	if err := h.Validator.Struct(request); err != nil {
+		return err
+	}
+
+	rates, err := h.PriceList.SaveExchangeRates(c.Request().Context(), request)
+	if err != nil {
+		log.Print("Unknown error saving exchange rates in database")
+		return err
+	}
+
+	log.Print("PUT exchange rates request finished")
+	successResponse := requests.SuccessDataResponse(rates)
+	return requests.Render(c, http.StatusOK, successResponse)
+}
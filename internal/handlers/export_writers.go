@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/waldrey/eulabs/internal/dto"
+	"github.com/waldrey/eulabs/internal/entity"
+	"github.com/waldrey/eulabs/pkg/xlsx"
+)
+
+// exportWriter writes the products of an export in one of its formats, Flush
+// sends what was written so far and Close completes the file.
+type exportWriter interface {
+	Write(product entity.Product) error
+	Flush() error
+	Close() error
+}
+
+// exportFormats are the media type and file extension of each format.
+var exportFormats = map[string]struct{ mediaType, extension string }{
+	dto.ExportCSV:    {MIMETextCSV + "; charset=utf-8", ".csv"},
+	dto.ExportNDJSON: {MIMEApplicationNDJSON, ".ndjson"},
+	dto.ExportXLSX:   {"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", ".xlsx"},
+}
+
+func newExportWriter(w io.Writer, format string) (exportWriter, error) {
+	switch format {
+	case dto.ExportCSV:
+		writer := csv.NewWriter(w)
+		return &csvExport{writer: writer}, writer.Write(dto.ExportColumns)
+	case dto.ExportXLSX:
+		writer, err := xlsx.NewWriter(w, "Products")
+		if err != nil {
+			return nil, err
+		}
+		return &xlsxExport{writer: writer}, writer.WriteRow(exportHeader()...)
+	}
+
+	return &ndjsonExport{encoder: json.NewEncoder(w)}, nil
+}
+
+// exportRecord is the product in the order of dto.ExportColumns.
+func exportRecord(product entity.Product) []string {
+	return []string{
+		strconv.FormatUint(uint64(product.ID), 10),
+		escapeFormula(product.Name),
+		escapeFormula(product.Description),
+		product.Price.String(),
+		product.Price.Currency,
+		strconv.FormatUint(uint64(product.Version), 10),
+		product.CreatedAt.UTC().Format(time.RFC3339),
+		product.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// formulaPrefixes start the cells a spreadsheet evaluates as a formula.
+const formulaPrefixes = "=+-@"
+
+// escapeFormula quotes a text cell that would be evaluated as a formula when
+// the CSV is opened in a spreadsheet, unescapeFormula removes the quote again
+// on import. The xlsx cells are typed as text and need no quoting.
+func escapeFormula(value string) string {
+	if value != "" && strings.ContainsRune(formulaPrefixes, rune(value[0])) {
+		return "'" + value
+	}
+
+	return value
+}
+
+func unescapeFormula(value string) string {
+	if len(value) > 1 && value[0] == '\'' && strings.ContainsRune(formulaPrefixes, rune(value[1])) {
+		return value[1:]
+	}
+
+	return value
+}
+
+func exportHeader() []interface{} {
+	header := make([]interface{}, 0, len(dto.ExportColumns))
+	for _, column := range dto.ExportColumns {
+		header = append(header, column)
+	}
+
+	return header
+}
+
+type csvExport struct {
+	writer *csv.Writer
+}
+
+func (e *csvExport) Write(product entity.Product) error {
+	return e.writer.Write(exportRecord(product))
+}
+
+func (e *csvExport) Flush() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+func (e *csvExport) Close() error {
+	return e.Flush()
+}
+
+type ndjsonExport struct {
+	encoder *json.Encoder
+}
+
+func (e *ndjsonExport) Write(product entity.Product) error {
+	return e.encoder.Encode(product)
+}
+
+func (e *ndjsonExport) Flush() error {
+	return nil
+}
+
+func (e *ndjsonExport) Close() error {
+	return nil
+}
+
+type xlsxExport struct {
+	writer *xlsx.Writer
+}
+
+func (e *xlsxExport) Write(product entity.Product) error {
+	return e.writer.WriteRow(
+		product.ID,
+		product.Name,
+		product.Description,
+		xlsx.Number(product.Price.String()),
+		product.Price.Currency,
+		product.Version,
+		product.CreatedAt.UTC(),
+		product.UpdatedAt.UTC(),
+	)
+}
+
+func (e *xlsxExport) Flush() error {
+	return e.writer.Flush()
+}
+
+func (e *xlsxExport) Close() error {
+	return e.writer.Close()
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/waldrey/eulabs/internal/dto"
+	"github.com/waldrey/eulabs/internal/entity"
+	"github.com/waldrey/eulabs/pkg/money"
+	"gorm.io/gorm"
+)
+
+func exportedProduct() entity.Product {
+	createdAt := time.Date(2024, 5, 2, 12, 0, 0, 0, time.UTC)
+	return entity.Product{
+		Model:       gorm.Model{ID: 1, CreatedAt: createdAt, UpdatedAt: createdAt},
+		Name:        "Macbook Pro",
+		Description: "O poderoso computador da Apple, 2024",
+		Price:       money.New(2300000, "BRL"),
+		Version:     3,
+	}
+}
+
+func TestGivenProducts_WhenIExportThemAsCSV_ThenShouldWriteTheHeaderAndOneRecordEach(t *testing.T) {
+	var buffer bytes.Buffer
+	writer, err := newExportWriter(&buffer, dto.ExportCSV)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Write(exportedProduct()))
+	assert.NoError(t, writer.Close())
+
+	assert.Equal(t, "id,name,description,price,currency,version,created_at,updated_at\n"+
+		"1,Macbook Pro,\"O poderoso computador da Apple, 2024\",23000.00,BRL,3,2024-05-02T12:00:00Z,2024-05-02T12:00:00Z\n", buffer.String())
+}
+
+func TestGivenAFormulaInAText_WhenIExportItAsCSV_ThenShouldQuoteItAndImportItBack(t *testing.T) {
+	product := exportedProduct()
+	product.Name = "=HYPERLINK(\"http://evil.example\")"
+	product.Description = "-10% no Pix"
+
+	var buffer bytes.Buffer
+	writer, err := newExportWriter(&buffer, dto.ExportCSV)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Write(product))
+	assert.NoError(t, writer.Close())
+
+	assert.Contains(t, buffer.String(), "\n1,\"'=HYPERLINK(\"\"http://evil.example\"\")\",'-10% no Pix,23000.00,")
+
+	source, err := newCSVRows(&buffer)
+	assert.NoError(t, err)
+
+	rows := readRows(t, source)
+	assert.Len(t, rows, 1)
+	assert.Equal(t, product.Name, rows[0].Product.Name)
+	assert.Equal(t, product.Description, rows[0].Product.Description)
+}
+
+func TestGivenProducts_WhenIExportThemAsNDJSON_ThenShouldWriteOneProductPerLine(t *testing.T) {
+	var buffer bytes.Buffer
+	writer, err := newExportWriter(&buffer, dto.ExportNDJSON)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Write(exportedProduct()))
+	assert.NoError(t, writer.Write(exportedProduct()))
+	assert.NoError(t, writer.Close())
+
+	lines := bytes.Split(bytes.TrimSuffix(buffer.Bytes(), []byte("\n")), []byte("\n"))
+	assert.Len(t, lines, 2)
+	assert.Contains(t, string(lines[0]), `"price":{"amount":"23000.00","currency":"BRL"}`)
+}
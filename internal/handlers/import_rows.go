@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	"github.com/waldrey/eulabs/internal/dto"
+	"github.com/waldrey/eulabs/pkg/money"
+	"github.com/waldrey/eulabs/tools"
+)
+
+// ErrInvalidUpload is returned when an import file cannot be read any further,
+// unlike the row errors which only skip the row.
+var ErrInvalidUpload = errors.New("invalid upload")
+
+// MaxImportLineSize is the longest NDJSON line accepted.
+const MaxImportLineSize = 1 << 20
+
+// importColumns are the CSV columns of an import, the currency column is
+// optional and defaults to money.DefaultCurrency. The other columns of an
+// export are accepted and ignored, so an export can be imported back.
+var importColumns = []string{"name", "description", "price", "currency"}
+
+// csvRows reads the products of a CSV file with a header row, the price is in
+// the major unit of its currency.
+type csvRows struct {
+	reader  *csv.Reader
+	columns map[string]int
+	fields  int
+}
+
+func newCSVRows(r io.Reader) (*csvRows, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: missing header", ErrInvalidUpload)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidUpload, err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if !slices.Contains(importColumns, name) && !slices.Contains(dto.ExportColumns, name) {
+			return nil, fmt.Errorf("%w: unknown column %q", ErrInvalidUpload, name)
+		}
+
+		if _, ok := columns[name]; ok {
+			return nil, fmt.Errorf("%w: repeated column %q", ErrInvalidUpload, name)
+		}
+		columns[name] = i
+	}
+
+	for _, name := range importColumns[:3] {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("%w: missing column %q", ErrInvalidUpload, name)
+		}
+	}
+
+	return &csvRows{reader: reader, columns: columns, fields: len(header)}, nil
+}
+
+func (r *csvRows) Next() (dto.ImportRow, error) {
+	record, err := r.reader.Read()
+
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return rowError(parseErr.StartLine, "", parseErr.Err.Error()), nil
+	}
+	if errors.Is(err, io.EOF) {
+		return dto.ImportRow{}, io.EOF
+	}
+	if err != nil {
+		return dto.ImportRow{}, fmt.Errorf("%w: %v", ErrInvalidUpload, err)
+	}
+
+	line, _ := r.reader.FieldPos(0)
+	if len(record) != r.fields {
+		return rowError(line, "", fmt.Sprintf("expected %d fields, found %d", r.fields, len(record))), nil
+	}
+
+	row := dto.ImportRow{Line: line}
+	row.Product.Name = unescapeFormula(strings.TrimSpace(record[r.columns["name"]]))
+	row.Product.Description = unescapeFormula(strings.TrimSpace(record[r.columns["description"]]))
+
+	currency := money.DefaultCurrency
+	if i, ok := r.columns["currency"]; ok && strings.TrimSpace(record[i]) != "" {
+		currency = strings.TrimSpace(record[i])
+	}
+
+	row.Product.Price, err = money.Parse(strings.TrimSpace(record[r.columns["price"]]), currency)
+	if errors.Is(err, money.ErrInvalidCurrency) {
+		row.Errors = append(row.Errors, dto.ImportRowError{Line: line, Field: "currency", Message: err.Error()})
+	} else if err != nil {
+		row.Errors = append(row.Errors, dto.ImportRowError{Line: line, Field: "price", Message: err.Error()})
+	}
+
+	return row, nil
+}
+
+// ndjsonRows reads one dto.CreateProductRequest per line, blank lines are
+// skipped.
+type ndjsonRows struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func newNDJSONRows(r io.Reader) *ndjsonRows {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), MaxImportLineSize)
+
+	return &ndjsonRows{scanner: scanner}
+}
+
+func (r *ndjsonRows) Next() (dto.ImportRow, error) {
+	for r.scanner.Scan() {
+		r.line++
+		text := bytes.TrimSpace(r.scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+
+		row := dto.ImportRow{Line: r.line}
+		if err := json.Unmarshal(text, &row.Product); err != nil {
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &typeErr) {
+				return rowError(r.line, typeErr.Field, err.Error()), nil
+			}
+			return rowError(r.line, "", err.Error()), nil
+		}
+
+		return row, nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return dto.ImportRow{}, fmt.Errorf("%w: line %d: %v", ErrInvalidUpload, r.line+1, err)
+	}
+
+	return dto.ImportRow{}, io.EOF
+}
+
+func rowError(line int, field string, message string) dto.ImportRow {
+	return dto.ImportRow{
+		Line:   line,
+		Errors: []dto.ImportRowError{{Line: line, Field: field, Message: message}},
+	}
+}
+
+// validatedRows validates the products read by the source like the body of a
+// POST, reporting one error per invalid field in the language of the
+// translator.
+type validatedRows struct {
+	source     dto.ImportSource
+	validator  *validator.Validate
+	translator ut.Translator
+}
+
+func (r *validatedRows) Next() (dto.ImportRow, error) {
+	row, err := r.source.Next()
+	if err != nil || len(row.Errors) > 0 {
+		return row, err
+	}
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(r.validator.Struct(row.Product), &validationErrors) {
+		return row, nil
+	}
+
+	for _, fieldErr := range validationErrors {
+		row.Errors = append(row.Errors, dto.ImportRowError{
+			Line:    row.Line,
+			Field:   fieldErr.Field(),
+			Message: tools.FormatValidationError(validator.ValidationErrors{fieldErr}, r.translator)[0],
+		})
+	}
+
+	return row, nil
+}
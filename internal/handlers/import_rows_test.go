@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/waldrey/eulabs/internal/dto"
+	"github.com/waldrey/eulabs/pkg/money"
+	"github.com/waldrey/eulabs/tools"
+)
+
+func readRows(t *testing.T, source dto.ImportSource) []dto.ImportRow {
+	var rows []dto.ImportRow
+	for {
+		row, err := source.Next()
+		if errors.Is(err, io.EOF) {
+			return rows
+		}
+		assert.NoError(t, err)
+		rows = append(rows, row)
+	}
+}
+
+func TestGivenACSVFile_WhenIReadItsRows_ThenShouldReportTheInvalidFieldsByLine(t *testing.T) {
+	file := "\ufeffName,price,description,currency\n" +
+		"Macbook Pro,23000.00,O poderoso computador da Apple,\n" +
+		"\"Kindle\nPaperwhite\",99.99,O leitor da Amazon,usd\n" +
+		"iPhone 15,10000.123,,\n" +
+		"AirPods,1299.00\n"
+
+	source, err := newCSVRows(strings.NewReader(file))
+	assert.NoError(t, err)
+
+	rows := readRows(t, &validatedRows{source: source, validator: tools.NewValidator()})
+	assert.Len(t, rows, 4)
+
+	assert.Empty(t, rows[0].Errors)
+	assert.Equal(t, dto.CreateProductRequest{
+		Name: "Macbook Pro", Description: "O poderoso computador da Apple", Price: money.New(2300000, "BRL"),
+	}, rows[0].Product)
+
+	assert.Empty(t, rows[1].Errors)
+	assert.Equal(t, money.New(9999, "USD"), rows[1].Product.Price)
+
+	assert.Equal(t, []dto.ImportRowError{{Line: 5, Field: "price", Message: money.ErrInvalidAmount.Error()}}, rows[2].Errors)
+	assert.Equal(t, []dto.ImportRowError{{Line: 6, Message: "expected 4 fields, found 2"}}, rows[3].Errors)
+}
+
+func TestGivenAnExportedCSVFile_WhenIReadItsRows_ThenShouldIgnoreTheExportOnlyColumns(t *testing.T) {
+	file := strings.Join(dto.ExportColumns, ",") + "\n" +
+		"1,Macbook Pro,O poderoso computador da Apple,23000.00,BRL,3,2024-05-02T12:00:00Z,2024-05-02T12:00:00Z\n"
+
+	source, err := newCSVRows(strings.NewReader(file))
+	assert.NoError(t, err)
+
+	rows := readRows(t, source)
+	assert.Len(t, rows, 1)
+	assert.Empty(t, rows[0].Errors)
+	assert.Equal(t, money.New(2300000, "BRL"), rows[0].Product.Price)
+}
+
+func TestGivenAnUnknownColumn_WhenIReadACSVFile_ThenShouldReceiveAnInvalidUpload(t *testing.T) {
+	_, err := newCSVRows(strings.NewReader("name,description,price,stock\n"))
+	assert.ErrorIs(t, err, ErrInvalidUpload)
+
+	_, err = newCSVRows(strings.NewReader("name,description\n"))
+	assert.ErrorIs(t, err, ErrInvalidUpload)
+}
+
+func TestGivenANDJSONFile_WhenIReadItsRows_ThenShouldValidateEachLine(t *testing.T) {
+	file := `{"name": "Macbook Pro", "description": "O poderoso computador da Apple", "price": {"amount": "23000.00", "currency": "BRL"}}
+
+{"name": "Kindle", "price": 99.99}
+{"name": 10}
+`
+
+	rows := readRows(t, &validatedRows{source: newNDJSONRows(strings.NewReader(file)), validator: tools.NewValidator()})
+	assert.Len(t, rows, 3)
+
+	assert.Empty(t, rows[0].Errors)
+	assert.Equal(t, 1, rows[0].Line)
+	assert.Equal(t, []dto.ImportRowError{
+		{Line: 3, Field: "description", Message: "the field 'description' is required"},
+	}, rows[1].Errors)
+	assert.Equal(t, 4, rows[2].Errors[0].Line)
+	assert.Equal(t, "name", rows[2].Errors[0].Field)
+}
+
+func TestGivenAQuotedFormula_WhenIReadACSVFile_ThenShouldRemoveTheQuote(t *testing.T) {
+	file := "name,description,price\n" +
+		"'=SUM(A1:A2),'It's fine,10.00\n"
+
+	source, err := newCSVRows(strings.NewReader(file))
+	assert.NoError(t, err)
+
+	rows := readRows(t, source)
+	assert.Len(t, rows, 1)
+	assert.Equal(t, "=SUM(A1:A2)", rows[0].Product.Name)
+	assert.Equal(t, "'It's fine", rows[0].Product.Description)
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/waldrey/eulabs/internal/dto"
+	"github.com/waldrey/eulabs/internal/entity"
+	"github.com/waldrey/eulabs/internal/infra/service"
+	"github.com/waldrey/eulabs/pkg/requests"
+	"github.com/waldrey/eulabs/tools"
+)
+
+type JobHandler struct {
+	Jobs service.JobInterface
+}
+
+func NewJobHandler(jobs service.JobInterface) *JobHandler {
+	return &JobHandler{Jobs: jobs}
+}
+
+// Get Job godoc
+// @Summary      Get job
+// @Description  Status, progress (rows processed), summary and error of a background import or export, the result URL is set once it succeeded
+// @Tags         Jobs
+// @Produce      json,xml,application/msgpack
+// @Param        id   path      string  true  "job ID" Format(int)
+// @Success      200       {object}  requests.TypeSuccessResponse
+// @Failure      400       {object}  requests.Problem
+// @Failure      404       {object}  requests.Problem
+// @Failure      500       {object}  requests.Problem
+// @Router       /jobs/{id} [get]
+func (h *JobHandler) Find(c echo.Context) error {
+	id, err := tools.ValidateRequest(c)
+	if err != nil {
+		return err
+	}
+
+	job, err := h.Jobs.Find(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	successResponse := requests.SuccessDataResponse(jobResponse(*job))
+	return requests.Render(c, http.StatusOK, successResponse)
+}
+
+// Get Job Result godoc
+// @Summary      Download job result
+// @Description  The exported file or the import report of a succeeded job
+// @Tags         Jobs
+// @Produce      octet-stream
+// @Param        id   path      string  true  "job ID" Format(int)
+// @Success      200
+// @Failure      400       {object}  requests.Problem
+// @Failure      404       {object}  requests.Problem
+// @Failure      409       {object}  requests.Problem
+// @Failure      500       {object}  requests.Problem
+// @Router       /jobs/{id}/result [get]
+func (h *JobHandler) Result(c echo.Context) error {
+	id, err := tools.ValidateRequest(c)
+	if err != nil {
+		return err
+	}
+
+	job, err := h.Jobs.Find(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	file, err := h.Jobs.Result(job)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", job.ResultName))
+	return c.Stream(http.StatusOK, job.ResultType, file)
+}
+
+// acceptJob answers a request run as a job with 202, pointing to its status.
+func acceptJob(c echo.Context, job *entity.Job) error {
+	c.Response().Header().Set(echo.HeaderLocation, jobURL(job.ID))
+	successResponse := requests.SuccessDataResponse(jobResponse(*job))
+	return requests.Render(c, http.StatusAccepted, successResponse)
+}
+
+func jobResponse(job entity.Job) dto.JobResponse {
+	response := dto.JobResponse{Job: job}
+	if job.Summary != "" {
+		response.Summary = json.RawMessage(job.Summary)
+	}
+
+	if job.Status == entity.JobSucceeded {
+		response.ResultURL = jobURL(job.ID) + "/result"
+	}
+
+	return response
+}
+
+func jobURL(id uint) string {
+	return fmt.Sprintf("/api/v1/jobs/%d", id)
+}
+
+// jobError reports a stopped runner as unavailable, the other errors of the
+// runner are domain errors.
+func jobError(err error) error {
+	if errors.Is(err, service.ErrJobRunnerStopped) {
+		return errShuttingDown
+	}
+
+	return err
+}
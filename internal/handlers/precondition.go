@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/waldrey/eulabs/internal/dto"
+	"github.com/waldrey/eulabs/internal/entity"
+)
+
+// productETag is the strong entity tag of the product, its version.
+func productETag(product entity.Product) string {
+	return `"` + strconv.FormatUint(uint64(product.Version), 10) + `"`
+}
+
+// precondition reads the If-Match header of a write, it is not ok when the
+// header is required and missing.
+func (h *ProductHandler) precondition(c echo.Context) (dto.Precondition, bool) {
+	header := strings.TrimSpace(c.Request().Header.Get("If-Match"))
+	if header == "" {
+		return dto.AnyVersion, !h.RequireIfMatch
+	}
+
+	if header == "*" {
+		return dto.AnyVersion, true
+	}
+
+	// If-Match uses the strong comparison, weak and foreign tags never match
+	var precondition dto.Precondition
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if len(tag) < 2 || !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) {
+			continue
+		}
+
+		version, err := strconv.ParseUint(tag[1:len(tag)-1], 10, 64)
+		if err == nil {
+			precondition.Versions = append(precondition.Versions, uint(version))
+		}
+	}
+
+	return precondition, true
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/waldrey/eulabs/pkg/requests"
+)
+
+// Problems of the HTTP layer, the domain ones come from the services as
+// entity.Error.
+var (
+	errInvalidAcceptCurrency = requests.NewProblem(http.StatusBadRequest, "invalid_accept_currency", "invalid Accept-Currency header")
+	errInvalidCursor         = requests.NewProblem(http.StatusBadRequest, "invalid_cursor", "invalid cursor")
+	errCursorSort            = requests.NewProblem(http.StatusBadRequest, "cursor_sort_unsupported", "sort is not supported with cursor")
+	errPreconditionRequired  = requests.NewProblem(http.StatusPreconditionRequired, "precondition_required", "If-Match header is required")
+	errShuttingDown          = requests.NewProblem(http.StatusServiceUnavailable, "shutting_down", "server is shutting down")
+)
+
+// invalidQuery is the problem of query parameters that could not be bound.
+func invalidQuery(err error) error {
+	return badRequest("invalid_query", "invalid query parameters", err)
+}
+
+// invalidBody is the problem of a request body that could not be bound.
+func invalidBody(err error) error {
+	return badRequest("invalid_body", "invalid request body", err)
+}
+
+// invalidParameter is the problem of a query parameter that does not parse.
+func invalidParameter(name string) error {
+	return requests.NewProblem(http.StatusBadRequest, "invalid_parameter", "invalid "+name+" parameter").WithArgs(name)
+}
+
+// invalidFilter keeps the position of the error in the filter expression.
+func invalidFilter(err error) error {
+	return requests.NewProblem(http.StatusBadRequest, "invalid_filter", err.Error()).WithArgs(err.Error()).WithDetails(err)
+}
+
+// unsupportedMediaType is sent along with the header listing the media types
+// accepted instead, like Accept-Patch.
+func unsupportedMediaType(c echo.Context, header string, accepted string) error {
+	c.Response().Header().Set(header, accepted)
+	return requests.NewProblem(http.StatusUnsupportedMediaType, "unsupported_media_type", "unsupported media type, use one of "+accepted).WithArgs(accepted)
+}
+
+// badRequest describes a request that could not be bound, a body over the
+// limit of middlewares.BodyLimit stays a 413.
+func badRequest(code string, detail string, err error) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return requests.ErrBodyTooLarge
+	}
+
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		if message, ok := httpErr.Message.(string); ok {
+			detail += ": " + message
+		}
+	}
+
+	return requests.NewProblem(http.StatusBadRequest, code, detail)
+}
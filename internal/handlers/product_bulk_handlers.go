@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	ut "github.com/go-playground/universal-translator"
+	"github.com/labstack/echo/v4"
+	"github.com/waldrey/eulabs/internal/dto"
+	"github.com/waldrey/eulabs/internal/entity"
+	"github.com/waldrey/eulabs/internal/infra/service"
+	"github.com/waldrey/eulabs/pkg/i18n"
+	"github.com/waldrey/eulabs/pkg/requests"
+)
+
+// Bulk Products godoc
+// @Summary      Bulk create, update and delete products
+// @Description  Runs up to 1000 operations. Create takes the product of a POST, update the fields of a PATCH and a version works as the If-Match of an update or delete. With atomic=true nothing is written unless every operation succeeds, the failures are returned in the error details and the other operations get 424. Otherwise the valid operations are written and the status of each one is returned with 207.
+// @Tags         Products
+// @Accept       json,xml,application/msgpack
+// @Produce      json,xml,application/msgpack
+// @Param        atomic   query     bool                     false  "all or nothing"
+// @Param        request  body      dto.BulkProductsRequest  true   "operations"
+// @Success      200       {object}  requests.TypeSuccessResponse
+// @Success      207       {object}  requests.TypeSuccessResponse
+// @Failure      400       {object}  requests.Problem
+// @Failure      413       {object}  requests.Problem
+// @Failure      422       {object}  requests.Problem
+// @Failure      500       {object}  requests.Problem
+// @Router       /products/bulk [post]
+func (h *ProductHandler) Bulk(c echo.Context) error {
+	log.Print("POST bulk request initialization")
+
+	atomic, err := boolParam(c, "atomic")
+	if err != nil {
+		return invalidParameter("atomic")
+	}
+
+	var request dto.BulkProductsRequest
+	if err := c.Bind(&request); err != nil {
+		return invalidBody(err)
+	}
+
+	if err := h.Validator.Struct(request); err != nil {
+		return err
+	}
+
+	translator := i18n.FromContext(c)
+	results := make([]dto.BulkProductResult, len(request.Operations))
+	operations := make([]dto.BulkProductOperation, 0, len(request.Operations))
+	for i, operation := range request.Operations {
+		operation.Index = i
+		results[i] = h.validateBulkOperation(&operation, translator)
+		if results[i].Status == 0 {
+			operations = append(operations, operation)
+		}
+	}
+
+	if atomic && len(operations) < len(request.Operations) {
+		for _, operation := range operations {
+			results[operation.Index] = bulkFailure(results[operation.Index], service.ErrBulkNotApplied, translator)
+		}
+
+		return errBulkFailed.WithDetails(results)
+	}
+
+	written, err := h.Service.Bulk(c.Request().Context(), operations, atomic)
+	if err != nil {
+		log.Print("Unknown error writing products in database")
+		return err
+	}
+
+	failed := false
+	for _, result := range written {
+		results[result.Index] = bulkStatus(result, translator)
+		failed = failed || results[result.Index].Status >= http.StatusBadRequest
+	}
+
+	log.Print("POST bulk request finished")
+	if !atomic {
+		return requests.Render(c, http.StatusMultiStatus, requests.SuccessDataResponse(results))
+	}
+
+	if failed {
+		return errBulkFailed.WithDetails(results)
+	}
+	return requests.Render(c, http.StatusOK, requests.SuccessDataResponse(results))
+}
+
+var (
+	errBulkFailed          = requests.NewProblem(http.StatusUnprocessableEntity, "bulk_failed", "bulk request failed, nothing was written")
+	errBulkVersionRequired = requests.NewProblem(http.StatusPreconditionRequired, "version_required", "version is required")
+	errInvalidBulkProduct  = entity.Validation("invalid_product", "invalid product")
+)
+
+// validateBulkOperation decodes and validates the operation on its own, the
+// result has no status when it is valid.
+func (h *ProductHandler) validateBulkOperation(operation *dto.BulkProductOperation, translator ut.Translator) dto.BulkProductResult {
+	result := dto.BulkProductResult{Index: operation.Index, Op: operation.Op}
+
+	err := h.Validator.Struct(operation)
+	if err == nil {
+		if decodeErr := operation.DecodeProduct(); decodeErr != nil {
+			err = errInvalidBulkProduct.Wrap(decodeErr)
+		}
+	}
+	if err == nil && operation.Op == dto.BulkCreate {
+		err = h.Validator.Struct(operation.Create)
+	}
+	if err == nil && operation.Op == dto.BulkUpdate {
+		err = h.Validator.Struct(operation.Update)
+	}
+	if err == nil && h.RequireIfMatch && operation.Op != dto.BulkCreate && operation.Version == 0 {
+		err = errBulkVersionRequired
+	}
+
+	if err != nil {
+		return bulkFailure(result, err, translator)
+	}
+	return result
+}
+
+// bulkStatus is the status code of an operation run by the service, along
+// with the problem of a failed one.
+func bulkStatus(result dto.BulkProductResult, translator ut.Translator) dto.BulkProductResult {
+	switch {
+	case result.Err != nil:
+		return bulkFailure(result, result.Err, translator)
+	case result.Op == dto.BulkCreate:
+		result.Status = http.StatusCreated
+	case result.Op == dto.BulkDelete:
+		result.Status = http.StatusNoContent
+	default:
+		result.Status = http.StatusOK
+	}
+
+	return result
+}
+
+// bulkFailure describes the error of an operation like the problem of a
+// single request, except the operations left out of an atomic bulk which get
+// 424.
+func bulkFailure(result dto.BulkProductResult, err error, translator ut.Translator) dto.BulkProductResult {
+	problem := requests.ProblemFor(err).Localize(translator)
+	result.Status = problem.Status
+	if errors.Is(err, service.ErrBulkNotApplied) {
+		result.Status = http.StatusFailedDependency
+	}
+	result.Code = problem.Code
+	result.Error = problem.Detail
+	result.Errors = problem.Errors
+
+	return result
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/waldrey/eulabs/internal/dto"
+	"github.com/waldrey/eulabs/internal/entity"
+)
+
+// exportFlushRows is the number of rows sent in each chunk of an export.
+const exportFlushRows = 500
+
+// Export Products godoc
+// @Summary      Export products
+// @Description  Streams every product matching the listing filters as CSV, NDJSON or XLSX, read from the database as the file is sent. The CSV and XLSX columns are id, name, description, price (in the major unit), currency, version, created_at and updated_at, a CSV export can be imported back.
+// @Tags         Products
+// @Produce      text/csv
+// @Produce      application/x-ndjson
+// @Produce      application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param        format          query     string  true   "file format"  Enums(csv, ndjson, xlsx)
+// @Param        sort            query     string  false  "comma separated fields, prefix with - for descending (id, name, price, created_at, updated_at)"
+// @Param        min_price       query     number  false  "minimum price in the default currency"
+// @Param        max_price       query     number  false  "maximum price in the default currency"
+// @Param        name_contains   query     string  false  "part of the product name"
+// @Param        created_after   query     string  false  "RFC 3339 date time"
+// @Param        created_before  query     string  false  "RFC 3339 date time"
+// @Param        filter          query     string  false  "RSQL expression, e.g. price=gt=100;name=like=*Pro*"
+// @Success      200
+// @Failure      400       {object}  requests.Problem
+// @Failure      422       {object}  requests.Problem
+// @Failure      500       {object}  requests.Problem
+// @Router       /products/export [get]
+func (h *ProductHandler) Export(c echo.Context) error {
+	log.Print("GET export request initialization")
+
+	var query dto.ExportProductsQuery
+	if err := c.Bind(&query); err != nil {
+		return invalidQuery(err)
+	}
+
+	if err := h.Validator.Struct(query); err != nil {
+		return err
+	}
+
+	if err := query.ParseFilter(); err != nil {
+		return invalidFilter(err)
+	}
+
+	// the response starts with the first product, so a failing query is
+	// still answered with an error
+	var writer exportWriter
+	start := func() error {
+		format := exportFormats[query.Format]
+		header := c.Response().Header()
+		header.Set(echo.HeaderContentType, format.mediaType)
+		header.Set(echo.HeaderContentDisposition, `attachment; filename="products`+format.extension+`"`)
+		c.Response().WriteHeader(http.StatusOK)
+
+		var err error
+		writer, err = newExportWriter(c.Response(), query.Format)
+		return err
+	}
+
+	rows := 0
+	err := h.Service.Export(c.Request().Context(), query, func(product entity.Product) error {
+		if writer == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+
+		if err := writer.Write(product); err != nil {
+			return err
+		}
+
+		rows++
+		if rows%exportFlushRows == 0 {
+			if err := writer.Flush(); err != nil {
+				return err
+			}
+			c.Response().Flush()
+		}
+
+		return nil
+	})
+	if err == nil && writer == nil {
+		err = start()
+	}
+	if err == nil {
+		err = writer.Close()
+	}
+
+	if err != nil && !c.Response().Committed {
+		log.Print("Unknown error exporting products in database")
+		return err
+	}
+
+	if err != nil {
+		// aborting the connection tells the client the file is incomplete
+		log.Printf("export failed after %d products: %v", rows, err)
+		panic(http.ErrAbortHandler)
+	}
+
+	log.Print("GET export request finished")
+	return nil
+}
+
+// Export Products Job godoc
+// @Summary      Export products in background
+// @Description  Runs the export as a background job, answered with 202 and the job. Once it succeeded the file is downloaded from the result URL of the job.
+// @Tags         Products
+// @Produce      json,xml,application/msgpack
+// @Param        format          query     string  true   "file format"  Enums(csv, ndjson, xlsx)
+// @Param        sort            query     string  false  "comma separated fields, prefix with - for descending (id, name, price, created_at, updated_at)"
+// @Param        min_price       query     number  false  "minimum price in the default currency"
+// @Param        max_price       query     number  false  "maximum price in the default currency"
+// @Param        name_contains   query     string  false  "part of the product name"
+// @Param        created_after   query     string  false  "RFC 3339 date time"
+// @Param        created_before  query     string  false  "RFC 3339 date time"
+// @Param        filter          query     string  false  "RSQL expression, e.g. price=gt=100;name=like=*Pro*"
+// @Success      202       {object}  requests.TypeSuccessResponse
+// @Failure      400       {object}  requests.Problem
+// @Failure      422       {object}  requests.Problem
+// @Failure      500       {object}  requests.Problem
+// @Failure      503       {object}  requests.Problem
+// @Router       /products/export [post]
+func (h *ProductHandler) ExportJob(c echo.Context) error {
+	var query dto.ExportProductsQuery
+	if err := (&echo.DefaultBinder{}).BindQueryParams(c, &query); err != nil {
+		return invalidQuery(err)
+	}
+
+	if err := h.Validator.Struct(query); err != nil {
+		return err
+	}
+
+	if err := query.ParseFilter(); err != nil {
+		return invalidFilter(err)
+	}
+
+	format := exportFormats[query.Format]
+	job, err := h.Jobs.Enqueue(c.Request().Context(), dto.JobRequest{
+		Kind:       dto.JobExportProducts,
+		Params:     query,
+		ResultType: format.mediaType,
+		ResultName: "products" + format.extension,
+	}, nil)
+	if err != nil {
+		return jobError(err)
+	}
+
+	log.Print("POST export request queued")
+	return acceptJob(c, job)
+}
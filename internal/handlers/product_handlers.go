@@ -1,26 +1,50 @@
 package handlers
 
 import (
+	"io"
 	"log"
+	"mime"
 	"net/http"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
 	"github.com/waldrey/eulabs/internal/dto"
+	"github.com/waldrey/eulabs/internal/entity"
 	"github.com/waldrey/eulabs/internal/infra/service"
+	"github.com/waldrey/eulabs/pkg/cursor"
 	"github.com/waldrey/eulabs/pkg/requests"
 	"github.com/waldrey/eulabs/tools"
 )
 
 type ProductHandler struct {
 	Service   service.ProductInterface
+	PriceList service.PriceListInterface
 	Validator *validator.Validate
+	Cursor    *cursor.Signer
+	Jobs      service.JobInterface
+
+	// RequireIfMatch refuses writes without an If-Match header
+	RequireIfMatch bool
+
+	// CacheControl is sent on product reads, nothing is sent when empty
+	CacheControl string
 }
 
-func NewProductHandler(service service.ProductInterface) *ProductHandler {
+type ProductHandlerConfig struct {
+	RequireIfMatch bool
+	CacheControl   string
+}
+
+func NewProductHandler(service service.ProductInterface, priceList service.PriceListInterface, jobs service.JobInterface, cursorSigner *cursor.Signer, config ProductHandlerConfig) *ProductHandler {
 	return &ProductHandler{
-		Service:   service,
-		Validator: validator.New(),
+		Service:        service,
+		PriceList:      priceList,
+		Validator:      tools.NewValidator(),
+		Cursor:         cursorSigner,
+		Jobs:           jobs,
+		RequireIfMatch: config.RequireIfMatch,
+		CacheControl:   config.CacheControl,
 	}
 }
 
@@ -28,62 +52,275 @@ func NewProductHandler(service service.ProductInterface) *ProductHandler {
 // @Summary      Create Product
 // @Description  Create product
 // @Tags         Products
-// @Accept       json
-// @Produce      json
+// @Accept       json,xml,application/msgpack
+// @Produce      json,xml,application/msgpack
 // @Success      201       {array}   requests.TypeSuccessResponse
-// @Failure 	 400 	   {object}  requests.TypeErrorResponse
-// @Failure 	 422 	   {object}  requests.TypeErrorResponse
-// @Failure 	 500 	   {object}  requests.TypeErrorResponse
+// @Failure 	 400 	   {object}  requests.Problem
+// @Failure 	 413 	   {object}  requests.Problem
+// @Failure 	 422 	   {object}  requests.Problem
+// @Failure 	 500 	   {object}  requests.Problem
 // @Router       /api/v1/products [post]
 func (h *ProductHandler) Create(c echo.Context) error {
 	log.Print("POST request initialization")
 
 	var product dto.CreateProductRequest
 	if err := c.Bind(&product); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{
-			"error": tools.FormatValidationError(err),
-		})
+		return invalidBody(err)
 	}
 
 	if err := h.Validator.Struct(product); err != nil {
-		return c.JSON(http.StatusUnprocessableEntity, map[string]interface{}{
-			"error": tools.FormatValidationError(err),
-		})
+		return err
 	}
 
-	entityProduct, err := h.Service.Create(product)
+	entityProduct, err := h.Service.Create(c.Request().Context(), product)
 	if err != nil {
-		errResponse := requests.ErrorResponse("Internal Server Error")
-		return c.JSON(http.StatusInternalServerError, errResponse)
+		return err
 	}
 
 	log.Print("POST request finished")
+	c.Response().Header().Set("ETag", productETag(*entityProduct))
 	successResponse := requests.SuccessResponse(*entityProduct)
-	return c.JSON(http.StatusCreated, successResponse)
+	return requests.Render(c, http.StatusCreated, successResponse)
 }
 
 // List Products godoc
 // @Summary      List products
-// @Description  Get all products
+// @Description  Get products paginated by page or, when the cursor parameter is present (empty to start), by keyset cursor
 // @Tags         Products
 // @Accept       json
-// @Produce      json
+// @Produce      json,xml,application/msgpack,text/csv
+// @Param        page       query     int  false  "page number"  minimum(1)
+// @Param        page_size  query     int  false  "page size (max 100)"  minimum(1)
+// @Param        cursor     query     string  false  "opaque cursor returned as next_cursor"
+// @Param        sort            query     string  false  "comma separated fields, prefix with - for descending (id, name, price, created_at, updated_at)"
+// @Param        min_price       query     number  false  "minimum price in the default currency"
+// @Param        max_price       query     number  false  "maximum price in the default currency"
+// @Param        name_contains   query     string  false  "part of the product name"
+// @Param        created_after   query     string  false  "RFC 3339 date time"
+// @Param        created_before  query     string  false  "RFC 3339 date time"
+// @Param        filter          query     string  false  "RSQL expression, e.g. price=gt=100;name=like=*Pro*,description==null"
+// @Param        fields          query     string  false  "comma separated fields to return (id, name, description, price, created_at, updated_at)"
+// @Param        currency        query     string  false  "ISO 4217 currency to return the prices in"
+// @Param        Accept-Currency header    string  false  "ISO 4217 currency to return the prices in, when currency is absent"
+// @Param        If-None-Match   header    string  false  "ETag of the cached listing"
+// @Param        If-Modified-Since  header  string  false  "Last-Modified of the cached listing"
 // @Success      200       {array}   requests.TypeSuccessResponse
-// @Failure 	 500 	   {object}  requests.TypeErrorResponse
+// @Success      304
+// @Failure      400       {object}  requests.Problem
+// @Failure      422       {object}  requests.Problem
+// @Failure 	 500 	   {object}  requests.Problem
 // @Router       /products [get]
 func (h *ProductHandler) List(c echo.Context) error {
 	log.Print("GET request initialization")
 
-	products, err := h.Service.FindAll()
+	var query dto.ListProductsQuery
+	if err := c.Bind(&query); err != nil {
+		return invalidQuery(err)
+	}
+
+	if err := h.Validator.Struct(query); err != nil {
+		return err
+	}
+	query.Normalize()
+
+	if err := query.ParseFilter(); err != nil {
+		return invalidFilter(err)
+	}
+
+	fields, err := query.ParseFields()
+	if err != nil {
+		return err
+	}
+
+	currency, ok := requestedCurrency(c, query.Currency)
+	if !ok {
+		return errInvalidAcceptCurrency
+	}
+	query.Currency = currency
+
+	// converted prices are revalidated once rendered, see convertedValidators
+	if query.Currency == "" {
+		validators, err := h.Service.CatalogValidators(c.Request().Context())
+		if err != nil {
+			log.Print("Unknown error getting products in database")
+			return err
+		}
+
+		if h.notModified(c, validators) {
+			return c.NoContent(http.StatusNotModified)
+		}
+	}
+
+	if c.QueryParams().Has("cursor") {
+		return h.listAfter(c, query, fields)
+	}
+
+	products, total, err := h.Service.List(c.Request().Context(), query)
 	if err != nil {
 		log.Print("Unknown error getting products in database")
-		errResponse := requests.ErrorResponse("Internal Server Error")
-		return c.JSON(http.StatusInternalServerError, errResponse)
+		return err
+	}
+
+	data, err := h.priced(c.Request().Context(), products, query.Currency, fields)
+	if err != nil {
+		return err
 	}
 
 	log.Print("GET request finished")
-	successResponse := requests.SuccessListResponse(products)
-	return c.JSON(http.StatusOK, successResponse)
+	meta := requests.NewPaginationMeta(c.Request().URL, query.Page, query.PageSize, total)
+	successResponse := requests.SuccessPageResponse(data, meta)
+	if query.Currency != "" && h.notModified(c, convertedValidators(successResponse)) {
+		return c.NoContent(http.StatusNotModified)
+	}
+	return requests.Render(c, http.StatusOK, successResponse)
+}
+
+func (h *ProductHandler) listAfter(c echo.Context, query dto.ListProductsQuery, fields []string) error {
+	var after *cursor.Position
+	if query.Sort != "" {
+		return errCursorSort
+	}
+
+	if query.Cursor != "" {
+		position, err := h.Cursor.Decode(query.Cursor)
+		if err != nil {
+			return errInvalidCursor
+		}
+		after = &position
+	}
+
+	products, next, err := h.Service.ListAfter(c.Request().Context(), query, after)
+	if err != nil {
+		log.Print("Unknown error getting products in database")
+		return err
+	}
+
+	meta := requests.CursorMeta{PageSize: query.PageSize}
+	if next != nil {
+		meta.NextCursor = h.Cursor.Encode(*next)
+	}
+
+	data, err := h.priced(c.Request().Context(), products, query.Currency, fields)
+	if err != nil {
+		return err
+	}
+
+	log.Print("GET request finished")
+	successResponse := requests.SuccessPageResponse(data, meta)
+	if query.Currency != "" && h.notModified(c, convertedValidators(successResponse)) {
+		return c.NoContent(http.StatusNotModified)
+	}
+	return requests.Render(c, http.StatusOK, successResponse)
+}
+
+// Search Products godoc
+// @Summary      Search products
+// @Description  Full-text search over product name and description ranked by relevance
+// @Tags         Products
+// @Accept       json
+// @Produce      json,xml,application/msgpack,text/csv
+// @Param        q      query     string  true   "search terms"
+// @Param        limit  query     int     false  "max results (max 100)"  minimum(1)
+// @Success      200       {array}   requests.TypeSuccessResponse
+// @Failure      400       {object}  requests.Problem
+// @Failure      422       {object}  requests.Problem
+// @Failure      500       {object}  requests.Problem
+// @Router       /products/search [get]
+func (h *ProductHandler) Search(c echo.Context) error {
+	log.Print("GET search request initialization")
+
+	var query dto.SearchProductsQuery
+	if err := c.Bind(&query); err != nil {
+		return invalidQuery(err)
+	}
+
+	if err := h.Validator.Struct(query); err != nil {
+		return err
+	}
+
+	results, err := h.Service.Search(c.Request().Context(), query)
+	if err != nil {
+		log.Print("Unknown error searching products in database")
+		return err
+	}
+
+	log.Print("GET search request finished")
+	successResponse := requests.SuccessSearchResponse(results)
+	return requests.Render(c, http.StatusOK, successResponse)
+}
+
+// Suggest Products godoc
+// @Summary      Suggest products
+// @Description  Product names starting with the typed prefix, tolerant of typos
+// @Tags         Products
+// @Accept       json
+// @Produce      json,xml,application/msgpack,text/csv
+// @Param        prefix  query     string  true   "typed prefix"
+// @Param        limit   query     int     false  "max suggestions (max 20)"  minimum(1)
+// @Success      200       {array}   requests.TypeSuccessResponse
+// @Failure      400       {object}  requests.Problem
+// @Failure      422       {object}  requests.Problem
+// @Router       /products/suggest [get]
+func (h *ProductHandler) Suggest(c echo.Context) error {
+	var query dto.SuggestProductsQuery
+	if err := c.Bind(&query); err != nil {
+		return invalidQuery(err)
+	}
+
+	if err := h.Validator.Struct(query); err != nil {
+		return err
+	}
+
+	suggestions := h.Service.Suggest(query)
+	successResponse := requests.SuccessSuggestResponse(suggestions)
+	return requests.Render(c, http.StatusOK, successResponse)
+}
+
+// Products Stats godoc
+// @Summary      Products stats
+// @Description  Count, price aggregates and price histogram of the products priced in one currency, accepts the listing filters
+// @Tags         Products
+// @Accept       json
+// @Produce      json,xml,application/msgpack
+// @Param        currency        query     string  false  "ISO 4217 code of the prices aggregated, the default currency when not sent"
+// @Param        buckets         query     int     false  "histogram buckets (max 50)"  minimum(1)
+// @Param        bucket_size     query     number  false  "histogram bucket width, overrides buckets"
+// @Param        min_price       query     number  false  "minimum price in the currency"
+// @Param        max_price       query     number  false  "maximum price in the currency"
+// @Param        name_contains   query     string  false  "part of the product name"
+// @Param        created_after   query     string  false  "RFC 3339 date time"
+// @Param        created_before  query     string  false  "RFC 3339 date time"
+// @Param        filter          query     string  false  "RSQL expression, e.g. price=gt=100;name=like=*Pro*"
+// @Success      200       {object}  requests.TypeSuccessResponse
+// @Failure      400       {object}  requests.Problem
+// @Failure      422       {object}  requests.Problem
+// @Failure      500       {object}  requests.Problem
+// @Router       /products/stats [get]
+func (h *ProductHandler) Stats(c echo.Context) error {
+	log.Print("GET stats request initialization")
+
+	var query dto.ProductStatsQuery
+	if err := c.Bind(&query); err != nil {
+		return invalidQuery(err)
+	}
+
+	if err := h.Validator.Struct(query); err != nil {
+		return err
+	}
+
+	if err := query.ParseFilter(); err != nil {
+		return invalidFilter(err)
+	}
+
+	stats, err := h.Service.Stats(c.Request().Context(), query)
+	if err != nil {
+		log.Print("Unknown error aggregating products in database")
+		return err
+	}
+
+	log.Print("GET stats request finished")
+	successResponse := requests.SuccessStatsResponse(*stats)
+	return requests.Render(c, http.StatusOK, successResponse)
 }
 
 // Get Product godoc
@@ -91,12 +328,19 @@ func (h *ProductHandler) List(c echo.Context) error {
 // @Description  Get product by id
 // @Tags         Products
 // @Accept       json
-// @Produce      json
+// @Produce      json,xml,application/msgpack
 // @Param        id   path      string  true  "product ID" Format(int)
+// @Param        fields  query     string  false  "comma separated fields to return (id, name, description, price, created_at, updated_at)"
+// @Param        currency         query     string  false  "ISO 4217 currency to return the price in"
+// @Param        Accept-Currency  header    string  false  "ISO 4217 currency to return the price in, when currency is absent"
+// @Param        If-None-Match    header    string  false  "ETag of the cached product"
+// @Param        If-Modified-Since  header  string  false  "Last-Modified of the cached product"
 // @Success      200       {array}   requests.TypeSuccessResponse
-// @Failure      400       {object}  requests.TypeErrorResponse
-// @Failure 	 404 	   {object}  requests.TypeErrorResponse
-// @Failure      500       {object}  requests.TypeErrorResponse
+// @Success      304
+// @Failure      400       {object}  requests.Problem
+// @Failure 	 404 	   {object}  requests.Problem
+// @Failure      422       {object}  requests.Problem
+// @Failure      500       {object}  requests.Problem
 // @Router       /products/{id} [get]
 func (h *ProductHandler) FindOne(c echo.Context) error {
 	log.Print("GET :id request initialization")
@@ -106,28 +350,62 @@ func (h *ProductHandler) FindOne(c echo.Context) error {
 		return err
 	}
 
-	product, err := h.Service.FindOne(id)
+	var query dto.FindProductQuery
+	if err := c.Bind(&query); err != nil {
+		return invalidQuery(err)
+	}
+
+	if err := h.Validator.Struct(query); err != nil {
+		return err
+	}
+
+	fields, err := query.ParseFields()
+	if err != nil {
+		return err
+	}
+
+	currency, ok := requestedCurrency(c, query.Currency)
+	if !ok {
+		return errInvalidAcceptCurrency
+	}
+
+	product, err := h.Service.FindOneFields(c.Request().Context(), id, fields)
 	if err != nil {
-		errResponse := requests.ErrorResponse("Product not found")
-		return c.JSON(http.StatusNotFound, errResponse)
+		return err
+	}
+
+	if currency == "" && h.notModified(c, productValidators(*product)) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	data, err := h.pricedOne(c.Request().Context(), *product, currency, fields)
+	if err != nil {
+		return err
 	}
 
 	log.Print("GET :id request finished")
-	successResponse := requests.SuccessResponse(*product)
-	return c.JSON(http.StatusOK, successResponse)
+	successResponse := requests.SuccessDataResponse(data)
+	if currency != "" && h.notModified(c, convertedValidators(successResponse)) {
+		return c.NoContent(http.StatusNotModified)
+	}
+	return requests.Render(c, http.StatusOK, successResponse)
 }
 
 // Delete Product godoc
 // @Summary      Delete product
-// @Description  Deletes the product from the system
+// @Description  Moves the product to the trash, from where it can be restored until it is purged. With hard=true the product, in the trash or not, is removed for good.
 // @Tags         Products
 // @Accept       json
-// @Produce      json
+// @Produce      json,xml,application/msgpack
 // @Param        id   path      string  true  "product ID" Format(int)
+// @Param        hard  query     bool  false  "remove the product for good"
+// @Param        If-Match  header  string  false  "ETag of the product, required when the server is configured so"
 // @Success      204
-// @Failure      400       {object}  requests.TypeErrorResponse
-// @Failure      404       {object}  requests.TypeErrorResponse
-// @Failure      500       {object}  requests.TypeErrorResponse
+// @Failure      400       {object}  requests.Problem
+// @Failure      404       {object}  requests.Problem
+// @Failure      412       {object}  requests.Problem
+// @Failure      428       {object}  requests.Problem
+// @Failure      500       {object}  requests.Problem
 // @Router       /products/{id} [delete]
 func (h *ProductHandler) Delete(c echo.Context) error {
 	log.Print("DELETE :id  request initialization")
@@ -137,35 +415,46 @@ func (h *ProductHandler) Delete(c echo.Context) error {
 		return err
 	}
 
-	err = h.Service.Delete(id)
+	hard, err := boolParam(c, "hard")
 	if err != nil {
-		log.Print("Unknown error deleting products in database")
+		return invalidParameter("hard")
+	}
 
-		if err.Error() == "record not found" {
-			errResponse := requests.ErrorResponse("Product not found")
-			return c.JSON(http.StatusNotFound, errResponse)
-		}
-		errResponse := requests.ErrorResponse("Internal Server Error")
-		return c.JSON(http.StatusInternalServerError, errResponse)
+	precondition, ok := h.precondition(c)
+	if !ok {
+		return errPreconditionRequired
+	}
+
+	if hard {
+		err = h.Service.Purge(c.Request().Context(), id, precondition)
+	} else {
+		err = h.Service.Delete(c.Request().Context(), id, precondition)
+	}
+	if err != nil {
+		return err
 	}
 
 	log.Print("DELETE :id request finished")
-	return c.JSON(http.StatusNoContent, "")
+	return c.NoContent(http.StatusNoContent)
 }
 
 // Update Product godoc
 // @Summary      Update product
 // @Description  Update product
 // @Tags         Products
-// @Accept       json
-// @Produce      json
+// @Accept       json,xml,application/msgpack
+// @Produce      json,xml,application/msgpack
 // @Param        id   path      string  true  "product ID" Format(int)
 // @Param        request     body      dto.PutProductRequest  true  "product request"
+// @Param        If-Match  header  string  false  "ETag of the product, required when the server is configured so"
 // @Success      200       {array}   requests.TypeSuccessResponse
-// @Failure      400       {object}  requests.TypeErrorResponse
-// @Failure      404       {object}  requests.TypeErrorResponse
-// @Failure      422       {object}  requests.TypeErrorResponse
-// @Failure      500       {object}  requests.TypeErrorResponse
+// @Failure      400       {object}  requests.Problem
+// @Failure      404       {object}  requests.Problem
+// @Failure      412       {object}  requests.Problem
+// @Failure      413       {object}  requests.Problem
+// @Failure      422       {object}  requests.Problem
+// @Failure      428       {object}  requests.Problem
+// @Failure      500       {object}  requests.Problem
 // @Router       /products/{id} [put]
 func (h *ProductHandler) UpdatePut(c echo.Context) error {
 	log.Print("PUT :id  request initialization")
@@ -177,56 +466,50 @@ func (h *ProductHandler) UpdatePut(c echo.Context) error {
 
 	var product dto.PutProductRequest
 	if err := c.Bind(&product); err != nil {
-		return err
+		return invalidBody(err)
 	}
 
 	if err := h.Validator.Struct(product); err != nil {
-		return c.JSON(http.StatusUnprocessableEntity, map[string]interface{}{
-			"error": tools.FormatValidationError(err),
-		})
+		return err
 	}
 
-	_, err = h.Service.FindOne(id)
-	if err != nil {
-		errResponse := requests.ErrorResponse("Product not found")
-		return c.JSON(http.StatusNotFound, errResponse)
+	precondition, ok := h.precondition(c)
+	if !ok {
+		return errPreconditionRequired
 	}
 
-	_, err = h.Service.Update(id, product)
+	productUpdated, err := h.Service.Update(c.Request().Context(), id, precondition, product)
 	if err != nil {
-		log.Print("Unknown error deleting products in database")
-
-		if err.Error() == "record not found" {
-			errResponse := requests.ErrorResponse("Product not found")
-			return c.JSON(http.StatusNotFound, errResponse)
-		}
-		errResponse := requests.ErrorResponse("Internal Server Error")
-		return c.JSON(http.StatusInternalServerError, errResponse)
-	}
-
-	productUpdated, err := h.Service.FindOne(id)
-	if err != nil {
-		errResponse := requests.ErrorResponse("Product not found")
-		return c.JSON(http.StatusNotFound, errResponse)
+		return err
 	}
 
 	log.Print("PUT :id request finished")
+	c.Response().Header().Set("ETag", productETag(*productUpdated))
 	successResponse := requests.SuccessResponse(*productUpdated)
-	return c.JSON(http.StatusOK, successResponse)
+	return requests.Render(c, http.StatusOK, successResponse)
 }
 
 // Update Product godoc
-// @Summary      Update product
-// @Description  Update product
+// @Summary      Partially update product
+// @Description  Update only the fields sent, a field sent as null is cleared. Also accepts a JSON Merge Patch (application/merge-patch+json) or a JSON Patch with add, remove, replace and test operations (application/json-patch+json) over the name, description and price of the product.
 // @Tags         Products
-// @Accept       json
-// @Produce      json
+// @Accept       json,xml,application/msgpack
+// @Accept       application/merge-patch+json
+// @Accept       application/json-patch+json
+// @Produce      json,xml,application/msgpack
 // @Param        id   path      string  true  "product ID" Format(int)
 // @Param        request     body      dto.UpdateProductRequest  true  "product request"
+// @Param        If-Match  header  string  false  "ETag of the product, required when the server is configured so"
 // @Success      200       {array}   requests.TypeSuccessResponse
-// @Failure      422       {object}  requests.TypeErrorResponse
-// @Failure      404       {object}  requests.TypeErrorResponse
-// @Failure      500       {object}  requests.TypeErrorResponse
+// @Failure      400       {object}  requests.Problem
+// @Failure      404       {object}  requests.Problem
+// @Failure      409       {object}  requests.Problem
+// @Failure      412       {object}  requests.Problem
+// @Failure      413       {object}  requests.Problem
+// @Failure      415       {object}  requests.Problem
+// @Failure      422       {object}  requests.Problem
+// @Failure      428       {object}  requests.Problem
+// @Failure      500       {object}  requests.Problem
 // @Router       /products/{id} [patch]
 func (h *ProductHandler) UpdatePatch(c echo.Context) error {
 	log.Print("PATCH :id  request initialization")
@@ -236,46 +519,57 @@ func (h *ProductHandler) UpdatePatch(c echo.Context) error {
 		return err
 	}
 
-	var product dto.UpdateProductRequest
-	if err := c.Bind(&product); err != nil {
-		return err
+	precondition, ok := h.precondition(c)
+	if !ok {
+		return errPreconditionRequired
 	}
 
-	if err := h.Validator.Struct(product); err != nil {
-		return c.JSON(http.StatusUnprocessableEntity, map[string]interface{}{
-			"error": tools.FormatValidationError(err),
-		})
-	}
+	var productUpdated *entity.Product
+	mediaType, _, _ := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+	switch mediaType {
+	case echo.MIMEApplicationJSON, echo.MIMEApplicationXML, echo.MIMETextXML, requests.MIMEApplicationMsgpack, requests.MIMEApplicationXMsgpack, requests.MIMEApplicationVndMsgpack:
+		var product dto.UpdateProductRequest
+		if err := c.Bind(&product); err != nil {
+			return invalidBody(err)
+		}
 
-	_, err = h.Service.FindOne(id)
-	if err != nil {
-		errResponse := requests.ErrorResponse("Product not found")
-		return c.JSON(http.StatusNotFound, errResponse)
-	}
+		if err := h.Validator.Struct(product); err != nil {
+			return err
+		}
 
-	_, err = h.Service.Update(id, dto.PutProductRequest{
-		Name:        tools.SafeDereferenceString(product.Name),
-		Description: tools.SafeDereferenceString(product.Description),
-		Price:       tools.SafeDereferenceFloat64(product.Price),
-	})
-	if err != nil {
-		log.Print("Unknown error deleting products in database")
+		productUpdated, err = h.Service.Patch(c.Request().Context(), id, precondition, product)
+	case MIMEApplicationMergePatch, MIMEApplicationJSONPatch:
+		patch, readErr := io.ReadAll(c.Request().Body)
+		if readErr != nil {
+			return invalidBody(readErr)
+		}
 
-		if err.Error() == "record not found" {
-			errResponse := requests.ErrorResponse("Product not found")
-			return c.JSON(http.StatusNotFound, errResponse)
+		if mediaType == MIMEApplicationMergePatch {
+			productUpdated, err = h.Service.MergePatch(c.Request().Context(), id, precondition, patch)
+		} else {
+			productUpdated, err = h.Service.JSONPatch(c.Request().Context(), id, precondition, patch)
 		}
-		errResponse := requests.ErrorResponse("Internal Server Error")
-		return c.JSON(http.StatusInternalServerError, errResponse)
+	default:
+		return unsupportedMediaType(c, "Accept-Patch", strings.Join(acceptPatch, ", "))
 	}
 
-	productUpdated, err := h.Service.FindOne(id)
 	if err != nil {
-		errResponse := requests.ErrorResponse("Product not found")
-		return c.JSON(http.StatusNotFound, errResponse)
+		return err
 	}
 
-	log.Print("PUT :id request finished")
+	log.Print("PATCH :id request finished")
+	c.Response().Header().Set("ETag", productETag(*productUpdated))
 	successResponse := requests.SuccessResponse(*productUpdated)
-	return c.JSON(http.StatusOK, successResponse)
+	return requests.Render(c, http.StatusOK, successResponse)
+}
+
+const (
+	MIMEApplicationMergePatch = "application/merge-patch+json"
+	MIMEApplicationJSONPatch  = "application/json-patch+json"
+)
+
+// acceptPatch lists the media types accepted by PATCH, sent back on 415.
+var acceptPatch = []string{
+	echo.MIMEApplicationJSON, MIMEApplicationMergePatch, MIMEApplicationJSONPatch,
+	echo.MIMEApplicationXML, echo.MIMETextXML, requests.MIMEApplicationMsgpack,
 }
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/waldrey/eulabs/internal/middlewares"
+	"github.com/waldrey/eulabs/pkg/requests"
+)
+
+func serveProducts(target string) *httptest.ResponseRecorder {
+	handler := NewProductHandler(nil, nil, nil, nil, ProductHandlerConfig{})
+
+	e := echo.New()
+	e.HTTPErrorHandler = requests.HTTPErrorHandler
+	e.GET("/products", handler.List)
+
+	request := httptest.NewRequest(http.MethodGet, target, nil)
+	request.Header.Set(echo.HeaderAccept, echo.MIMEApplicationJSON)
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestGivenAMalformedDate_WhenIListProducts_ThenShouldReceiveAValidationProblem(t *testing.T) {
+	recorder := serveProducts("/products?created_after=notadate")
+
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"code":"validation_failed"`)
+	assert.Contains(t, recorder.Body.String(), `{"field":"created_after","code":"rfc3339","message":"the field 'created_after' is rfc3339"}`)
+}
+
+func TestGivenDatesOutOfOrder_WhenIListProducts_ThenShouldReceiveAValidationProblem(t *testing.T) {
+	recorder := serveProducts("/products?created_after=2024-05-01T00:00:00Z&created_before=2024-04-01T00:00:00Z")
+
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"field":"created_before","code":"gtdatefield"`)
+}
+
+func TestGivenAnInvalidPage_WhenIListProducts_ThenShouldReceiveAValidationProblem(t *testing.T) {
+	recorder := serveProducts("/products?page=0&page_size=-1")
+
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"field":"page_size","code":"gte"`)
+}
+
+func TestGivenABodyOverTheLimit_WhenIPatchAProduct_ThenShouldReceiveATooLargeProblem(t *testing.T) {
+	handler := NewProductHandler(nil, nil, nil, nil, ProductHandlerConfig{})
+
+	e := echo.New()
+	e.Binder = &requests.Binder{}
+	e.HTTPErrorHandler = requests.HTTPErrorHandler
+	e.PATCH("/products/:id", handler.UpdatePatch, middlewares.BodyLimit(1024))
+
+	body := `{"description":"` + strings.Repeat("a", 2048) + `"}`
+	for _, contentType := range []string{MIMEApplicationMergePatch, MIMEApplicationJSONPatch, echo.MIMEApplicationJSON, echo.MIMEApplicationXML} {
+		// without a Content-Length the limit is only reached while reading
+		request := httptest.NewRequest(http.MethodPatch, "/products/1", iotest.OneByteReader(strings.NewReader(body)))
+		request.ContentLength = -1
+		request.Header.Set(echo.HeaderContentType, contentType)
+		request.Header.Set(echo.HeaderAccept, echo.MIMEApplicationJSON)
+		recorder := httptest.NewRecorder()
+		e.ServeHTTP(recorder, request)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code, contentType)
+		assert.Contains(t, recorder.Body.String(), `"code":"body_too_large"`, contentType)
+	}
+}
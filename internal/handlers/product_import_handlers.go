@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/waldrey/eulabs/internal/dto"
+	"github.com/waldrey/eulabs/pkg/i18n"
+	"github.com/waldrey/eulabs/pkg/requests"
+)
+
+const (
+	MIMETextCSV           = "text/csv"
+	MIMEApplicationNDJSON = "application/x-ndjson"
+)
+
+// importMediaTypes maps the accepted upload media types and file extensions
+// to the format of the import.
+var importMediaTypes = map[string]string{
+	MIMETextCSV:           MIMETextCSV,
+	".csv":                MIMETextCSV,
+	MIMEApplicationNDJSON: MIMEApplicationNDJSON,
+	"application/ndjson":  MIMEApplicationNDJSON,
+	"application/jsonl":   MIMEApplicationNDJSON,
+	".ndjson":             MIMEApplicationNDJSON,
+	".jsonl":              MIMEApplicationNDJSON,
+}
+
+// Import Products godoc
+// @Summary      Import products
+// @Description  Upserts the products of a CSV (name, description, price and optional currency columns, price in the major unit) or NDJSON (one product per line, like the body of a POST) file by name, compared case-insensitively. The file is sent as the body or as the file field of a multipart form, it is read as it arrives and written in batches, each in its own transaction. Invalid rows are reported with their line and skipped, a dry run reports them and what would change without writing anything.
+// @Tags         Products
+// @Accept       text/csv
+// @Accept       application/x-ndjson
+// @Accept       multipart/form-data
+// @Produce      json,xml,application/msgpack
+// @Param        dry_run  query     bool  false  "validate only"
+// @Param        async    query     bool  false  "run as a background job, answered with 202 and the job"
+// @Success      200       {object}  requests.TypeSuccessResponse
+// @Success      202       {object}  requests.TypeSuccessResponse
+// @Failure      400       {object}  requests.Problem
+// @Failure      415       {object}  requests.Problem
+// @Failure      500       {object}  requests.Problem
+// @Router       /products/import [post]
+func (h *ProductHandler) Import(c echo.Context) error {
+	log.Print("POST import request initialization")
+
+	dryRun, err := boolParam(c, "dry_run")
+	if err != nil {
+		return invalidParameter("dry_run")
+	}
+
+	async, err := boolParam(c, "async")
+	if err != nil {
+		return invalidParameter("async")
+	}
+
+	body, format, err := importUpload(c.Request())
+	if err != nil {
+		return requests.NewProblem(http.StatusBadRequest, "invalid_upload", err.Error()).WithArgs(err.Error())
+	}
+
+	if async && format != "" {
+		job, err := h.Jobs.Enqueue(c.Request().Context(), dto.JobRequest{
+			Kind:       dto.JobImportProducts,
+			Params:     dto.ImportJobParams{Format: format, DryRun: dryRun},
+			ResultType: echo.MIMEApplicationJSONCharsetUTF8,
+			ResultName: "import-report.json",
+		}, body)
+		if err != nil {
+			return jobError(err)
+		}
+
+		log.Print("POST import request queued")
+		return acceptJob(c, job)
+	}
+
+	var source dto.ImportSource
+	switch format {
+	case MIMETextCSV:
+		source, err = newCSVRows(body)
+		if err != nil {
+			return requests.NewProblem(http.StatusBadRequest, "invalid_upload", err.Error()).WithArgs(err.Error())
+		}
+	case MIMEApplicationNDJSON:
+		source = newNDJSONRows(body)
+	default:
+		return unsupportedMediaType(c, "Accept-Post", strings.Join([]string{MIMETextCSV, MIMEApplicationNDJSON, echo.MIMEMultipartForm}, ", "))
+	}
+
+	result, err := h.Service.Import(c.Request().Context(), &validatedRows{source: source, validator: h.Validator, translator: i18n.FromContext(c)}, dryRun)
+	if errors.Is(err, ErrInvalidUpload) {
+		return requests.NewProblem(http.StatusBadRequest, "invalid_upload", err.Error()).WithArgs(err.Error()).WithDetails(result)
+	}
+	if err != nil {
+		log.Print("Unknown error importing products in database")
+		return requests.NewProblem(http.StatusInternalServerError, "internal_error", "import failed").WithDetails(result)
+	}
+
+	log.Print("POST import request finished")
+	successResponse := requests.SuccessDataResponse(result)
+	return requests.Render(c, http.StatusOK, successResponse)
+}
+
+// importUpload returns the uploaded file and its format, read from the body
+// or from the file field of a multipart form without buffering it. The format
+// is empty when the media type is not supported.
+func importUpload(request *http.Request) (io.Reader, string, error) {
+	mediaType, _, _ := mime.ParseMediaType(request.Header.Get(echo.HeaderContentType))
+	if mediaType != echo.MIMEMultipartForm {
+		return request.Body, importMediaTypes[mediaType], nil
+	}
+
+	reader, err := request.MultipartReader()
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: invalid multipart form", ErrInvalidUpload)
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, "", fmt.Errorf("%w: missing file field", ErrInvalidUpload)
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: invalid multipart form", ErrInvalidUpload)
+		}
+
+		if part.FormName() != "file" {
+			continue
+		}
+
+		partType, _, _ := mime.ParseMediaType(part.Header.Get(echo.HeaderContentType))
+		if format, ok := importMediaTypes[partType]; ok {
+			return part, format, nil
+		}
+
+		return part, importMediaTypes[strings.ToLower(filepath.Ext(part.FileName()))], nil
+	}
+}
+
+// boolParam reads an optional boolean query parameter, false when absent.
+func boolParam(c echo.Context, name string) (bool, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return false, nil
+	}
+
+	return strconv.ParseBool(value)
+}
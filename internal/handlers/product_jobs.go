@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/waldrey/eulabs/internal/dto"
+	"github.com/waldrey/eulabs/internal/entity"
+	"github.com/waldrey/eulabs/internal/infra/service"
+	"github.com/waldrey/eulabs/pkg/requests"
+)
+
+// RegisterJobs sets the functions running the product imports and exports
+// enqueued by the handler.
+func (h *ProductHandler) RegisterJobs(jobs *service.Jobs) {
+	jobs.Register(dto.JobImportProducts, h.importJob)
+	jobs.Register(dto.JobExportProducts, h.exportJob)
+}
+
+// importJob imports the uploaded file, writing the import report as result.
+func (h *ProductHandler) importJob(ctx context.Context, run *service.JobRun) (interface{}, error) {
+	var params dto.ImportJobParams
+	if err := json.Unmarshal([]byte(run.Job.Params), &params); err != nil {
+		return nil, err
+	}
+
+	var source dto.ImportSource = newNDJSONRows(run.Input)
+	if params.Format == MIMETextCSV {
+		rows, err := newCSVRows(run.Input)
+		if err != nil {
+			return nil, err
+		}
+		source = rows
+	}
+
+	result, err := h.Service.Import(ctx, &jobRows{
+		source: &validatedRows{source: source, validator: h.Validator},
+		ctx:    ctx,
+		run:    run,
+	}, params.DryRun)
+	if err != nil {
+		return result, err
+	}
+
+	return result, json.NewEncoder(run.Output).Encode(requests.SuccessDataResponse(result))
+}
+
+// exportJob writes the exported file as result.
+func (h *ProductHandler) exportJob(ctx context.Context, run *service.JobRun) (interface{}, error) {
+	var query dto.ExportProductsQuery
+	if err := json.Unmarshal([]byte(run.Job.Params), &query); err != nil {
+		return nil, err
+	}
+
+	if err := query.ParseFilter(); err != nil {
+		return nil, err
+	}
+
+	writer, err := newExportWriter(run.Output, query.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	var summary dto.ExportJobSummary
+	err = h.Service.Export(ctx, query, func(product entity.Product) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := writer.Write(product); err != nil {
+			return err
+		}
+
+		summary.Rows++
+		run.Progress(summary.Rows)
+		return nil
+	})
+	if err != nil {
+		return summary, err
+	}
+
+	return summary, writer.Close()
+}
+
+// jobRows reports the rows read as the progress of the job, stopping when the
+// job is cancelled.
+type jobRows struct {
+	source dto.ImportSource
+	ctx    context.Context
+	run    *service.JobRun
+	rows   int64
+}
+
+func (r *jobRows) Next() (dto.ImportRow, error) {
+	if err := r.ctx.Err(); err != nil {
+		return dto.ImportRow{}, err
+	}
+
+	row, err := r.source.Next()
+	if err == nil {
+		r.rows++
+		r.run.Progress(r.rows)
+	}
+
+	return row, err
+}
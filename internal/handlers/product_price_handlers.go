@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/waldrey/eulabs/internal/dto"
+	"github.com/waldrey/eulabs/internal/entity"
+	"github.com/waldrey/eulabs/pkg/money"
+	"github.com/waldrey/eulabs/pkg/requests"
+	"github.com/waldrey/eulabs/tools"
+)
+
+// Get Product Prices godoc
+// @Summary      Get product prices
+// @Description  Explicit prices of the product in other currencies
+// @Tags         Products
+// @Accept       json
+// @Produce      json,xml,application/msgpack,text/csv
+// @Param        id   path      string  true  "product ID" Format(int)
+// @Success      200       {object}  requests.TypeSuccessResponse
+// @Failure      400       {object}  requests.Problem
+// @Failure      404       {object}  requests.Problem
+// @Failure      500       {object}  requests.Problem
+// @Router       /products/{id}/prices [get]
+func (h *ProductHandler) FindPrices(c echo.Context) error {
+	id, err := tools.ValidateRequest(c)
+	if err != nil {
+		return err
+	}
+
+	_, err = h.Service.FindOne(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	prices, err := h.PriceList.ProductPrices(c.Request().Context(), id)
+	if err != nil {
+		log.Print("Unknown error getting product prices in database")
+		return err
+	}
+
+	successResponse := requests.SuccessDataResponse(prices)
+	return requests.Render(c, http.StatusOK, successResponse)
+}
+
+// Replace Product Prices godoc
+// @Summary      Replace product prices
+// @Description  Replace the explicit prices of the product, one per currency other than the product price currency. The product version is bumped.
+// @Tags         Products
+// @Accept       json,xml,application/msgpack
+// @Produce      json,xml,application/msgpack
+// @Param        id   path      string  true  "product ID" Format(int)
+// @Param        If-Match  header  string  false  "ETag of the product, required when the server is configured so"
+// @Param        request     body      dto.ProductPricesRequest  true  "prices request"
+// @Success      200       {object}  requests.TypeSuccessResponse
+// @Failure      400       {object}  requests.Problem
+// @Failure      404       {object}  requests.Problem
+// @Failure      412       {object}  requests.Problem
+// @Failure      413       {object}  requests.Problem
+// @Failure      422       {object}  requests.Problem
+// @Failure      428       {object}  requests.Problem
+// @Failure      500       {object}  requests.Problem
+// @Router       /products/{id}/prices [put]
+func (h *ProductHandler) ReplacePrices(c echo.Context) error {
+	log.Print("PUT :id/prices request initialization")
+
+	id, err := tools.ValidateRequest(c)
+	if err != nil {
+		return err
+	}
+
+	var request dto.ProductPricesRequest
+	if err := c.Bind(&request); err != nil {
+		return invalidBody(err)
+	}
+
+	if err := h.Validator.Struct(request); err != nil {
+		return err
+	}
+
+	precondition, ok := h.precondition(c)
+	if !ok {
+		return errPreconditionRequired
+	}
+
+	product, prices, err := h.PriceList.ReplaceProductPrices(c.Request().Context(), id, precondition, request)
+	if err != nil {
+		log.Print("Error replacing product prices in database")
+		return err
+	}
+
+	log.Print("PUT :id/prices request finished")
+	c.Response().Header().Set("ETag", productETag(*product))
+	successResponse := requests.SuccessDataResponse(prices)
+	return requests.Render(c, http.StatusOK, successResponse)
+}
+
+// priced returns the products ready to render, converted when a currency was
+// requested.
+func (h *ProductHandler) priced(ctx context.Context, products []entity.Product, currency string, fields []string) (interface{}, error) {
+	if currency == "" {
+		return dto.SparseProducts(products, fields), nil
+	}
+
+	converted, err := h.PriceList.Convert(ctx, products, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	return dto.SparseConvertedProducts(converted, fields), nil
+}
+
+func (h *ProductHandler) pricedOne(ctx context.Context, product entity.Product, currency string, fields []string) (interface{}, error) {
+	if currency == "" {
+		return dto.SparseProduct(product, fields), nil
+	}
+
+	converted, err := h.PriceList.Convert(ctx, []entity.Product{product}, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	return dto.SparseConvertedProduct(converted[0], fields), nil
+}
+
+// requestedCurrency returns the currency parameter or, when it is absent, the
+// first currency listed in the Accept-Currency header. It is not ok when the
+// header holds an unknown currency.
+func requestedCurrency(c echo.Context, currency string) (string, bool) {
+	if currency != "" {
+		return strings.ToUpper(currency), true
+	}
+
+	header := c.Request().Header.Get("Accept-Currency")
+	if header == "" {
+		return "", true
+	}
+
+	first, _, _ := strings.Cut(header, ",")
+	first, _, _ = strings.Cut(first, ";")
+	first = strings.ToUpper(strings.TrimSpace(first))
+	if !money.IsValidCurrency(first) {
+		return "", false
+	}
+
+	return first, true
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/waldrey/eulabs/internal/dto"
+	"github.com/waldrey/eulabs/pkg/requests"
+	"github.com/waldrey/eulabs/tools"
+)
+
+// Trash Products godoc
+// @Summary      List trashed products
+// @Description  Lists the deleted products not purged yet, the last deleted first. They are purged for good once the retention period of the server is over.
+// @Tags         Products
+// @Accept       json
+// @Produce      json,xml,application/msgpack,text/csv
+// @Param        page       query     int  false  "page number"  minimum(1)
+// @Param        page_size  query     int  false  "page size (max 100)"  minimum(1)
+// @Success      200       {array}   requests.TypeSuccessResponse
+// @Failure      400       {object}  requests.Problem
+// @Failure      422       {object}  requests.Problem
+// @Failure      500       {object}  requests.Problem
+// @Router       /products/trash [get]
+func (h *ProductHandler) Trash(c echo.Context) error {
+	log.Print("GET trash request initialization")
+
+	var query dto.ListTrashQuery
+	if err := c.Bind(&query); err != nil {
+		return invalidQuery(err)
+	}
+
+	if err := h.Validator.Struct(query); err != nil {
+		return err
+	}
+	query.Normalize()
+
+	products, total, err := h.Service.Trash(c.Request().Context(), query)
+	if err != nil {
+		return err
+	}
+
+	log.Print("GET trash request finished")
+	meta := requests.NewPaginationMeta(c.Request().URL, query.Page, query.PageSize, total)
+	return requests.Render(c, http.StatusOK, requests.SuccessPageResponse(products, meta))
+}
+
+// Restore Product godoc
+// @Summary      Restore product
+// @Description  Takes a deleted product out of the trash
+// @Tags         Products
+// @Accept       json
+// @Produce      json,xml,application/msgpack
+// @Param        id   path      string  true  "product ID" Format(int)
+// @Param        If-Match  header  string  false  "ETag of the product, required when the server is configured so"
+// @Success      200       {object}  requests.TypeSuccessResponse
+// @Failure      400       {object}  requests.Problem
+// @Failure      404       {object}  requests.Problem
+// @Failure      409       {object}  requests.Problem
+// @Failure      412       {object}  requests.Problem
+// @Failure      428       {object}  requests.Problem
+// @Failure      500       {object}  requests.Problem
+// @Router       /products/{id}/restore [post]
+func (h *ProductHandler) Restore(c echo.Context) error {
+	log.Print("POST :id restore request initialization")
+
+	id, err := tools.ValidateRequest(c)
+	if err != nil {
+		return err
+	}
+
+	precondition, ok := h.precondition(c)
+	if !ok {
+		return errPreconditionRequired
+	}
+
+	product, err := h.Service.Restore(c.Request().Context(), id, precondition)
+	if err != nil {
+		return err
+	}
+
+	log.Print("POST :id restore request finished")
+	c.Response().Header().Set("ETag", productETag(*product))
+	return requests.Render(c, http.StatusOK, requests.SuccessResponse(*product))
+}
@@ -0,0 +1,18 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/waldrey/eulabs/internal/entity"
+	"gorm.io/gorm"
+)
+
+// notFound replaces gorm.ErrRecordNotFound by the domain error of the missing
+// resource, errors.Is still matches both.
+func notFound(err error, domainErr *entity.Error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domainErr.Wrap(err)
+	}
+
+	return err
+}
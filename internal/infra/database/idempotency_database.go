@@ -0,0 +1,71 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/waldrey/eulabs/internal/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Idempotency struct {
+	DB *gorm.DB
+}
+
+func IdempotencyRepository(db *gorm.DB) *Idempotency {
+	return &Idempotency{DB: db}
+}
+
+// Reserve stores the key as in flight. When the key is taken it returns false
+// along with the stored record, expired keys are replaced.
+func (i *Idempotency) Reserve(ctx context.Context, record *entity.IdempotencyKey) (*entity.IdempotencyKey, bool, error) {
+	err := session(ctx, i.DB).Where("idempotency_key = ? AND expires_at <= ?", record.Key, time.Now()).
+		Delete(&entity.IdempotencyKey{}).Error
+	if err != nil {
+		return nil, false, err
+	}
+
+	result := session(ctx, i.DB).Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+
+	if result.RowsAffected == 1 {
+		return record, true, nil
+	}
+
+	var existing entity.IdempotencyKey
+	err = session(ctx, i.DB).Where("idempotency_key = ?", record.Key).First(&existing).Error
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &existing, false, nil
+}
+
+func (i *Idempotency) Complete(ctx context.Context, record *entity.IdempotencyKey) error {
+	return session(ctx, i.DB).Model(&entity.IdempotencyKey{}).Where("idempotency_key = ?", record.Key).Updates(map[string]interface{}{
+		"status_code": record.StatusCode,
+		"headers":     record.Headers,
+		"body":        record.Body,
+		"expires_at":  record.ExpiresAt,
+	}).Error
+}
+
+// Extend pushes back the expiry of the key while its request is in flight.
+func (i *Idempotency) Extend(ctx context.Context, key string, expiresAt time.Time) error {
+	return session(ctx, i.DB).Model(&entity.IdempotencyKey{}).Where("idempotency_key = ? AND status_code = 0", key).
+		Update("expires_at", expiresAt).Error
+}
+
+// DeleteExpired removes the keys expired by now along with their responses,
+// returning how many were removed.
+func (i *Idempotency) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := session(ctx, i.DB).Where("expires_at <= ?", now).Delete(&entity.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
+
+func (i *Idempotency) Release(ctx context.Context, key string) error {
+	return session(ctx, i.DB).Where("idempotency_key = ?", key).Delete(&entity.IdempotencyKey{}).Error
+}
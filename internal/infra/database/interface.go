@@ -1,13 +1,64 @@
 package database
 
 import (
+	"context"
+	"time"
+
 	"github.com/waldrey/eulabs/internal/entity"
 )
 
 type ProductInterface interface {
-	Create(product *entity.Product) (*entity.Product, error)
-	FindAll() ([]entity.Product, error)
-	FindByID(id int) (*entity.Product, error)
-	Update(product *entity.Product) error
-	Delete(product *entity.Product) error
+	Create(ctx context.Context, product *entity.Product) (*entity.Product, error)
+	FindAll(ctx context.Context) ([]entity.Product, error)
+	List(ctx context.Context, options ListOptions) ([]entity.Product, int64, error)
+	ListAfter(ctx context.Context, options ListOptions) ([]entity.Product, error)
+	Export(ctx context.Context, options ListOptions, each func(product entity.Product) error) error
+	FindByID(ctx context.Context, id int) (*entity.Product, error)
+	FindByIDForUpdate(ctx context.Context, id int) (*entity.Product, error)
+	FindByIDFields(ctx context.Context, id int, fields []string) (*entity.Product, error)
+	FindByIDs(ctx context.Context, ids []uint) ([]entity.Product, error)
+	FindByNames(ctx context.Context, names []string) ([]entity.Product, error)
+	CatalogState(ctx context.Context) (CatalogState, error)
+	Update(ctx context.Context, product *entity.Product) error
+	Delete(ctx context.Context, product *entity.Product) error
+	BulkWrite(ctx context.Context, writes BulkWrites) error
+	Search(ctx context.Context, query string, limit int) ([]SearchResult, error)
+	PriceStats(ctx context.Context, filter ProductFilter) (PriceStats, error)
+	PricesAt(ctx context.Context, filter ProductFilter, offset int, limit int) ([]int64, error)
+	PriceHistogram(ctx context.Context, filter ProductFilter, from int64, width int64) (map[int]int64, error)
+	ListTrashed(ctx context.Context, offset int, limit int) ([]entity.Product, int64, error)
+	FindWithTrashedForUpdate(ctx context.Context, id int) (*entity.Product, error)
+	Restore(ctx context.Context, product *entity.Product) error
+	Purge(ctx context.Context, product *entity.Product) error
+	PurgeTrashed(ctx context.Context, before time.Time) (int64, error)
+}
+
+type PriceListInterface interface {
+	FindProductPrices(ctx context.Context, productID int) ([]entity.ProductPrice, error)
+	FindProductPricesIn(ctx context.Context, productIDs []uint, currency string) ([]entity.ProductPrice, error)
+	ReplaceProductPrices(ctx context.Context, productID int, prices []entity.ProductPrice) error
+	FindExchangeRates(ctx context.Context) ([]entity.ExchangeRate, error)
+	FindExchangeRate(ctx context.Context, base string, quote string) (*entity.ExchangeRate, error)
+	SaveExchangeRates(ctx context.Context, rates []entity.ExchangeRate) error
+}
+
+type IdempotencyInterface interface {
+	Reserve(ctx context.Context, record *entity.IdempotencyKey) (*entity.IdempotencyKey, bool, error)
+	Complete(ctx context.Context, record *entity.IdempotencyKey) error
+	Extend(ctx context.Context, key string, expiresAt time.Time) error
+	Release(ctx context.Context, key string) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+type JobInterface interface {
+	Create(ctx context.Context, job *entity.Job) error
+	FindByID(ctx context.Context, id int) (*entity.Job, error)
+	FindByStatus(ctx context.Context, status string) ([]entity.Job, error)
+	ClaimNext(ctx context.Context) (*entity.Job, error)
+	UpdateProgress(ctx context.Context, id uint, progress int64) error
+	Save(ctx context.Context, job *entity.Job) error
+}
+
+type UnitOfWorkInterface interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
 }
@@ -0,0 +1,82 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/waldrey/eulabs/internal/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Job struct {
+	DB *gorm.DB
+}
+
+func JobRepository(db *gorm.DB) *Job {
+	return &Job{DB: db}
+}
+
+func (j *Job) Create(ctx context.Context, job *entity.Job) error {
+	return session(ctx, j.DB).Create(job).Error
+}
+
+func (j *Job) FindByID(ctx context.Context, id int) (*entity.Job, error) {
+	var job entity.Job
+	err := session(ctx, j.DB).First(&job, "id = ?", id).Error
+	if err != nil {
+		return nil, notFound(err, entity.ErrJobNotFound)
+	}
+
+	return &job, nil
+}
+
+func (j *Job) FindByStatus(ctx context.Context, status string) ([]entity.Job, error) {
+	var jobs []entity.Job
+	err := session(ctx, j.DB).Where("status = ?", status).Order("id").Find(&jobs).Error
+
+	return jobs, err
+}
+
+// ClaimNext marks the oldest queued job as running and returns it, nil when
+// no job is queued. Locked jobs are skipped, so runners never share a job.
+func (j *Job) ClaimNext(ctx context.Context) (*entity.Job, error) {
+	var job entity.Job
+	err := session(ctx, j.DB).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ?", entity.JobQueued).
+			Order("id").
+			First(&job).Error
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		job.Status = entity.JobRunning
+		job.StartedAt = &now
+		job.Attempts++
+
+		return tx.Model(&job).Updates(map[string]interface{}{
+			"status":     job.Status,
+			"started_at": job.StartedAt,
+			"attempts":   job.Attempts,
+		}).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+func (j *Job) UpdateProgress(ctx context.Context, id uint, progress int64) error {
+	return session(ctx, j.DB).Model(&entity.Job{}).Where("id = ?", id).Update("progress", progress).Error
+}
+
+func (j *Job) Save(ctx context.Context, job *entity.Job) error {
+	return session(ctx, j.DB).Save(job).Error
+}
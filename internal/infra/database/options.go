@@ -0,0 +1,34 @@
+package database
+
+import (
+	"time"
+
+	"github.com/waldrey/eulabs/pkg/cursor"
+	"github.com/waldrey/eulabs/pkg/rsql"
+)
+
+type ListOptions struct {
+	Offset int
+	Limit  int
+	After  *cursor.Position
+	Sort   []Sort
+	Filter ProductFilter
+	Fields []string
+}
+
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// ProductFilter prices are in minor units of Currency, when set only the
+// products priced in it match.
+type ProductFilter struct {
+	Currency      string
+	MinPrice      int64
+	MaxPrice      int64
+	NameContains  string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	Expression    rsql.Node
+}
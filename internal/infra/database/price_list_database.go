@@ -0,0 +1,77 @@
+package database
+
+import (
+	"context"
+	"github.com/waldrey/eulabs/internal/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PriceList struct {
+	DB *gorm.DB
+}
+
+func PriceListRepository(db *gorm.DB) *PriceList {
+	return &PriceList{DB: db}
+}
+
+func (p *PriceList) FindProductPrices(ctx context.Context, productID int) ([]entity.ProductPrice, error) {
+	var prices []entity.ProductPrice
+	err := session(ctx, p.DB).Where("product_id = ?", productID).Order("price_currency").Find(&prices).Error
+
+	return prices, err
+}
+
+func (p *PriceList) FindProductPricesIn(ctx context.Context, productIDs []uint, currency string) ([]entity.ProductPrice, error) {
+	var prices []entity.ProductPrice
+	if len(productIDs) == 0 {
+		return prices, nil
+	}
+
+	err := session(ctx, p.DB).Where("product_id IN ? AND price_currency = ?", productIDs, currency).Find(&prices).Error
+
+	return prices, err
+}
+
+// ReplaceProductPrices swaps every explicit price of the product in a single
+// transaction, the replaced prices are removed for good.
+func (p *PriceList) ReplaceProductPrices(ctx context.Context, productID int, prices []entity.ProductPrice) error {
+	return session(ctx, p.DB).Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Where("product_id = ?", productID).Delete(&entity.ProductPrice{}).Error
+		if err != nil {
+			return err
+		}
+
+		if len(prices) == 0 {
+			return nil
+		}
+
+		return tx.Create(&prices).Error
+	})
+}
+
+func (p *PriceList) FindExchangeRates(ctx context.Context) ([]entity.ExchangeRate, error) {
+	var rates []entity.ExchangeRate
+	err := session(ctx, p.DB).Order("base").Order("quote").Find(&rates).Error
+
+	return rates, err
+}
+
+func (p *PriceList) FindExchangeRate(ctx context.Context, base string, quote string) (*entity.ExchangeRate, error) {
+	var rate entity.ExchangeRate
+	err := session(ctx, p.DB).Where("base = ? AND quote = ?", base, quote).First(&rate).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &rate, nil
+}
+
+// SaveExchangeRates inserts the rates, a rate for a pair already stored
+// replaces the previous one.
+func (p *PriceList) SaveExchangeRates(ctx context.Context, rates []entity.ExchangeRate) error {
+	return session(ctx, p.DB).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "base"}, {Name: "quote"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "rated_at", "updated_at", "deleted_at"}),
+	}).Create(&rates).Error
+}
@@ -0,0 +1,170 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/waldrey/eulabs/internal/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BulkBatchSize is the number of rows written by each statement of a bulk write.
+const BulkBatchSize = 100
+
+// BulkWrites are the products written by a bulk request, the updated and
+// deleted products hold the version they were read at.
+type BulkWrites struct {
+	Create []*entity.Product
+	Update []*entity.Product
+	Delete []*entity.Product
+}
+
+func (b BulkWrites) Len() int {
+	return len(b.Create) + len(b.Update) + len(b.Delete)
+}
+
+// BulkConflictError lists the products changed or deleted since they were
+// read, nothing of the bulk write was written.
+type BulkConflictError struct {
+	IDs []uint
+}
+
+func (e *BulkConflictError) Error() string {
+	return fmt.Sprintf("%d products were modified", len(e.IDs))
+}
+
+func (e *BulkConflictError) Is(target error) bool {
+	return target == entity.ErrVersionConflict
+}
+
+func (p *Product) FindByIDs(ctx context.Context, ids []uint) ([]entity.Product, error) {
+	var products []entity.Product
+	if len(ids) == 0 {
+		return products, nil
+	}
+
+	err := session(ctx, p.DB).Where("id IN ?", ids).Find(&products).Error
+
+	return products, err
+}
+
+// FindByNames reads the products by their name, using the collation of the
+// column, ordered by id.
+func (p *Product) FindByNames(ctx context.Context, names []string) ([]entity.Product, error) {
+	var products []entity.Product
+	if len(names) == 0 {
+		return products, nil
+	}
+
+	err := session(ctx, p.DB).Where("name IN ?", names).Order("id").Find(&products).Error
+
+	return products, err
+}
+
+// BulkWrite writes every product in a single transaction, creates and updates
+// are sent BulkBatchSize rows per statement and deletes in one statement. The
+// updated and deleted products are locked first and a *BulkConflictError is
+// returned when any of them is no longer at the version it was read.
+func (p *Product) BulkWrite(ctx context.Context, writes BulkWrites) error {
+	err := session(ctx, p.DB).Transaction(func(tx *gorm.DB) error {
+		err := lockVersions(tx, slices.Concat(writes.Update, writes.Delete))
+		if err != nil {
+			return err
+		}
+
+		if len(writes.Create) > 0 {
+			err = tx.CreateInBatches(writes.Create, BulkBatchSize).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(writes.Update) > 0 {
+			now := time.Now()
+			for _, product := range writes.Update {
+				product.UpdatedAt = now
+				product.Version++
+			}
+
+			// the rows exist and are locked, so every insert becomes an update
+			err = tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"name", "description", "price_amount", "price_currency", "updated_at", "version"}),
+			}).CreateInBatches(writes.Update, BulkBatchSize).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(writes.Delete) > 0 {
+			ids := make([]uint, 0, len(writes.Delete))
+			for _, product := range writes.Delete {
+				ids = append(ids, product.ID)
+			}
+
+			err = tx.Delete(&entity.Product{}, ids).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, product := range slices.Concat(writes.Create, writes.Update) {
+		p.reindex(ctx, product, false)
+	}
+	for _, product := range writes.Delete {
+		p.reindex(ctx, product, true)
+	}
+
+	return nil
+}
+
+func lockVersions(tx *gorm.DB, products []*entity.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, 0, len(products))
+	for _, product := range products {
+		ids = append(ids, product.ID)
+	}
+
+	var rows []struct {
+		ID      uint
+		Version uint
+	}
+	err := tx.Model(&entity.Product{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id", "version").
+		Where("id IN ?", ids).
+		Find(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	versions := make(map[uint]uint, len(rows))
+	for _, row := range rows {
+		versions[row.ID] = row.Version
+	}
+
+	var conflict BulkConflictError
+	for _, product := range products {
+		if version, ok := versions[product.ID]; !ok || version != product.Version {
+			conflict.IDs = append(conflict.IDs, product.ID)
+		}
+	}
+
+	if len(conflict.IDs) > 0 {
+		return &conflict
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/waldrey/eulabs/internal/entity"
+)
+
+// CatalogState changes whenever a product is created, updated or deleted, it
+// is cheap enough to check before reading the catalog itself.
+type CatalogState struct {
+	Count        int64
+	LastModified time.Time
+}
+
+func (p *Product) CatalogState(ctx context.Context) (CatalogState, error) {
+	var row struct {
+		Count     int64
+		UpdatedAt *time.Time
+		DeletedAt *time.Time
+	}
+
+	// soft deleted rows are read too, a delete does not touch updated_at
+	err := session(ctx, p.DB).Unscoped().Model(&entity.Product{}).
+		Select("COUNT(CASE WHEN deleted_at IS NULL THEN 1 END) AS count, MAX(updated_at) AS updated_at, MAX(deleted_at) AS deleted_at").
+		Scan(&row).Error
+	if err != nil {
+		return CatalogState{}, err
+	}
+
+	state := CatalogState{Count: row.Count}
+	for _, at := range []*time.Time{row.UpdatedAt, row.DeletedAt} {
+		if at != nil && at.After(state.LastModified) {
+			state.LastModified = *at
+		}
+	}
+
+	return state, nil
+}
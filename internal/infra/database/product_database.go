@@ -1,56 +1,275 @@
 package database
 
 import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
 	"github.com/waldrey/eulabs/internal/entity"
+	"github.com/waldrey/eulabs/pkg/rsql"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Product struct {
 	DB *gorm.DB
+
+	searchFallback searchFallback
 }
 
 func ProductRepository(db *gorm.DB) *Product {
 	return &Product{DB: db}
 }
 
-func (p *Product) Create(product *entity.Product) (*entity.Product, error) {
-	err := p.DB.Create(product).Error
+func (p *Product) Create(ctx context.Context, product *entity.Product) (*entity.Product, error) {
+	err := session(ctx, p.DB).Create(product).Error
 	if err != nil {
 		return nil, err
 	}
 
 	var createdProduct entity.Product
-	err = p.DB.First(&createdProduct, product.ID).Error
+	err = session(ctx, p.DB).First(&createdProduct, product.ID).Error
 	if err != nil {
 		return nil, err
 	}
+	p.reindex(ctx, &createdProduct, false)
 
 	return &createdProduct, nil
 }
 
-func (p *Product) FindAll() ([]entity.Product, error) {
+func (p *Product) FindAll(ctx context.Context) ([]entity.Product, error) {
 	var products []entity.Product
-	err := p.DB.Find(&products).Error
+	err := session(ctx, p.DB).Find(&products).Error
 
 	return products, err
 }
 
-func (p *Product) Update(product *entity.Product) error {
-	err := p.DB.Save(product).Error
+func (p *Product) List(ctx context.Context, options ListOptions) ([]entity.Product, int64, error) {
+	query, err := applyFilter(session(ctx, p.DB).Model(&entity.Product{}), options.Filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var total int64
+	err = query.Session(&gorm.Session{}).Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query, err = applySort(query, options.Sort)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var products []entity.Product
+	err = applySelect(query, options.Fields).Offset(options.Offset).Limit(options.Limit).Find(&products).Error
+
+	return products, total, err
+}
+
+func (p *Product) ListAfter(ctx context.Context, options ListOptions) ([]entity.Product, error) {
+	query, err := applyFilter(session(ctx, p.DB), options.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	query = query.Order("created_at, id").Limit(options.Limit)
+	if options.After != nil {
+		query = query.Where(
+			"(created_at > ? OR (created_at = ? AND id > ?))",
+			options.After.CreatedAt, options.After.CreatedAt, options.After.ID,
+		)
+	}
+
+	var products []entity.Product
+	err = applySelect(query, options.Fields).Find(&products).Error
+
+	return products, err
+}
+
+// Export walks the products through a database cursor, reading one row at a
+// time whatever the number of products, and calls each for every one of them.
+func (p *Product) Export(ctx context.Context, options ListOptions, each func(product entity.Product) error) error {
+	query, err := applyFilter(session(ctx, p.DB).Model(&entity.Product{}), options.Filter)
 	if err != nil {
 		return err
 	}
 
+	query, err = applySort(query, options.Sort)
+	if err != nil {
+		return err
+	}
+
+	rows, err := query.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var product entity.Product
+		if err := p.DB.ScanRows(rows, &product); err != nil {
+			return err
+		}
+
+		if err := each(product); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// Update writes the product only while it is still at the version it was
+// read, bumping the version, otherwise entity.ErrVersionConflict is returned.
+func (p *Product) Update(ctx context.Context, product *entity.Product) error {
+	result := session(ctx, p.DB).Model(product).Where("version = ?", product.Version).Updates(map[string]interface{}{
+		"name":           product.Name,
+		"description":    product.Description,
+		"price_amount":   product.Price.Amount,
+		"price_currency": product.Price.Currency,
+		"version":        gorm.Expr("version + 1"),
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return entity.ErrVersionConflict
+	}
+	product.Version++
+	p.reindex(ctx, product, false)
+
 	return nil
 }
 
-func (p *Product) Delete(product *entity.Product) error {
-	err := p.DB.Delete(product).Error
-	return err
+// Delete removes the product only while it is still at the version it was
+// read, like Update.
+func (p *Product) Delete(ctx context.Context, product *entity.Product) error {
+	result := session(ctx, p.DB).Where("version = ?", product.Version).Delete(product)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return entity.ErrVersionConflict
+	}
+	p.reindex(ctx, product, true)
+
+	return nil
+}
+
+func (p *Product) FindByID(ctx context.Context, id int) (*entity.Product, error) {
+	var product entity.Product
+	err := session(ctx, p.DB).First(&product, "id = ?", id).Error
+	return &product, notFound(err, entity.ErrProductNotFound)
+}
+
+// FindByIDForUpdate reads the product locking its row, with SELECT ... FOR
+// UPDATE, until the end of the unit of work of the context.
+func (p *Product) FindByIDForUpdate(ctx context.Context, id int) (*entity.Product, error) {
+	var product entity.Product
+	err := session(ctx, p.DB).Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, "id = ?", id).Error
+	return &product, notFound(err, entity.ErrProductNotFound)
 }
 
-func (p *Product) FindByID(id int) (*entity.Product, error) {
+func (p *Product) FindByIDFields(ctx context.Context, id int, fields []string) (*entity.Product, error) {
 	var product entity.Product
-	err := p.DB.First(&product, "id = ?", id).Error
-	return &product, err
+	err := applySelect(session(ctx, p.DB), fields).First(&product, "id = ?", id).Error
+	return &product, notFound(err, entity.ErrProductNotFound)
+}
+
+// sortColumns maps the sortable fields to their columns, anything outside of it
+// never reaches the ORDER BY clause. Prices are grouped by currency since their
+// amounts are only comparable within one.
+var sortColumns = map[string][]string{
+	"id":         {"id"},
+	"name":       {"name"},
+	"price":      {"price_currency", "price_amount"},
+	"created_at": {"created_at"},
+	"updated_at": {"updated_at"},
+}
+
+// selectColumns maps the fields of a sparse fieldset to their columns.
+var selectColumns = map[string][]string{
+	"id":          {"id"},
+	"name":        {"name"},
+	"description": {"description"},
+	"price":       {"price_amount", "price_currency"},
+	"created_at":  {"created_at"},
+	"updated_at":  {"updated_at"},
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func applyFilter(query *gorm.DB, filter ProductFilter) (*gorm.DB, error) {
+	if filter.Currency != "" {
+		query = query.Where("price_currency = ?", filter.Currency)
+	}
+
+	if filter.MinPrice > 0 {
+		query = query.Where("price_amount >= ?", filter.MinPrice)
+	}
+
+	if filter.MaxPrice > 0 {
+		query = query.Where("price_amount <= ?", filter.MaxPrice)
+	}
+
+	if filter.NameContains != "" {
+		query = query.Where("name LIKE ?", "%"+likeEscaper.Replace(filter.NameContains)+"%")
+	}
+
+	if !filter.CreatedAfter.IsZero() {
+		query = query.Where("created_at > ?", filter.CreatedAfter)
+	}
+
+	if !filter.CreatedBefore.IsZero() {
+		query = query.Where("created_at < ?", filter.CreatedBefore)
+	}
+
+	if filter.Expression != nil {
+		condition, args, err := rsql.Where(filter.Expression)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where(condition, args...)
+	}
+
+	return query, nil
+}
+
+func applySort(query *gorm.DB, sorts []Sort) (*gorm.DB, error) {
+	for _, sort := range sorts {
+		columns, ok := sortColumns[sort.Field]
+		if !ok {
+			return nil, fmt.Errorf("unsupported sort field %q", sort.Field)
+		}
+
+		for _, column := range columns {
+			query = query.Order(clause.OrderByColumn{Column: clause.Column{Name: column}, Desc: sort.Desc})
+		}
+	}
+
+	// id keeps the order stable between pages
+	return query.Order("id"), nil
+}
+
+// applySelect restricts the query to the requested fields, id and created_at
+// are always read since the keyset cursor is built from them.
+func applySelect(query *gorm.DB, fields []string) *gorm.DB {
+	if len(fields) == 0 {
+		return query
+	}
+
+	columns := []string{"id", "created_at", "updated_at", "version"}
+	for _, field := range fields {
+		for _, column := range selectColumns[field] {
+			if !slices.Contains(columns, column) {
+				columns = append(columns, column)
+			}
+		}
+	}
+
+	return query.Select(columns)
 }
@@ -0,0 +1,135 @@
+package database
+
+import (
+	"context"
+	"sync"
+
+	"github.com/waldrey/eulabs/internal/entity"
+	"github.com/waldrey/eulabs/pkg/search"
+)
+
+const fullTextMatch = "MATCH(name, description) AGAINST (? IN NATURAL LANGUAGE MODE)"
+
+type SearchResult struct {
+	Product entity.Product
+	Score   float64
+}
+
+// searchFallback is the in-memory index used by drivers without full-text
+// support, it is loaded on first use and kept current by the repository writes.
+type searchFallback struct {
+	once  sync.Once
+	index *search.Index
+	err   error
+}
+
+func (p *Product) Search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	if p.fullTextSupported() {
+		return p.fullTextSearch(ctx, query, limit)
+	}
+
+	index, err := p.fallbackIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	hits := index.Search(query, limit)
+	if len(hits) == 0 {
+		return []SearchResult{}, nil
+	}
+
+	ids := make([]uint, 0, len(hits))
+	for _, hit := range hits {
+		ids = append(ids, hit.ID)
+	}
+
+	var products []entity.Product
+	err = session(ctx, p.DB).Find(&products, ids).Error
+	if err != nil {
+		return nil, err
+	}
+
+	productsByID := make(map[uint]entity.Product, len(products))
+	for _, product := range products {
+		productsByID[product.ID] = product
+	}
+
+	results := make([]SearchResult, 0, len(hits))
+	for _, hit := range hits {
+		if product, ok := productsByID[hit.ID]; ok {
+			results = append(results, SearchResult{Product: product, Score: hit.Score})
+		}
+	}
+
+	return results, nil
+}
+
+func (p *Product) fullTextSupported() bool {
+	return p.DB.Dialector.Name() == "mysql"
+}
+
+func (p *Product) fullTextSearch(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	var rows []struct {
+		entity.Product
+		Score float64
+	}
+
+	err := session(ctx, p.DB).Model(&entity.Product{}).
+		Select("*, "+fullTextMatch+" AS score", query).
+		Where(fullTextMatch, query).
+		Order("score DESC").
+		Limit(limit).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]SearchResult, 0, len(rows))
+	for _, row := range rows {
+		results = append(results, SearchResult{Product: row.Product, Score: row.Score})
+	}
+
+	return results, nil
+}
+
+func (p *Product) fallbackIndex(ctx context.Context) (*search.Index, error) {
+	p.searchFallback.once.Do(func() {
+		// the index is loaded once, a request going away or rolled back must
+		// not fail it for the next ones
+		products, err := p.FindAll(withoutTransaction(context.WithoutCancel(ctx)))
+		if err != nil {
+			p.searchFallback.err = err
+			return
+		}
+
+		index := search.NewIndex()
+		for _, product := range products {
+			index.Add(product.ID, product.Name, product.Description)
+		}
+		p.searchFallback.index = index
+	})
+
+	return p.searchFallback.index, p.searchFallback.err
+}
+
+// reindex keeps the fallback index current after a write is committed, it is
+// a no-op when full-text search is handled by the database.
+func (p *Product) reindex(ctx context.Context, product *entity.Product, deleted bool) {
+	if p.fullTextSupported() {
+		return
+	}
+
+	id, name, description := product.ID, product.Name, product.Description
+	afterCommit(ctx, func() {
+		index, err := p.fallbackIndex(ctx)
+		if err != nil {
+			return
+		}
+
+		if deleted {
+			index.Remove(id)
+			return
+		}
+		index.Add(id, name, description)
+	})
+}
@@ -0,0 +1,72 @@
+package database
+
+import (
+	"context"
+	"github.com/waldrey/eulabs/internal/entity"
+)
+
+// PriceStats holds the price aggregates in minor units.
+type PriceStats struct {
+	Count    int64
+	MinPrice int64
+	MaxPrice int64
+	AvgPrice float64
+}
+
+func (p *Product) PriceStats(ctx context.Context, filter ProductFilter) (PriceStats, error) {
+	var stats PriceStats
+
+	query, err := applyFilter(session(ctx, p.DB).Model(&entity.Product{}), filter)
+	if err != nil {
+		return stats, err
+	}
+
+	err = query.
+		Select("COUNT(*) AS count, COALESCE(MIN(price_amount), 0) AS min_price, COALESCE(MAX(price_amount), 0) AS max_price, COALESCE(AVG(price_amount), 0) AS avg_price").
+		Scan(&stats).Error
+
+	return stats, err
+}
+
+// PricesAt returns the prices at the given position of the products ordered
+// by price, used to find the median without loading every row.
+func (p *Product) PricesAt(ctx context.Context, filter ProductFilter, offset int, limit int) ([]int64, error) {
+	query, err := applyFilter(session(ctx, p.DB).Model(&entity.Product{}), filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var prices []int64
+	err = query.Order("price_amount").Offset(offset).Limit(limit).Pluck("price_amount", &prices).Error
+
+	return prices, err
+}
+
+// PriceHistogram counts the products by bucket of width starting at from, the
+// result is keyed by bucket index.
+func (p *Product) PriceHistogram(ctx context.Context, filter ProductFilter, from int64, width int64) (map[int]int64, error) {
+	query, err := applyFilter(session(ctx, p.DB).Model(&entity.Product{}), filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		Bucket int
+		Count  int64
+	}
+
+	err = query.
+		Select("FLOOR((price_amount - ?) / ?) AS bucket, COUNT(*) AS count", from, width).
+		Group("bucket").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	histogram := make(map[int]int64, len(rows))
+	for _, row := range rows {
+		histogram[row.Bucket] += row.Count
+	}
+
+	return histogram, nil
+}
@@ -0,0 +1,106 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/waldrey/eulabs/internal/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ListTrashed reads a page of the soft deleted products, the last deleted
+// first, along with the number of products in the trash.
+func (p *Product) ListTrashed(ctx context.Context, offset int, limit int) ([]entity.Product, int64, error) {
+	query := session(ctx, p.DB).Unscoped().Model(&entity.Product{}).Where("deleted_at IS NOT NULL")
+
+	var total int64
+	err := query.Session(&gorm.Session{}).Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var products []entity.Product
+	err = query.Order("deleted_at DESC").Order("id").Offset(offset).Limit(limit).Find(&products).Error
+
+	return products, total, err
+}
+
+// FindWithTrashedForUpdate reads the product whether it is deleted or not,
+// locking its row like FindByIDForUpdate.
+func (p *Product) FindWithTrashedForUpdate(ctx context.Context, id int) (*entity.Product, error) {
+	var product entity.Product
+	err := session(ctx, p.DB).Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, "id = ?", id).Error
+	return &product, notFound(err, entity.ErrProductNotFound)
+}
+
+// Restore takes the product out of the trash only while it is still at the
+// version it was read, like Update.
+func (p *Product) Restore(ctx context.Context, product *entity.Product) error {
+	result := session(ctx, p.DB).Unscoped().Model(product).Where("version = ?", product.Version).Updates(map[string]interface{}{
+		"deleted_at": nil,
+		"version":    gorm.Expr("version + 1"),
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return entity.ErrVersionConflict
+	}
+	product.DeletedAt = gorm.DeletedAt{}
+	product.Version++
+	p.reindex(ctx, product, false)
+
+	return nil
+}
+
+// Purge removes the product for good, along with its explicit prices, only
+// while it is still at the version it was read.
+func (p *Product) Purge(ctx context.Context, product *entity.Product) error {
+	err := session(ctx, p.DB).Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Where("product_id = ?", product.ID).Delete(&entity.ProductPrice{}).Error
+		if err != nil {
+			return err
+		}
+
+		result := tx.Unscoped().Where("version = ?", product.Version).Delete(product)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return entity.ErrVersionConflict
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	p.reindex(ctx, product, true)
+
+	return nil
+}
+
+// PurgeTrashed removes for good the products deleted before the given time,
+// along with their explicit prices, returning how many were removed. The
+// search index and the suggestions are left as they are: a product leaves both
+// when it is deleted, and neither is loaded with the trashed products.
+func (p *Product) PurgeTrashed(ctx context.Context, before time.Time) (int64, error) {
+	var purged int64
+	err := session(ctx, p.DB).Transaction(func(tx *gorm.DB) error {
+		trashed := tx.Unscoped().Model(&entity.Product{}).Select("id").Where("deleted_at < ?", before)
+		err := tx.Unscoped().Where("product_id IN (?)", trashed).Delete(&entity.ProductPrice{}).Error
+		if err != nil {
+			return err
+		}
+
+		result := tx.Unscoped().Where("deleted_at < ?", before).Delete(&entity.Product{})
+		purged = result.RowsAffected
+
+		return result.Error
+	})
+
+	return purged, err
+}
@@ -0,0 +1,76 @@
+package database
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type transactionKey struct{}
+
+// transaction is the transaction of a unit of work, carried by the context
+// given to the repositories.
+type transaction struct {
+	tx        *gorm.DB
+	committed []func()
+}
+
+// UnitOfWork runs several repository calls in a single transaction, every
+// repository given the context of the work reads and writes through it.
+type UnitOfWork struct {
+	DB *gorm.DB
+}
+
+func NewUnitOfWork(db *gorm.DB) *UnitOfWork {
+	return &UnitOfWork{DB: db}
+}
+
+// Do commits the work when fn returns nil and rolls it back otherwise. A work
+// started inside another one joins it, and is committed with it.
+func (u *UnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(transactionKey{}).(*transaction); ok {
+		return fn(ctx)
+	}
+
+	current := &transaction{}
+	err := u.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		current.tx = tx
+		return fn(context.WithValue(ctx, transactionKey{}, current))
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, committed := range current.committed {
+		committed()
+	}
+
+	return nil
+}
+
+// session is the connection of the repositories, the transaction of the unit
+// of work of the context when there is one.
+func session(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if current, ok := ctx.Value(transactionKey{}).(*transaction); ok {
+		return current.tx.WithContext(ctx)
+	}
+
+	return db.WithContext(ctx)
+}
+
+// withoutTransaction leaves the unit of work of the context, the repositories
+// given the returned context read what is already committed.
+func withoutTransaction(ctx context.Context) context.Context {
+	return context.WithValue(ctx, transactionKey{}, nil)
+}
+
+// afterCommit defers fn until the unit of work of the context is committed,
+// it is dropped on rollback. Without a unit of work fn runs right away.
+func afterCommit(ctx context.Context, fn func()) {
+	if current, ok := ctx.Value(transactionKey{}).(*transaction); ok {
+		current.committed = append(current.committed, fn)
+		return
+	}
+
+	fn()
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/waldrey/eulabs/internal/dto"
+	"github.com/waldrey/eulabs/internal/entity"
+	"github.com/waldrey/eulabs/internal/infra/database"
+)
+
+var (
+	ErrIdempotencyKeyReused   = entity.Validation("idempotency_key_reused", "idempotency key was used with another request")
+	ErrIdempotencyKeyInFlight = entity.Conflict("idempotency_key_in_flight", "a request with this idempotency key is in progress")
+)
+
+const (
+	// IdempotencyTTL is how long a response is replayed for its key
+	IdempotencyTTL = 24 * time.Hour
+
+	// IdempotencyLockTimeout frees the key of a request that never completed,
+	// like one running when the server crashed. The requests still running
+	// extend it, see Extend
+	IdempotencyLockTimeout = time.Minute
+)
+
+// replayedHeaders are the response headers stored along with the body.
+var replayedHeaders = []string{"Content-Type", "ETag", "Last-Modified", "Location"}
+
+type Idempotency struct {
+	repository database.IdempotencyInterface
+}
+
+func IdempotencyService(repository database.IdempotencyInterface) *Idempotency {
+	return &Idempotency{repository: repository}
+}
+
+// Begin reserves the key for the request, it returns the stored response when
+// the key was already used by the same request.
+func (i *Idempotency) Begin(ctx context.Context, key string, fingerprint string) (*dto.StoredResponse, error) {
+	record, reserved, err := i.repository.Reserve(ctx, &entity.IdempotencyKey{
+		Key:         key,
+		Fingerprint: fingerprint,
+		ExpiresAt:   time.Now().Add(IdempotencyLockTimeout),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if reserved {
+		return nil, nil
+	}
+
+	if record.Fingerprint != fingerprint {
+		return nil, ErrIdempotencyKeyReused
+	}
+
+	if record.StatusCode == 0 {
+		return nil, ErrIdempotencyKeyInFlight
+	}
+
+	response := &dto.StoredResponse{
+		StatusCode: record.StatusCode,
+		Header:     http.Header{},
+		Body:       record.Body,
+	}
+
+	var header map[string]string
+	if err := json.Unmarshal([]byte(record.Headers), &header); err == nil {
+		for name, value := range header {
+			response.Header.Set(name, value)
+		}
+	}
+
+	return response, nil
+}
+
+func (i *Idempotency) Complete(ctx context.Context, key string, response dto.StoredResponse) error {
+	header := map[string]string{}
+	for _, name := range replayedHeaders {
+		if value := response.Header.Get(name); value != "" {
+			header[name] = value
+		}
+	}
+
+	headers, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+
+	return i.repository.Complete(ctx, &entity.IdempotencyKey{
+		Key:        key,
+		StatusCode: response.StatusCode,
+		Headers:    string(headers),
+		Body:       response.Body,
+		ExpiresAt:  time.Now().Add(IdempotencyTTL),
+	})
+}
+
+// Extend keeps the key reserved for another IdempotencyLockTimeout, a request
+// running longer than it must extend its key before the lock expires.
+func (i *Idempotency) Extend(ctx context.Context, key string) error {
+	return i.repository.Extend(ctx, key, time.Now().Add(IdempotencyLockTimeout))
+}
+
+// Release forgets the key, so the request can be retried with it.
+func (i *Idempotency) Release(ctx context.Context, key string) error {
+	return i.repository.Release(ctx, key)
+}
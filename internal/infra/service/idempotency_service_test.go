@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	testify "github.com/stretchr/testify/mock"
+	"github.com/waldrey/eulabs/internal/entity"
+	"github.com/waldrey/eulabs/test/mock"
+)
+
+func TestGivenACompletedKey_WhenICallBeginIdempotencyService_ThenShouldReceiveTheStoredResponse(t *testing.T) {
+	repository := &mock.IdempotencyRepositoryMock{}
+	repository.On("Reserve", testify.Anything, testify.AnythingOfType("*entity.IdempotencyKey")).Return(&entity.IdempotencyKey{
+		Key:         "checkout-1",
+		Fingerprint: "abc",
+		StatusCode:  http.StatusCreated,
+		Headers:     `{"Content-Type": "application/json", "ETag": "\"1\""}`,
+		Body:        []byte(`{"data": {}}`),
+	}, false, nil)
+	service := IdempotencyService(repository)
+
+	response, err := service.Begin(context.Background(), "checkout-1", "abc")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, response.StatusCode)
+	assert.Equal(t, `"1"`, response.Header.Get("ETag"))
+	assert.Equal(t, []byte(`{"data": {}}`), response.Body)
+
+	_, err = service.Begin(context.Background(), "checkout-1", "def")
+	assert.ErrorIs(t, err, ErrIdempotencyKeyReused)
+}
+
+func TestGivenAKeyInFlight_WhenICallBeginIdempotencyService_ThenShouldReceiveAnError(t *testing.T) {
+	repository := &mock.IdempotencyRepositoryMock{}
+	repository.On("Reserve", testify.Anything, testify.AnythingOfType("*entity.IdempotencyKey")).Return(&entity.IdempotencyKey{
+		Key:         "checkout-1",
+		Fingerprint: "abc",
+	}, false, nil)
+	service := IdempotencyService(repository)
+
+	_, err := service.Begin(context.Background(), "checkout-1", "abc")
+	assert.ErrorIs(t, err, ErrIdempotencyKeyInFlight)
+}
+
+func TestGivenAKeyInFlight_WhenICallExtendIdempotencyService_ThenShouldPushBackItsExpiry(t *testing.T) {
+	repository := &mock.IdempotencyRepositoryMock{}
+	repository.On("Extend", testify.Anything, "bulk-1", testify.MatchedBy(func(expiresAt time.Time) bool {
+		return expiresAt.After(time.Now().Add(IdempotencyLockTimeout - time.Second))
+	})).Return(nil)
+	service := IdempotencyService(repository)
+
+	assert.NoError(t, service.Extend(context.Background(), "bulk-1"))
+	repository.AssertExpectations(t)
+}
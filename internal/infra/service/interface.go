@@ -1,14 +1,57 @@
 package service
 
 import (
+	"context"
+	"io"
+	"os"
+
 	"github.com/waldrey/eulabs/internal/dto"
 	"github.com/waldrey/eulabs/internal/entity"
+	"github.com/waldrey/eulabs/pkg/cursor"
+	"github.com/waldrey/eulabs/pkg/money"
 )
 
 type ProductInterface interface {
-	Create(product dto.CreateProductRequest) (*entity.Product, error)
-	FindAll() ([]entity.Product, error)
-	FindOne(id int) (*entity.Product, error)
-	Update(id int, product dto.PutProductRequest) (*entity.Product, error)
-	Delete(id int) error
+	Create(ctx context.Context, product dto.CreateProductRequest) (*entity.Product, error)
+	FindAll(ctx context.Context) ([]entity.Product, error)
+	List(ctx context.Context, query dto.ListProductsQuery) ([]entity.Product, int64, error)
+	ListAfter(ctx context.Context, query dto.ListProductsQuery, after *cursor.Position) ([]entity.Product, *cursor.Position, error)
+	Export(ctx context.Context, query dto.ExportProductsQuery, each func(product entity.Product) error) error
+	FindOne(ctx context.Context, id int) (*entity.Product, error)
+	FindOneFields(ctx context.Context, id int, fields []string) (*entity.Product, error)
+	CatalogValidators(ctx context.Context) (dto.Validators, error)
+	Update(ctx context.Context, id int, precondition dto.Precondition, product dto.PutProductRequest) (*entity.Product, error)
+	Patch(ctx context.Context, id int, precondition dto.Precondition, product dto.UpdateProductRequest) (*entity.Product, error)
+	MergePatch(ctx context.Context, id int, precondition dto.Precondition, patch []byte) (*entity.Product, error)
+	JSONPatch(ctx context.Context, id int, precondition dto.Precondition, patch []byte) (*entity.Product, error)
+	Delete(ctx context.Context, id int, precondition dto.Precondition) error
+	Trash(ctx context.Context, query dto.ListTrashQuery) ([]entity.Product, int64, error)
+	Restore(ctx context.Context, id int, precondition dto.Precondition) (*entity.Product, error)
+	Purge(ctx context.Context, id int, precondition dto.Precondition) error
+	Bulk(ctx context.Context, operations []dto.BulkProductOperation, atomic bool) ([]dto.BulkProductResult, error)
+	Import(ctx context.Context, source dto.ImportSource, dryRun bool) (*dto.ImportResult, error)
+	Search(ctx context.Context, query dto.SearchProductsQuery) ([]dto.ProductSearchResult, error)
+	Suggest(query dto.SuggestProductsQuery) []dto.ProductSuggestion
+	Stats(ctx context.Context, query dto.ProductStatsQuery) (*dto.ProductStatsResponse, error)
+}
+
+type PriceListInterface interface {
+	ProductPrices(ctx context.Context, productID int) ([]money.Money, error)
+	ReplaceProductPrices(ctx context.Context, productID int, precondition dto.Precondition, request dto.ProductPricesRequest) (*entity.Product, []money.Money, error)
+	ExchangeRates(ctx context.Context) ([]dto.ExchangeRateResponse, error)
+	SaveExchangeRates(ctx context.Context, request dto.SaveExchangeRatesRequest) ([]dto.ExchangeRateResponse, error)
+	Convert(ctx context.Context, products []entity.Product, currency string) ([]dto.ConvertedProduct, error)
+}
+
+type IdempotencyInterface interface {
+	Begin(ctx context.Context, key string, fingerprint string) (*dto.StoredResponse, error)
+	Complete(ctx context.Context, key string, response dto.StoredResponse) error
+	Extend(ctx context.Context, key string) error
+	Release(ctx context.Context, key string) error
+}
+
+type JobInterface interface {
+	Enqueue(ctx context.Context, request dto.JobRequest, input io.Reader) (*entity.Job, error)
+	Find(ctx context.Context, id int) (*entity.Job, error)
+	Result(job *entity.Job) (*os.File, error)
 }
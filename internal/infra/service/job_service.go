@@ -0,0 +1,421 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/waldrey/eulabs/internal/dto"
+	"github.com/waldrey/eulabs/internal/entity"
+	"github.com/waldrey/eulabs/internal/infra/database"
+)
+
+var (
+	ErrJobNotFinished   = entity.Conflict("job_not_finished", "job has not succeeded")
+	ErrJobNoResult      = entity.NotFound("job_result_expired", "job result is no longer available")
+	ErrJobRunnerStopped = errors.New("job runner is stopped")
+)
+
+const (
+	// MaxJobAttempts is how many times a job interrupted by a restart is run
+	// before it fails
+	MaxJobAttempts = 3
+
+	// jobPollInterval is how often idle workers look for queued jobs, besides
+	// being woken by Enqueue
+	jobPollInterval = 5 * time.Second
+
+	// jobProgressInterval is how often the progress of a job is saved
+	jobProgressInterval = time.Second
+)
+
+// JobRun is a job being run, its input is nil when the job has none and the
+// result written to Output is kept only when the job succeeds.
+type JobRun struct {
+	Job    entity.Job
+	Input  io.Reader
+	Output io.Writer
+
+	progress func(rows int64)
+}
+
+// Progress reports the number of rows processed so far.
+func (r *JobRun) Progress(rows int64) {
+	r.progress(rows)
+}
+
+// JobFunc runs a job of a kind, the returned summary is stored as JSON and
+// reported along with the job status. The context is cancelled when the
+// server stops, the job is then queued again.
+type JobFunc func(ctx context.Context, run *JobRun) (interface{}, error)
+
+// Jobs runs the queued jobs with a fixed number of workers, the jobs table is
+// the queue so jobs survive restarts. The runner is meant to be the only one
+// using the table, jobs left running by a previous process are queued again.
+type Jobs struct {
+	repository database.JobInterface
+	dir        string
+	workers    int
+	funcs      map[string]JobFunc
+
+	wake    chan struct{}
+	stop    chan struct{}
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	stopped bool
+}
+
+func JobService(repository database.JobInterface, dir string, workers int) *Jobs {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Jobs{
+		repository: repository,
+		dir:        dir,
+		workers:    max(workers, 1),
+		funcs:      map[string]JobFunc{},
+		wake:       make(chan struct{}, 1),
+		stop:       make(chan struct{}),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// Register sets the function running the jobs of the kind, it must be called
+// before Start.
+func (j *Jobs) Register(kind string, fn JobFunc) {
+	j.funcs[kind] = fn
+}
+
+// Start recovers the jobs interrupted by the last shutdown and starts the
+// workers.
+func (j *Jobs) Start() error {
+	if err := os.MkdirAll(j.dir, 0o750); err != nil {
+		return err
+	}
+
+	if err := j.recover(); err != nil {
+		return err
+	}
+
+	for i := 0; i < j.workers; i++ {
+		j.wg.Add(1)
+		go j.work()
+	}
+
+	return nil
+}
+
+// recover queues the jobs left running again, failing the ones run too many
+// times or whose input was lost.
+func (j *Jobs) recover() error {
+	jobs, err := j.repository.FindByStatus(context.Background(), entity.JobRunning)
+	if err != nil {
+		return err
+	}
+
+	for i := range jobs {
+		job := &jobs[i]
+		_, statErr := os.Stat(j.inputPath(job.ID))
+
+		switch {
+		case job.Attempts >= MaxJobAttempts:
+			j.fail(job, fmt.Errorf("interrupted %d times", job.Attempts))
+		case job.HasInput && statErr != nil:
+			j.fail(job, errors.New("input file is missing"))
+		default:
+			log.Printf("resuming job %d", job.ID)
+			job.Status = entity.JobQueued
+			job.Progress = 0
+			if err := j.repository.Save(context.Background(), job); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Enqueue stores the job and its input, read until EOF, and wakes a worker.
+func (j *Jobs) Enqueue(ctx context.Context, request dto.JobRequest, input io.Reader) (*entity.Job, error) {
+	if j.isStopped() {
+		return nil, ErrJobRunnerStopped
+	}
+
+	params, err := json.Marshal(request.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	// the job is created running, so no worker takes it before its input is in
+	// place, then queued
+	job := &entity.Job{
+		Kind:       request.Kind,
+		Status:     entity.JobRunning,
+		Params:     string(params),
+		HasInput:   input != nil,
+		ResultType: request.ResultType,
+		ResultName: request.ResultName,
+	}
+
+	if err := j.repository.Create(ctx, job); err != nil {
+		return nil, err
+	}
+
+	if input != nil {
+		if err := writeFile(j.inputPath(job.ID), input); err != nil {
+			j.fail(job, fmt.Errorf("storing input: %w", err))
+			return nil, err
+		}
+	}
+
+	// the input is stored, the job is queued even when the client is gone
+	job.Status = entity.JobQueued
+	if err := j.repository.Save(context.WithoutCancel(ctx), job); err != nil {
+		j.fail(job, fmt.Errorf("queueing: %w", err))
+		return nil, err
+	}
+
+	select {
+	case j.wake <- struct{}{}:
+	default:
+	}
+
+	return job, nil
+}
+
+func (j *Jobs) Find(ctx context.Context, id int) (*entity.Job, error) {
+	return j.repository.FindByID(ctx, id)
+}
+
+// Result opens the result of a succeeded job.
+func (j *Jobs) Result(job *entity.Job) (*os.File, error) {
+	if job.Status != entity.JobSucceeded {
+		return nil, ErrJobNotFinished
+	}
+
+	file, err := os.Open(j.resultPath(job.ID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrJobNoResult
+	}
+
+	return file, err
+}
+
+// Shutdown stops taking jobs and waits for the running ones. When the context
+// ends first they are cancelled, and queued again, before waiting for them to
+// return.
+func (j *Jobs) Shutdown(ctx context.Context) error {
+	j.mu.Lock()
+	if !j.stopped {
+		j.stopped = true
+		close(j.stop)
+	}
+	j.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		j.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		j.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (j *Jobs) isStopped() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.stopped
+}
+
+func (j *Jobs) work() {
+	defer j.wg.Done()
+
+	ticker := time.NewTicker(jobPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-j.stop:
+			return
+		default:
+		}
+
+		job, err := j.repository.ClaimNext(j.ctx)
+		if err != nil {
+			log.Printf("failed claim job: %v", err)
+		}
+
+		if job != nil {
+			j.run(job)
+			continue
+		}
+
+		select {
+		case <-j.stop:
+			return
+		case <-j.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+func (j *Jobs) run(job *entity.Job) {
+	log.Printf("running job %d (%s)", job.ID, job.Kind)
+
+	summary, err := j.execute(job)
+	if j.ctx.Err() != nil {
+		log.Printf("job %d interrupted by shutdown", job.ID)
+		job.Status = entity.JobQueued
+		job.Progress = 0
+		// the context of the runner is done, the job is still saved
+		if err := j.repository.Save(context.Background(), job); err != nil {
+			log.Printf("failed requeue job %d: %v", job.ID, err)
+		}
+		return
+	}
+
+	// a failed job keeps the summary of what it did, like the rows imported
+	if summary != nil {
+		encoded, marshalErr := json.Marshal(summary)
+		if marshalErr == nil {
+			job.Summary = string(encoded)
+		} else if err == nil {
+			err = marshalErr
+		}
+	}
+
+	if err != nil {
+		j.fail(job, err)
+		return
+	}
+
+	now := time.Now()
+	job.Status = entity.JobSucceeded
+	job.FinishedAt = &now
+	if err := j.repository.Save(context.Background(), job); err != nil {
+		log.Printf("failed save job %d: %v", job.ID, err)
+		return
+	}
+
+	os.Remove(j.inputPath(job.ID))
+	log.Printf("job %d succeeded", job.ID)
+}
+
+// execute runs the function of the job, the result is written aside and only
+// moved in place when the job succeeds.
+func (j *Jobs) execute(job *entity.Job) (summary interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+
+	fn, ok := j.funcs[job.Kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown job kind %q", job.Kind)
+	}
+
+	run := &JobRun{Job: *job}
+
+	if job.HasInput {
+		input, err := os.Open(j.inputPath(job.ID))
+		if err != nil {
+			return nil, err
+		}
+		defer input.Close()
+		run.Input = input
+	}
+
+	output, err := os.CreateTemp(j.dir, strconv.FormatUint(uint64(job.ID), 10)+".result-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(output.Name())
+	defer output.Close()
+	run.Output = output
+
+	saved := time.Now()
+	run.progress = func(rows int64) {
+		job.Progress = rows
+		if time.Since(saved) < jobProgressInterval {
+			return
+		}
+
+		saved = time.Now()
+		if err := j.repository.UpdateProgress(j.ctx, job.ID, rows); err != nil {
+			log.Printf("failed save progress of job %d: %v", job.ID, err)
+		}
+	}
+
+	summary, err = fn(j.ctx, run)
+	if err != nil {
+		return summary, err
+	}
+
+	if err := output.Close(); err != nil {
+		return summary, err
+	}
+
+	return summary, os.Rename(output.Name(), j.resultPath(job.ID))
+}
+
+func (j *Jobs) fail(job *entity.Job, err error) {
+	log.Printf("job %d failed: %v", job.ID, err)
+
+	now := time.Now()
+	job.Status = entity.JobFailed
+	job.Error = err.Error()
+	job.FinishedAt = &now
+	if err := j.repository.Save(context.Background(), job); err != nil {
+		log.Printf("failed save job %d: %v", job.ID, err)
+	}
+
+	os.Remove(j.inputPath(job.ID))
+}
+
+func (j *Jobs) inputPath(id uint) string {
+	return filepath.Join(j.dir, strconv.FormatUint(uint64(id), 10)+".input")
+}
+
+func (j *Jobs) resultPath(id uint) string {
+	return filepath.Join(j.dir, strconv.FormatUint(uint64(id), 10)+".result")
+}
+
+// writeFile copies the reader to the path, the file only appears once it is
+// complete.
+func writeFile(path string, r io.Reader) error {
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), path)
+}
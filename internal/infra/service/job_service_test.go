@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/waldrey/eulabs/internal/dto"
+	"github.com/waldrey/eulabs/internal/entity"
+	"gorm.io/gorm"
+)
+
+// jobStore keeps the jobs in memory, like the jobs table.
+type jobStore struct {
+	mu   sync.Mutex
+	jobs map[uint]entity.Job
+}
+
+func newJobStore(jobs ...entity.Job) *jobStore {
+	store := &jobStore{jobs: map[uint]entity.Job{}}
+	for _, job := range jobs {
+		store.jobs[job.ID] = job
+	}
+	return store
+}
+
+func (s *jobStore) Create(ctx context.Context, job *entity.Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job.ID = uint(len(s.jobs) + 1)
+	s.jobs[job.ID] = *job
+	return nil
+}
+
+func (s *jobStore) FindByID(ctx context.Context, id int) (*entity.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[uint(id)]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &job, nil
+}
+
+func (s *jobStore) FindByStatus(ctx context.Context, status string) ([]entity.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var jobs []entity.Job
+	for _, job := range s.jobs {
+		if job.Status == status {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs, nil
+}
+
+func (s *jobStore) ClaimNext(ctx context.Context) (*entity.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id := uint(1); id <= uint(len(s.jobs)); id++ {
+		job, ok := s.jobs[id]
+		if ok && job.Status == entity.JobQueued {
+			job.Status = entity.JobRunning
+			job.Attempts++
+			s.jobs[id] = job
+			return &job, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *jobStore) UpdateProgress(ctx context.Context, id uint, progress int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job := s.jobs[id]
+	job.Progress = progress
+	s.jobs[id] = job
+	return nil
+}
+
+func (s *jobStore) Save(ctx context.Context, job *entity.Job) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.jobs[job.ID] = *job
+	return nil
+}
+
+func waitJob(t *testing.T, jobs *Jobs, id uint) *entity.Job {
+	for i := 0; i < 200; i++ {
+		job, err := jobs.Find(context.Background(), int(id))
+		assert.NoError(t, err)
+		if job.Finished() {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("job %d did not finish", id)
+	return nil
+}
+
+func TestGivenAJobWithInput_WhenTheRunnerRunsIt_ThenShouldKeepItsResultAndSummary(t *testing.T) {
+	jobs := JobService(newJobStore(), t.TempDir(), 2)
+	jobs.Register("upper", func(ctx context.Context, run *JobRun) (interface{}, error) {
+		input, err := io.ReadAll(run.Input)
+		if err != nil {
+			return nil, err
+		}
+
+		run.Progress(1)
+		_, err = io.WriteString(run.Output, strings.ToUpper(string(input)))
+		return dto.ExportJobSummary{Rows: 1}, err
+	})
+	jobs.Register("fail", func(ctx context.Context, run *JobRun) (interface{}, error) {
+		return nil, errors.New("invalid upload")
+	})
+	assert.NoError(t, jobs.Start())
+	defer jobs.Shutdown(context.Background())
+
+	job, err := jobs.Enqueue(context.Background(), dto.JobRequest{Kind: "upper", ResultType: "text/plain"}, strings.NewReader("macbook pro"))
+	assert.NoError(t, err)
+	assert.Equal(t, entity.JobQueued, job.Status)
+
+	job = waitJob(t, jobs, job.ID)
+	assert.Equal(t, entity.JobSucceeded, job.Status)
+	assert.Equal(t, int64(1), job.Progress)
+	assert.JSONEq(t, `{"rows": 1}`, job.Summary)
+
+	result, err := jobs.Result(job)
+	assert.NoError(t, err)
+	defer result.Close()
+	content, _ := io.ReadAll(result)
+	assert.Equal(t, "MACBOOK PRO", string(content))
+
+	job, err = jobs.Enqueue(context.Background(), dto.JobRequest{Kind: "fail"}, nil)
+	assert.NoError(t, err)
+
+	job = waitJob(t, jobs, job.ID)
+	assert.Equal(t, entity.JobFailed, job.Status)
+	assert.Equal(t, "invalid upload", job.Error)
+
+	_, err = jobs.Result(job)
+	assert.ErrorIs(t, err, ErrJobNotFinished)
+}
+
+// cancelAtEOF cancels the request once its upload is read, like a client
+// leaving right after sending it.
+type cancelAtEOF struct {
+	io.Reader
+	cancel context.CancelFunc
+}
+
+func (r cancelAtEOF) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if err == io.EOF {
+		r.cancel()
+	}
+	return n, err
+}
+
+func TestGivenAClientGoneAfterTheUpload_WhenIEnqueueAJob_ThenShouldStillQueueIt(t *testing.T) {
+	jobs := JobService(newJobStore(), t.TempDir(), 1)
+	jobs.Register("echo", func(ctx context.Context, run *JobRun) (interface{}, error) {
+		_, err := io.Copy(run.Output, run.Input)
+		return nil, err
+	})
+	assert.NoError(t, jobs.Start())
+	defer jobs.Shutdown(context.Background())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job, err := jobs.Enqueue(ctx, dto.JobRequest{Kind: "echo"}, cancelAtEOF{Reader: strings.NewReader("kindle"), cancel: cancel})
+	assert.NoError(t, err)
+	assert.Equal(t, entity.JobQueued, job.Status)
+
+	job = waitJob(t, jobs, job.ID)
+	assert.Equal(t, entity.JobSucceeded, job.Status)
+}
+
+func TestGivenJobsLeftRunning_WhenTheRunnerStarts_ThenShouldResumeOrFailThem(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(dir+"/1.input", []byte("kindle"), 0o600))
+
+	store := newJobStore(
+		entity.Job{ID: 1, Kind: "echo", Status: entity.JobRunning, HasInput: true, Attempts: 1},
+		entity.Job{ID: 2, Kind: "echo", Status: entity.JobRunning, HasInput: true, Attempts: 1},
+		entity.Job{ID: 3, Kind: "echo", Status: entity.JobRunning, Attempts: MaxJobAttempts},
+	)
+	jobs := JobService(store, dir, 1)
+	jobs.Register("echo", func(ctx context.Context, run *JobRun) (interface{}, error) {
+		_, err := io.Copy(run.Output, run.Input)
+		return nil, err
+	})
+	assert.NoError(t, jobs.Start())
+	defer jobs.Shutdown(context.Background())
+
+	job := waitJob(t, jobs, 1)
+	assert.Equal(t, entity.JobSucceeded, job.Status)
+	assert.Equal(t, 2, job.Attempts)
+
+	job = waitJob(t, jobs, 2)
+	assert.Equal(t, "input file is missing", job.Error)
+
+	job = waitJob(t, jobs, 3)
+	assert.Equal(t, entity.JobFailed, job.Status)
+}
+
+func TestGivenARunningJob_WhenTheShutdownTimesOut_ThenShouldQueueItAgain(t *testing.T) {
+	started := make(chan struct{})
+	jobs := JobService(newJobStore(), t.TempDir(), 1)
+	jobs.Register("wait", func(ctx context.Context, run *JobRun) (interface{}, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	assert.NoError(t, jobs.Start())
+
+	job, err := jobs.Enqueue(context.Background(), dto.JobRequest{Kind: "wait"}, nil)
+	assert.NoError(t, err)
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, jobs.Shutdown(ctx), context.DeadlineExceeded)
+
+	job, err = jobs.Find(context.Background(), int(job.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, entity.JobQueued, job.Status)
+
+	_, err = jobs.Enqueue(context.Background(), dto.JobRequest{Kind: "wait"}, nil)
+	assert.ErrorIs(t, err, ErrJobRunnerStopped)
+}
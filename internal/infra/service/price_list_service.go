@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/waldrey/eulabs/internal/dto"
+	"github.com/waldrey/eulabs/internal/entity"
+	"github.com/waldrey/eulabs/internal/infra/database"
+	"github.com/waldrey/eulabs/pkg/money"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNoExchangeRate       = entity.Validation("no_exchange_rate", "no exchange rate")
+	ErrProductCurrencyPrice = entity.Validation("product_currency_price", "prices must be in currencies other than the product price")
+)
+
+type PriceList struct {
+	repository database.PriceListInterface
+	products   database.ProductInterface
+	unitOfWork database.UnitOfWorkInterface
+}
+
+func PriceListService(repository database.PriceListInterface, products database.ProductInterface, unitOfWork database.UnitOfWorkInterface) *PriceList {
+	return &PriceList{repository: repository, products: products, unitOfWork: unitOfWork}
+}
+
+func (p *PriceList) ProductPrices(ctx context.Context, productID int) ([]money.Money, error) {
+	prices, err := p.repository.FindProductPrices(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]money.Money, 0, len(prices))
+	for _, price := range prices {
+		result = append(result, price.Price)
+	}
+
+	return result, nil
+}
+
+// ReplaceProductPrices swaps the explicit prices of the product in a single
+// unit of work, the product is locked and its version bumped so its ETag
+// changes along with its prices.
+func (p *PriceList) ReplaceProductPrices(ctx context.Context, productID int, precondition dto.Precondition, request dto.ProductPricesRequest) (*entity.Product, []money.Money, error) {
+	var product *entity.Product
+	var result []money.Money
+	err := p.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		var err error
+		product, err = p.products.FindByIDForUpdate(ctx, productID)
+		if err != nil {
+			return err
+		}
+
+		if !precondition.Matches(product.Version) {
+			return entity.ErrVersionConflict
+		}
+
+		prices := make([]entity.ProductPrice, 0, len(request.Prices))
+		for i, price := range request.Prices {
+			if price.Currency == product.Price.Currency {
+				return ErrProductCurrencyPrice.WithFields(entity.FieldError{
+					Field:   fmt.Sprintf("prices[%d].currency", i),
+					Code:    "product_currency",
+					Message: "the price is in the currency of the product",
+				})
+			}
+			prices = append(prices, entity.ProductPrice{ProductID: uint(productID), Price: price})
+		}
+
+		err = p.repository.ReplaceProductPrices(ctx, productID, prices)
+		if err != nil {
+			return err
+		}
+
+		err = p.products.Update(ctx, product)
+		if err != nil {
+			return err
+		}
+
+		result, err = p.ProductPrices(ctx, productID)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return product, result, nil
+}
+
+func (p *PriceList) ExchangeRates(ctx context.Context) ([]dto.ExchangeRateResponse, error) {
+	rates, err := p.repository.FindExchangeRates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]dto.ExchangeRateResponse, 0, len(rates))
+	for _, rate := range rates {
+		result = append(result, exchangeRateResponse(rate))
+	}
+
+	return result, nil
+}
+
+// SaveExchangeRates stores the uploaded rates, a rate without rated_at is
+// taken as of now.
+func (p *PriceList) SaveExchangeRates(ctx context.Context, request dto.SaveExchangeRatesRequest) ([]dto.ExchangeRateResponse, error) {
+	now := time.Now()
+
+	rates := make([]entity.ExchangeRate, 0, len(request.Rates))
+	for _, rate := range request.Rates {
+		value, err := money.ParseRate(rate.Rate.String())
+		if err != nil {
+			return nil, err
+		}
+
+		ratedAt := rate.RatedAt
+		if ratedAt.IsZero() {
+			ratedAt = now
+		}
+
+		rates = append(rates, entity.ExchangeRate{
+			Base:    strings.ToUpper(rate.Base),
+			Quote:   strings.ToUpper(rate.Quote),
+			Rate:    money.FormatRate(value),
+			RatedAt: ratedAt,
+		})
+	}
+
+	err := p.repository.SaveExchangeRates(ctx, rates)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]dto.ExchangeRateResponse, 0, len(rates))
+	for _, rate := range rates {
+		result = append(result, exchangeRateResponse(rate))
+	}
+
+	return result, nil
+}
+
+func exchangeRateResponse(rate entity.ExchangeRate) dto.ExchangeRateResponse {
+	value, err := money.ParseRate(rate.Rate)
+	if err == nil {
+		rate.Rate = money.FormatRate(value)
+	}
+
+	return dto.ExchangeRateResponse{
+		Base:    rate.Base,
+		Quote:   rate.Quote,
+		Rate:    rate.Rate,
+		RatedAt: rate.RatedAt,
+	}
+}
+
+// Convert returns the products priced in the currency, an explicit price of
+// the product wins over converting its price by the exchange rate.
+func (p *PriceList) Convert(ctx context.Context, products []entity.Product, currency string) ([]dto.ConvertedProduct, error) {
+	currency = strings.ToUpper(currency)
+
+	var ids []uint
+	for _, product := range products {
+		if needsConversion(product, currency) {
+			ids = append(ids, product.ID)
+		}
+	}
+
+	prices, err := p.repository.FindProductPricesIn(ctx, ids, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	explicit := make(map[uint]money.Money, len(prices))
+	for _, price := range prices {
+		explicit[price.ProductID] = price.Price
+	}
+
+	rates := map[string]*exchangeRate{}
+	result := make([]dto.ConvertedProduct, 0, len(products))
+	for _, product := range products {
+		converted := dto.ConvertedProduct{Product: product}
+		if !needsConversion(product, currency) {
+			result = append(result, converted)
+			continue
+		}
+
+		if price, ok := explicit[product.ID]; ok {
+			converted.Price = price
+			converted.PriceConversion = &dto.PriceConversion{
+				Source:   dto.PriceSourceList,
+				Original: product.Price,
+			}
+			result = append(result, converted)
+			continue
+		}
+
+		rate, ok := rates[product.Price.Currency]
+		if !ok {
+			rate, err = p.exchangeRate(ctx, product.Price.Currency, currency)
+			if err != nil {
+				return nil, err
+			}
+			rates[product.Price.Currency] = rate
+		}
+
+		converted.Price = product.Price.Convert(currency, rate.value)
+		converted.PriceConversion = &dto.PriceConversion{
+			Source:   dto.PriceSourceExchangeRate,
+			Original: product.Price,
+			Rate:     money.FormatRate(rate.value),
+			RatedAt:  &rate.ratedAt,
+		}
+		result = append(result, converted)
+	}
+
+	return result, nil
+}
+
+// needsConversion is false for products already in the currency and for
+// sparse reads that did not select the price.
+func needsConversion(product entity.Product, currency string) bool {
+	return !product.Price.IsZero() && product.Price.Currency != currency
+}
+
+type exchangeRate struct {
+	value   *big.Rat
+	ratedAt time.Time
+}
+
+// exchangeRate looks up the rate from base to quote, falling back to the
+// inverse of the rate uploaded for quote to base.
+func (p *PriceList) exchangeRate(ctx context.Context, base string, quote string) (*exchangeRate, error) {
+	inverse := false
+
+	rate, err := p.repository.FindExchangeRate(ctx, base, quote)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		inverse = true
+		rate, err = p.repository.FindExchangeRate(ctx, quote, base)
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w from %s to %s", ErrNoExchangeRate, base, quote)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := money.ParseRate(rate.Rate)
+	if err != nil {
+		return nil, err
+	}
+
+	if inverse {
+		value.Inv(value)
+	}
+
+	return &exchangeRate{value: value, ratedAt: rate.RatedAt}, nil
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	testify "github.com/stretchr/testify/mock"
+	"github.com/waldrey/eulabs/internal/dto"
+	"github.com/waldrey/eulabs/internal/entity"
+	"github.com/waldrey/eulabs/pkg/money"
+	"github.com/waldrey/eulabs/test/mock"
+	"gorm.io/gorm"
+)
+
+func TestGivenExplicitPricesAndRates_WhenICallConvertPriceListService_ThenShouldPreferTheExplicitPrice(t *testing.T) {
+	ratedAt := time.Date(2024, 5, 2, 12, 0, 0, 0, time.UTC)
+	products := []entity.Product{
+		{Model: gorm.Model{ID: 1}, Name: "Macbook Pro", Price: money.New(2300000, "BRL")},
+		{Model: gorm.Model{ID: 2}, Name: "iPhone 15 Pro Max", Price: money.New(1000000, "BRL")},
+		{Model: gorm.Model{ID: 3}, Name: "Kindle", Price: money.New(9999, "USD")},
+	}
+
+	repository := &mock.PriceListRepositoryMock{}
+	repository.On("FindProductPricesIn", testify.Anything, []uint{1, 2}, "USD").Return([]entity.ProductPrice{
+		{ProductID: 1, Price: money.New(419900, "USD")},
+	}, nil)
+	repository.On("FindExchangeRate", testify.Anything, "BRL", "USD").Return(&entity.ExchangeRate{
+		Base: "BRL", Quote: "USD", Rate: "0.197100000000", RatedAt: ratedAt,
+	}, nil)
+	service := PriceListService(repository, &mock.ProductRepositoryMock{}, &mock.UnitOfWork{})
+
+	converted, err := service.Convert(context.Background(), products, "usd")
+	assert.NoError(t, err)
+	assert.Len(t, converted, 3)
+
+	assert.Equal(t, money.New(419900, "USD"), converted[0].Price)
+	assert.Equal(t, &dto.PriceConversion{
+		Source:   dto.PriceSourceList,
+		Original: money.New(2300000, "BRL"),
+	}, converted[0].PriceConversion)
+
+	assert.Equal(t, money.New(197100, "USD"), converted[1].Price)
+	assert.Equal(t, &dto.PriceConversion{
+		Source:   dto.PriceSourceExchangeRate,
+		Original: money.New(1000000, "BRL"),
+		Rate:     "0.1971",
+		RatedAt:  &ratedAt,
+	}, converted[1].PriceConversion)
+
+	assert.Equal(t, money.New(9999, "USD"), converted[2].Price)
+	assert.Nil(t, converted[2].PriceConversion)
+	repository.AssertExpectations(t)
+}
+
+func TestGivenOnlyTheInverseRate_WhenICallConvertPriceListService_ThenShouldInvertIt(t *testing.T) {
+	products := []entity.Product{{Model: gorm.Model{ID: 1}, Price: money.New(1000, "BRL")}}
+
+	repository := &mock.PriceListRepositoryMock{}
+	repository.On("FindProductPricesIn", testify.Anything, []uint{1}, "USD").Return([]entity.ProductPrice{}, nil)
+	repository.On("FindExchangeRate", testify.Anything, "BRL", "USD").Return(nil, gorm.ErrRecordNotFound)
+	repository.On("FindExchangeRate", testify.Anything, "USD", "BRL").Return(&entity.ExchangeRate{Base: "USD", Quote: "BRL", Rate: "5"}, nil)
+	service := PriceListService(repository, &mock.ProductRepositoryMock{}, &mock.UnitOfWork{})
+
+	converted, err := service.Convert(context.Background(), products, "USD")
+	assert.NoError(t, err)
+	assert.Equal(t, money.New(200, "USD"), converted[0].Price)
+	assert.Equal(t, "0.2", converted[0].PriceConversion.Rate)
+	repository.AssertExpectations(t)
+}
+
+func TestGivenNoRate_WhenICallConvertPriceListService_ThenShouldReceiveAnError(t *testing.T) {
+	products := []entity.Product{{Model: gorm.Model{ID: 1}, Price: money.New(1000, "BRL")}}
+
+	repository := &mock.PriceListRepositoryMock{}
+	repository.On("FindProductPricesIn", testify.Anything, []uint{1}, "EUR").Return([]entity.ProductPrice{}, nil)
+	repository.On("FindExchangeRate", testify.Anything, "BRL", "EUR").Return(nil, gorm.ErrRecordNotFound)
+	repository.On("FindExchangeRate", testify.Anything, "EUR", "BRL").Return(nil, gorm.ErrRecordNotFound)
+	service := PriceListService(repository, &mock.ProductRepositoryMock{}, &mock.UnitOfWork{})
+
+	_, err := service.Convert(context.Background(), products, "EUR")
+	assert.ErrorIs(t, err, ErrNoExchangeRate)
+	assert.EqualError(t, err, "no exchange rate from BRL to EUR")
+}
+
+func TestGivenRatesWithoutDate_WhenICallSaveExchangeRatesPriceListService_ThenShouldStoreThemAsOfNow(t *testing.T) {
+	repository := &mock.PriceListRepositoryMock{}
+	repository.On("SaveExchangeRates", testify.Anything, testify.AnythingOfType("[]entity.ExchangeRate")).Return(nil)
+	service := PriceListService(repository, &mock.ProductRepositoryMock{}, &mock.UnitOfWork{})
+
+	rates, err := service.SaveExchangeRates(context.Background(), dto.SaveExchangeRatesRequest{
+		Rates: []dto.ExchangeRateRequest{{Base: "usd", Quote: "brl", Rate: "5.07310"}},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, rates, 1)
+	assert.Equal(t, "USD", rates[0].Base)
+	assert.Equal(t, "BRL", rates[0].Quote)
+	assert.Equal(t, "5.0731", rates[0].Rate)
+	assert.False(t, rates[0].RatedAt.IsZero())
+	repository.AssertExpectations(t)
+}
+
+func TestGivenNewPrices_WhenICallReplaceProductPricesPriceListService_ThenShouldBumpTheProductVersionInOneUnitOfWork(t *testing.T) {
+	product := &entity.Product{Model: gorm.Model{ID: 1}, Price: money.New(2300000, "BRL"), Version: 2}
+	prices := []entity.ProductPrice{{ProductID: 1, Price: money.New(419900, "USD")}}
+
+	products := &mock.ProductRepositoryMock{}
+	products.On("FindByIDForUpdate", testify.Anything, 1).Return(product, nil)
+	products.On("Update", testify.Anything, product).Return(nil)
+	repository := &mock.PriceListRepositoryMock{}
+	repository.On("ReplaceProductPrices", testify.Anything, 1, prices).Return(nil)
+	repository.On("FindProductPrices", testify.Anything, 1).Return(prices, nil)
+	unitOfWork := &mock.UnitOfWork{}
+	service := PriceListService(repository, products, unitOfWork)
+
+	updated, result, err := service.ReplaceProductPrices(context.Background(), 1, dto.Precondition{Versions: []uint{2}}, dto.ProductPricesRequest{
+		Prices: []money.Money{money.New(419900, "USD")},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, product, updated)
+	assert.Equal(t, []money.Money{money.New(419900, "USD")}, result)
+	assert.Equal(t, 1, unitOfWork.Committed)
+	products.AssertExpectations(t)
+	repository.AssertExpectations(t)
+}
+
+func TestGivenAStaleVersion_WhenICallReplaceProductPricesPriceListService_ThenShouldReceiveAVersionConflict(t *testing.T) {
+	products := &mock.ProductRepositoryMock{}
+	products.On("FindByIDForUpdate", testify.Anything, 1).Return(&entity.Product{Price: money.New(2300000, "BRL"), Version: 3}, nil)
+	repository := &mock.PriceListRepositoryMock{}
+	unitOfWork := &mock.UnitOfWork{}
+	service := PriceListService(repository, products, unitOfWork)
+
+	_, _, err := service.ReplaceProductPrices(context.Background(), 1, dto.Precondition{Versions: []uint{2}}, dto.ProductPricesRequest{
+		Prices: []money.Money{money.New(419900, "USD")},
+	})
+	assert.ErrorIs(t, err, entity.ErrVersionConflict)
+	assert.Equal(t, 1, unitOfWork.RolledBack)
+	repository.AssertNotCalled(t, "ReplaceProductPrices", testify.Anything, testify.Anything, testify.Anything)
+	products.AssertNotCalled(t, "Update", testify.Anything, testify.Anything)
+}
+
+func TestGivenAPriceInTheProductCurrency_WhenICallReplaceProductPricesPriceListService_ThenShouldReceiveAValidationError(t *testing.T) {
+	products := &mock.ProductRepositoryMock{}
+	products.On("FindByIDForUpdate", testify.Anything, 1).Return(&entity.Product{Price: money.New(2300000, "BRL"), Version: 1}, nil)
+	repository := &mock.PriceListRepositoryMock{}
+	service := PriceListService(repository, products, &mock.UnitOfWork{})
+
+	_, _, err := service.ReplaceProductPrices(context.Background(), 1, dto.AnyVersion, dto.ProductPricesRequest{
+		Prices: []money.Money{money.New(419900, "USD"), money.New(2300000, "BRL")},
+	})
+	assert.ErrorIs(t, err, ErrProductCurrencyPrice)
+	repository.AssertNotCalled(t, "ReplaceProductPrices", testify.Anything, testify.Anything, testify.Anything)
+}
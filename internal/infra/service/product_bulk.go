@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/waldrey/eulabs/internal/dto"
+	"github.com/waldrey/eulabs/internal/entity"
+	"github.com/waldrey/eulabs/internal/infra/database"
+)
+
+var (
+	// ErrBulkNotApplied marks the operations of an atomic bulk request left
+	// out because another one failed.
+	ErrBulkNotApplied = entity.Conflict("bulk_not_applied", "not applied, another operation failed")
+
+	ErrBulkDuplicateProduct = entity.Conflict("bulk_duplicate_product", "product already changed by another operation")
+)
+
+// Bulk runs the create, update and delete operations, already validated by the
+// handler, returning a result for each one in the same order. An atomic bulk
+// writes nothing unless every operation succeeds, otherwise the failed
+// operations are reported and the others written. The products are read and
+// written in a single unit of work.
+func (p *Product) Bulk(ctx context.Context, operations []dto.BulkProductOperation, atomic bool) ([]dto.BulkProductResult, error) {
+	var results []dto.BulkProductResult
+	err := p.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		var err error
+		results, err = p.bulk(ctx, operations, atomic)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (p *Product) bulk(ctx context.Context, operations []dto.BulkProductOperation, atomic bool) ([]dto.BulkProductResult, error) {
+	var ids []uint
+	for _, operation := range operations {
+		if operation.Op != dto.BulkCreate {
+			ids = append(ids, uint(operation.ID))
+		}
+	}
+
+	found, err := p.repository.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	products := make(map[uint]*entity.Product, len(found))
+	for i := range found {
+		products[found[i].ID] = &found[i]
+	}
+
+	results := make([]dto.BulkProductResult, len(operations))
+	seen := map[uint]bool{}
+	for i, operation := range operations {
+		results[i] = dto.BulkProductResult{Index: operation.Index, Op: operation.Op}
+		results[i].Product, results[i].Err = bulkProduct(operation, products, seen)
+	}
+
+	for {
+		if atomic && skipFailed(results) {
+			return results, nil
+		}
+
+		writes := bulkWrites(results)
+		if writes.Len() == 0 {
+			return results, nil
+		}
+
+		err = p.repository.BulkWrite(ctx, writes)
+
+		var conflict *database.BulkConflictError
+		if !errors.As(err, &conflict) || !markConflicts(results, conflict.IDs) {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i, result := range results {
+		switch {
+		case result.Err != nil:
+		case result.Op == dto.BulkDelete:
+			p.suggestions.Remove(result.Product.ID)
+			results[i].Product = nil
+		default:
+			p.suggestions.Add(result.Product.ID, result.Product.Name)
+		}
+	}
+
+	return results, nil
+}
+
+// bulkProduct builds the product written by the operation, checking the
+// version of the updated and deleted products.
+func bulkProduct(operation dto.BulkProductOperation, products map[uint]*entity.Product, seen map[uint]bool) (*entity.Product, error) {
+	if operation.Op == dto.BulkCreate {
+		return &entity.Product{
+			Name:        operation.Create.Name,
+			Description: operation.Create.Description,
+			Price:       operation.Create.Price,
+		}, nil
+	}
+
+	id := uint(operation.ID)
+	product, ok := products[id]
+	if !ok {
+		return nil, entity.ErrProductNotFound
+	}
+
+	if seen[id] {
+		return nil, ErrBulkDuplicateProduct
+	}
+	seen[id] = true
+
+	if operation.Version != 0 && operation.Version != product.Version {
+		return nil, entity.ErrVersionConflict
+	}
+
+	if operation.Op == dto.BulkUpdate {
+		err := applyFields(product, operation.Update)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return product, nil
+}
+
+// markConflicts fails the operations over the conflicting products, so the
+// others can be written again, telling whether any was marked.
+func markConflicts(results []dto.BulkProductResult, ids []uint) bool {
+	marked := false
+	for i := range results {
+		if results[i].Err != nil || results[i].Op == dto.BulkCreate || !slices.Contains(ids, results[i].Product.ID) {
+			continue
+		}
+
+		results[i].Product = nil
+		results[i].Err = entity.ErrVersionConflict
+		marked = true
+	}
+
+	return marked
+}
+
+// skipFailed marks every successful result as not applied when any result
+// failed, telling whether it did.
+func skipFailed(results []dto.BulkProductResult) bool {
+	failed := false
+	for _, result := range results {
+		if result.Err != nil {
+			failed = true
+			break
+		}
+	}
+
+	if !failed {
+		return false
+	}
+
+	for i := range results {
+		if results[i].Err == nil {
+			results[i].Product = nil
+			results[i].Err = ErrBulkNotApplied
+		}
+	}
+
+	return true
+}
+
+func bulkWrites(results []dto.BulkProductResult) database.BulkWrites {
+	var writes database.BulkWrites
+	for _, result := range results {
+		if result.Err != nil {
+			continue
+		}
+
+		switch result.Op {
+		case dto.BulkCreate:
+			writes.Create = append(writes.Create, result.Product)
+		case dto.BulkUpdate:
+			writes.Update = append(writes.Update, result.Product)
+		case dto.BulkDelete:
+			writes.Delete = append(writes.Delete, result.Product)
+		}
+	}
+
+	return writes
+}
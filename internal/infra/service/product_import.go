@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/waldrey/eulabs/internal/dto"
+	"github.com/waldrey/eulabs/internal/entity"
+	"github.com/waldrey/eulabs/internal/infra/database"
+)
+
+// importRetries is how many times a batch is read and written again after a
+// concurrent write to its products.
+const importRetries = 3
+
+// Import upserts the rows of the source by product name, compared
+// case-insensitively, reading and writing them database.BulkBatchSize rows at
+// a time so the file is never held in memory. Each batch is read and written
+// in a unit of work of its own, the rows with errors are reported and skipped. A dry run reads
+// the products to report what would change but writes nothing.
+func (p *Product) Import(ctx context.Context, source dto.ImportSource, dryRun bool) (*dto.ImportResult, error) {
+	result := &dto.ImportResult{DryRun: dryRun, Errors: []dto.ImportRowError{}}
+	batch := make([]dto.ImportRow, 0, database.BulkBatchSize)
+
+	for {
+		row, err := source.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return result, err
+		}
+
+		result.Rows++
+		if len(row.Errors) > 0 {
+			result.Fail(row.Errors)
+			continue
+		}
+
+		batch = append(batch, row)
+		if len(batch) == cap(batch) {
+			err = p.importBatch(ctx, batch, dryRun, result)
+			if err != nil {
+				return result, err
+			}
+			batch = batch[:0]
+		}
+	}
+
+	if len(batch) == 0 {
+		return result, nil
+	}
+
+	return result, p.importBatch(ctx, batch, dryRun, result)
+}
+
+func (p *Product) importBatch(ctx context.Context, rows []dto.ImportRow, dryRun bool, result *dto.ImportResult) error {
+	for attempt := 1; ; attempt++ {
+		var writes database.BulkWrites
+		var counts dto.ImportResult
+		err := p.unitOfWork.Do(ctx, func(ctx context.Context) error {
+			var err error
+			writes, counts, err = p.importWrites(ctx, rows)
+			if err != nil || dryRun || writes.Len() == 0 {
+				return err
+			}
+
+			return p.repository.BulkWrite(ctx, writes)
+		})
+		if errors.Is(err, entity.ErrVersionConflict) && attempt < importRetries {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if !dryRun {
+			for _, product := range slices.Concat(writes.Create, writes.Update) {
+				p.suggestions.Add(product.ID, product.Name)
+			}
+		}
+
+		result.Created += counts.Created
+		result.Updated += counts.Updated
+		result.Unchanged += counts.Unchanged
+		return nil
+	}
+}
+
+// importWrites matches the rows with the stored products, a name repeated in
+// the batch updates the product of its first row.
+func (p *Product) importWrites(ctx context.Context, rows []dto.ImportRow) (database.BulkWrites, dto.ImportResult, error) {
+	var writes database.BulkWrites
+	var counts dto.ImportResult
+
+	names := make([]string, 0, len(rows))
+	for _, row := range rows {
+		names = append(names, row.Product.Name)
+	}
+
+	found, err := p.repository.FindByNames(ctx, names)
+	if err != nil {
+		return writes, counts, err
+	}
+
+	products := make(map[string]*entity.Product, len(found))
+	for i := range found {
+		key := strings.ToLower(found[i].Name)
+		if _, ok := products[key]; !ok {
+			products[key] = &found[i]
+		}
+	}
+
+	written := map[*entity.Product]bool{}
+	for _, row := range rows {
+		key := strings.ToLower(row.Product.Name)
+		product, ok := products[key]
+		if !ok {
+			product = &entity.Product{}
+			products[key] = product
+			written[product] = true
+			writes.Create = append(writes.Create, product)
+			counts.Created++
+		} else if product.Name == row.Product.Name && product.Description == row.Product.Description && product.Price == row.Product.Price {
+			counts.Unchanged++
+			continue
+		} else {
+			if !written[product] {
+				written[product] = true
+				writes.Update = append(writes.Update, product)
+			}
+			counts.Updated++
+		}
+
+		product.Name = row.Product.Name
+		product.Description = row.Product.Description
+		product.Price = row.Product.Price
+	}
+
+	return writes, counts, nil
+}
@@ -1,78 +1,531 @@
 package service
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"math"
 
 	"github.com/waldrey/eulabs/internal/dto"
 	"github.com/waldrey/eulabs/internal/entity"
 	"github.com/waldrey/eulabs/internal/infra/database"
+	"github.com/waldrey/eulabs/pkg/cursor"
+	"github.com/waldrey/eulabs/pkg/jsonpatch"
+	"github.com/waldrey/eulabs/pkg/money"
+	"github.com/waldrey/eulabs/pkg/search"
+	"github.com/waldrey/eulabs/pkg/suggest"
+)
+
+var (
+	// ErrInvalidProductDocument is returned when a patched document no longer
+	// decodes as a product.
+	ErrInvalidProductDocument = entity.Validation("invalid_product_document", "invalid product document")
+
+	// the jsonpatch errors are returned wrapped in these ones
+	ErrInvalidPatch       = entity.Invalid("invalid_patch", "cannot read the patch")
+	ErrPatchTestFailed    = entity.Conflict("patch_test_failed", "the patch does not apply to this product")
+	ErrPatchNotApplicable = entity.Validation("patch_not_applicable", "the patch cannot be applied")
 )
 
 type Product struct {
-	repository database.ProductInterface
+	repository  database.ProductInterface
+	unitOfWork  database.UnitOfWorkInterface
+	suggestions *suggest.Index
 }
 
-func ProductService(repository database.ProductInterface) *Product {
-	return &Product{repository: repository}
+// ProductService returns the product service, every write of a product runs
+// in a unit of work of its own.
+func ProductService(repository database.ProductInterface, unitOfWork database.UnitOfWorkInterface) *Product {
+	return &Product{
+		repository:  repository,
+		unitOfWork:  unitOfWork,
+		suggestions: suggest.NewIndex(suggest.DefaultMaxEntries),
+	}
 }
 
-func (p *Product) Create(product dto.CreateProductRequest) (*entity.Product, error) {
+func (p *Product) Create(ctx context.Context, product dto.CreateProductRequest) (*entity.Product, error) {
 	productEntity := &entity.Product{
 		Name:        product.Name,
 		Description: product.Description,
 		Price:       product.Price,
 	}
 
-	return p.repository.Create(productEntity)
+	var createdProduct *entity.Product
+	err := p.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		var err error
+		createdProduct, err = p.repository.Create(ctx, productEntity)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	p.suggestions.Add(createdProduct.ID, createdProduct.Name)
+
+	return createdProduct, nil
 }
 
-func (p *Product) FindAll() ([]entity.Product, error) {
-	return p.repository.FindAll()
+func (p *Product) FindAll(ctx context.Context) ([]entity.Product, error) {
+	return p.repository.FindAll(ctx)
 }
 
-func (p *Product) FindOne(id int) (*entity.Product, error) {
-	return p.repository.FindByID(id)
+func (p *Product) List(ctx context.Context, query dto.ListProductsQuery) ([]entity.Product, int64, error) {
+	query.Normalize()
+
+	options, err := listOptions(query)
+	if err != nil {
+		return nil, 0, err
+	}
+	options.Offset = query.Offset()
+	options.Limit = query.PageSize
+
+	return p.repository.List(ctx, options)
 }
 
-func (p *Product) Delete(id int) error {
-	product, err := p.repository.FindByID(id)
+// ListAfter walks the products after the given position, the returned position
+// is nil when there are no more products to read.
+func (p *Product) ListAfter(ctx context.Context, query dto.ListProductsQuery, after *cursor.Position) ([]entity.Product, *cursor.Position, error) {
+	query.Normalize()
+	pageSize := query.PageSize
+
+	options, err := listOptions(query)
 	if err != nil {
-		return err
+		return nil, nil, err
+	}
+	options.Limit = pageSize + 1
+	options.After = after
+
+	products, err := p.repository.ListAfter(ctx, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(products) <= pageSize {
+		return products, nil, nil
 	}
 
-	log.Print("record found to deletion")
-	return p.repository.Delete(product)
+	products = products[:pageSize]
+	last := products[len(products)-1]
+
+	return products, &cursor.Position{ID: last.ID, CreatedAt: last.CreatedAt}, nil
+}
+
+// Export calls each for every product matching the filters of the query, in
+// the requested order, without holding them in memory.
+func (p *Product) Export(ctx context.Context, query dto.ExportProductsQuery, each func(product entity.Product) error) error {
+	return p.repository.Export(ctx, database.ListOptions{
+		Sort:   sortOptions(query.Sort),
+		Filter: productFilter(query.ProductFilterQuery, money.DefaultCurrency),
+	}, each)
 }
 
-func (p *Product) Update(id int, productFields dto.PutProductRequest) (*entity.Product, error) {
-	product, err := p.repository.FindByID(id)
+func listOptions(query dto.ListProductsQuery) (database.ListOptions, error) {
+	fields, err := query.ParseFields()
 	if err != nil {
-		return nil, err
+		return database.ListOptions{}, err
 	}
 
-	if productFields.Name != "" {
-		product.Name = productFields.Name
+	return database.ListOptions{
+		Sort:   sortOptions(query.Sort),
+		Filter: productFilter(query.ProductFilterQuery, money.DefaultCurrency),
+		Fields: fields,
+	}, nil
+}
+
+func sortOptions(sort string) []database.Sort {
+	var sorts []database.Sort
+	for _, field := range dto.ParseSort(sort) {
+		sorts = append(sorts, database.Sort{Field: field.Field, Desc: field.Desc})
 	}
 
-	if productFields.Description != "" {
-		product.Description = productFields.Description
+	return sorts
+}
+
+// productFilter reads the price bounds in currency, they restrict the products
+// to the ones priced in it.
+func productFilter(query dto.ProductFilterQuery, currency string) database.ProductFilter {
+	createdAfter, createdBefore := query.CreatedRange()
+
+	filter := database.ProductFilter{
+		MinPrice:      money.FromMajor(query.MinPrice, currency).Amount,
+		MaxPrice:      money.FromMajor(query.MaxPrice, currency).Amount,
+		NameContains:  query.NameContains,
+		CreatedAfter:  createdAfter,
+		CreatedBefore: createdBefore,
+		Expression:    query.Expression,
 	}
 
-	if productFields.Price >= 0.0 {
-		product.Price = productFields.Price
+	if filter.MinPrice > 0 || filter.MaxPrice > 0 {
+		filter.Currency = currency
+	}
+
+	return filter
+}
+
+func (p *Product) FindOne(ctx context.Context, id int) (*entity.Product, error) {
+	return p.repository.FindByID(ctx, id)
+}
+
+func (p *Product) FindOneFields(ctx context.Context, id int, fields []string) (*entity.Product, error) {
+	if len(fields) == 0 {
+		return p.repository.FindByID(ctx, id)
+	}
+
+	return p.repository.FindByIDFields(ctx, id, fields)
+}
+
+// CatalogValidators derives the validators of the product listings from the
+// number of products and the last write, any create, update or delete
+// changes them whatever page or filter is read.
+func (p *Product) CatalogValidators(ctx context.Context) (dto.Validators, error) {
+	state, err := p.repository.CatalogState(ctx)
+	if err != nil {
+		return dto.Validators{}, err
+	}
+
+	return dto.Validators{
+		ETag:         fmt.Sprintf(`W/"%d-%d"`, state.Count, state.LastModified.UnixNano()),
+		LastModified: state.LastModified,
+	}, nil
+}
+
+func (p *Product) Delete(ctx context.Context, id int, precondition dto.Precondition) error {
+	var product *entity.Product
+	err := p.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		var err error
+		product, err = p.findForWrite(ctx, id, precondition)
+		if err != nil {
+			return err
+		}
+
+		log.Print("record found to deletion")
+		return p.repository.Delete(ctx, product)
+	})
+	if err != nil {
+		return err
+	}
+	p.suggestions.Remove(product.ID)
+
+	return nil
+}
+
+func (p *Product) Update(ctx context.Context, id int, precondition dto.Precondition, productFields dto.PutProductRequest) (*entity.Product, error) {
+	return p.write(ctx, id, precondition, func(product *entity.Product) error {
+		if productFields.Name != "" {
+			product.Name = productFields.Name
+		}
+
+		if productFields.Description != "" {
+			product.Description = productFields.Description
+		}
+
+		if productFields.Price.Amount >= 0 {
+			product.Price = productFields.Price
+		}
+
+		return nil
+	})
+}
+
+// Patch applies only the fields sent in the request, a field sent as null is
+// cleared, then validates the resulting product before saving it.
+func (p *Product) Patch(ctx context.Context, id int, precondition dto.Precondition, productFields dto.UpdateProductRequest) (*entity.Product, error) {
+	return p.write(ctx, id, precondition, func(product *entity.Product) error {
+		return applyFields(product, productFields)
+	})
+}
+
+// applyFields sets the fields sent in a partial update and validates the
+// resulting product.
+func applyFields(product *entity.Product, productFields dto.UpdateProductRequest) error {
+	if productFields.Name.Set {
+		product.Name = productFields.Name.Value
+	}
+
+	if productFields.Description.Set {
+		product.Description = productFields.Description.Value
+	}
+
+	if productFields.Price.Set {
+		product.Price = productFields.Price.Value
+	}
+
+	return product.IsValid()
+}
+
+// MergePatch applies a RFC 7396 JSON Merge Patch to the product document.
+func (p *Product) MergePatch(ctx context.Context, id int, precondition dto.Precondition, patch []byte) (*entity.Product, error) {
+	return p.patchDocument(ctx, id, precondition, func(document []byte) ([]byte, error) {
+		return jsonpatch.MergePatch(document, patch)
+	})
+}
+
+// JSONPatch applies a RFC 6902 JSON Patch to the product document.
+func (p *Product) JSONPatch(ctx context.Context, id int, precondition dto.Precondition, patch []byte) (*entity.Product, error) {
+	return p.patchDocument(ctx, id, precondition, func(document []byte) ([]byte, error) {
+		return jsonpatch.Apply(document, patch)
+	})
+}
+
+// patchDocument patches the dto.ProductDocument of the product and validates
+// the result as a whole before saving it.
+func (p *Product) patchDocument(ctx context.Context, id int, precondition dto.Precondition, patch func(document []byte) ([]byte, error)) (*entity.Product, error) {
+	return p.write(ctx, id, precondition, func(product *entity.Product) error {
+		document, err := json.Marshal(dto.ProductDocument{
+			Name:        product.Name,
+			Description: product.Description,
+			Price:       product.Price,
+		})
+		if err != nil {
+			return err
+		}
+
+		document, err = patch(document)
+		if err != nil {
+			return patchError(err)
+		}
+
+		// members other than the editable fields are refused, not ignored
+		decoder := json.NewDecoder(bytes.NewReader(document))
+		decoder.DisallowUnknownFields()
+
+		var patched dto.ProductDocument
+		if err := decoder.Decode(&patched); err != nil {
+			return ErrInvalidProductDocument.Wrap(err)
+		}
+
+		product.Name = patched.Name
+		product.Description = patched.Description
+		product.Price = patched.Price
+
+		return product.IsValid()
+	})
+}
+
+func patchError(err error) error {
+	switch {
+	case errors.Is(err, jsonpatch.ErrInvalidPatch):
+		return ErrInvalidPatch.Wrap(err)
+	case errors.Is(err, jsonpatch.ErrTestFailed):
+		return ErrPatchTestFailed.Wrap(err)
+	case errors.Is(err, jsonpatch.ErrPathNotFound), errors.Is(err, jsonpatch.ErrUnsupportedOperation):
+		return ErrPatchNotApplicable.Wrap(err)
 	}
 
-	log.Print("record found to update")
-	err = p.repository.Update(product)
+	return err
+}
+
+// findForWrite reads and locks the product to change, failing with
+// entity.ErrVersionConflict when it is not at a version of the precondition.
+// It is called inside a unit of work, which holds the lock until it ends.
+func (p *Product) findForWrite(ctx context.Context, id int, precondition dto.Precondition) (*entity.Product, error) {
+	product, err := p.repository.FindByIDForUpdate(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	product, err = p.repository.FindByID(id)
+	if !precondition.Matches(product.Version) {
+		return nil, entity.ErrVersionConflict
+	}
+
+	return product, nil
+}
+
+// write changes the product and saves it in a single unit of work, returning
+// it as stored. Nothing is saved when change fails.
+func (p *Product) write(ctx context.Context, id int, precondition dto.Precondition, change func(product *entity.Product) error) (*entity.Product, error) {
+	var product *entity.Product
+	err := p.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		var err error
+		product, err = p.findForWrite(ctx, id, precondition)
+		if err != nil {
+			return err
+		}
+
+		err = change(product)
+		if err != nil {
+			return err
+		}
+
+		log.Print("record found to update")
+		err = p.repository.Update(ctx, product)
+		if err != nil {
+			return err
+		}
+
+		product, err = p.repository.FindByID(ctx, id)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
+	p.suggestions.Add(product.ID, product.Name)
+
 	log.Print("product updated with success")
 	return product, nil
 }
+
+func (p *Product) Search(ctx context.Context, query dto.SearchProductsQuery) ([]dto.ProductSearchResult, error) {
+	if query.Limit <= 0 {
+		query.Limit = dto.DefaultSearchLimit
+	}
+
+	results, err := p.repository.Search(ctx, query.Q, query.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	searchResults := make([]dto.ProductSearchResult, 0, len(results))
+	for _, result := range results {
+		highlights := map[string]string{}
+		if snippet := search.Highlight(result.Product.Name, query.Q, dto.SnippetSize); snippet != "" {
+			highlights["name"] = snippet
+		}
+		if snippet := search.Highlight(result.Product.Description, query.Q, dto.SnippetSize); snippet != "" {
+			highlights["description"] = snippet
+		}
+
+		searchResults = append(searchResults, dto.ProductSearchResult{
+			Product:    result.Product,
+			Score:      result.Score,
+			Highlights: highlights,
+		})
+	}
+
+	return searchResults, nil
+}
+
+// LoadSuggestions builds the suggestions index from the products in database,
+// afterwards it is kept current by Create, Update and Delete.
+func (p *Product) LoadSuggestions(ctx context.Context) error {
+	products, err := p.repository.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, product := range products {
+		if !p.suggestions.Add(product.ID, product.Name) {
+			log.Printf("suggestions index is full, indexed %d products", p.suggestions.Len())
+			break
+		}
+	}
+
+	return nil
+}
+
+func (p *Product) Suggest(query dto.SuggestProductsQuery) []dto.ProductSuggestion {
+	if query.Limit <= 0 {
+		query.Limit = dto.DefaultSuggestLimit
+	}
+
+	suggestions := p.suggestions.Suggest(query.Prefix, query.Limit)
+
+	productSuggestions := make([]dto.ProductSuggestion, 0, len(suggestions))
+	for _, suggestion := range suggestions {
+		productSuggestions = append(productSuggestions, dto.ProductSuggestion{
+			Id:   suggestion.ID,
+			Name: suggestion.Name,
+		})
+	}
+
+	return productSuggestions
+}
+
+func (p *Product) Stats(ctx context.Context, query dto.ProductStatsQuery) (*dto.ProductStatsResponse, error) {
+	currency := query.Currency
+	if currency == "" {
+		currency = money.DefaultCurrency
+	}
+
+	filter := productFilter(query.ProductFilterQuery, currency)
+	filter.Currency = currency
+
+	stats, err := p.repository.PriceStats(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &dto.ProductStatsResponse{
+		Count:     stats.Count,
+		MinPrice:  money.New(stats.MinPrice, currency),
+		MaxPrice:  money.New(stats.MaxPrice, currency),
+		AvgPrice:  money.New(int64(math.Round(stats.AvgPrice)), currency),
+		Histogram: []dto.PriceBucketResponse{},
+	}
+
+	if stats.Count == 0 {
+		return response, nil
+	}
+
+	limit := 1
+	if stats.Count%2 == 0 {
+		limit = 2
+	}
+
+	prices, err := p.repository.PricesAt(ctx, filter, int((stats.Count-1)/2), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var sum int64
+	for _, price := range prices {
+		sum += price
+	}
+	response.MedianPrice = money.New(int64(math.Round(float64(sum)/float64(len(prices)))), currency)
+
+	// every product has the same price, there is nothing to spread in buckets
+	if stats.MinPrice == stats.MaxPrice {
+		response.Histogram = []dto.PriceBucketResponse{
+			{From: response.MinPrice, To: response.MaxPrice, Count: stats.Count},
+		}
+		return response, nil
+	}
+
+	buckets, width := histogramBuckets(query, stats, currency)
+	counts, err := p.repository.PriceHistogram(ctx, filter, stats.MinPrice, width)
+	if err != nil {
+		return nil, err
+	}
+
+	response.Histogram = make([]dto.PriceBucketResponse, buckets)
+	for i := range response.Histogram {
+		response.Histogram[i].From = money.New(stats.MinPrice+int64(i)*width, currency)
+		response.Histogram[i].To = money.New(stats.MinPrice+int64(i+1)*width, currency)
+	}
+
+	// buckets are capped, so the highest prices may land past the last one
+	for bucket, count := range counts {
+		response.Histogram[max(0, min(bucket, buckets-1))].Count += count
+	}
+
+	return response, nil
+}
+
+// histogramBuckets returns the number of buckets and their width in minor
+// units, the bucket size of the query is in the major unit of currency. The
+// buckets are capped to dto.MaxStatsBuckets.
+func histogramBuckets(query dto.ProductStatsQuery, stats database.PriceStats, currency string) (int, int64) {
+	spread := stats.MaxPrice - stats.MinPrice
+
+	if query.BucketSize > 0 {
+		width := max(money.FromMajor(query.BucketSize, currency).Amount, 1)
+		buckets := spread/width + 1
+		if buckets <= dto.MaxStatsBuckets {
+			return int(buckets), width
+		}
+		return dto.MaxStatsBuckets, spread/dto.MaxStatsBuckets + 1
+	}
+
+	buckets := min(query.Buckets, dto.MaxStatsBuckets)
+	if buckets <= 0 {
+		buckets = dto.DefaultStatsBuckets
+	}
+
+	// rounding the width up keeps the max price inside the last bucket
+	width := max((spread+int64(buckets)-1)/int64(buckets), 1)
+
+	return buckets, width
+}
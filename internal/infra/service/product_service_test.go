@@ -1,40 +1,50 @@
 package service
 
 import (
+	"context"
+	"io"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	testify "github.com/stretchr/testify/mock"
 	"github.com/waldrey/eulabs/internal/dto"
 	"github.com/waldrey/eulabs/internal/entity"
+	"github.com/waldrey/eulabs/internal/infra/database"
+	"github.com/waldrey/eulabs/pkg/cursor"
+	"github.com/waldrey/eulabs/pkg/jsonpatch"
+	"github.com/waldrey/eulabs/pkg/money"
+	"github.com/waldrey/eulabs/pkg/optional"
 	"github.com/waldrey/eulabs/test/mock"
+	"gorm.io/gorm"
 )
 
 func TestGivenAValidParams_WhenICallProductCreateService_ThenShouldReceiveProductWithAllParams(t *testing.T) {
 	productRequest := dto.CreateProductRequest{
 		Name:        "Macbook Pro",
 		Description: "O poderoso computador da Apple",
-		Price:       23000.00,
+		Price:       money.New(2300000, "BRL"),
 	}
 
 	productEntityService := &entity.Product{
 		Name:        "Macbook Pro",
 		Description: "O poderoso computador da Apple",
-		Price:       23000.00,
+		Price:       money.New(2300000, "BRL"),
 	}
 
 	repository := &mock.ProductRepositoryMock{}
-	repository.On("Create", productEntityService).Return(&entity.Product{
+	repository.On("Create", testify.Anything, productEntityService).Return(&entity.Product{
 		Name:        "Macbook Pro",
 		Description: "O poderoso computador da Apple",
-		Price:       23000.00,
+		Price:       money.New(2300000, "BRL"),
 	}, nil)
-	service := ProductService(repository)
+	service := ProductService(repository, &mock.UnitOfWork{})
 
-	product, err := service.Create(productRequest)
+	product, err := service.Create(context.Background(), productRequest)
 	assert.NoError(t, err)
 	assert.Equal(t, "Macbook Pro", product.Name)
 	assert.Equal(t, "O poderoso computador da Apple", product.Description)
-	assert.Equal(t, 23000.00, product.Price)
+	assert.Equal(t, money.New(2300000, "BRL"), product.Price)
 
 	repository.AssertExpectations(t)
 }
@@ -43,40 +53,40 @@ func TestGivenAValidParams_WhenICallProductDeleteService_ThenShouldReceiveStatus
 	productRequest := dto.CreateProductRequest{
 		Name:        "Macbook Pro",
 		Description: "O poderoso computador da Apple",
-		Price:       23000.00,
+		Price:       money.New(2300000, "BRL"),
 	}
 
 	productEntityService := &entity.Product{
 		Name:        "Macbook Pro",
 		Description: "O poderoso computador da Apple",
-		Price:       23000.00,
+		Price:       money.New(2300000, "BRL"),
 	}
 
 	repository := &mock.ProductRepositoryMock{}
-	repository.On("Create", productEntityService).Return(&entity.Product{
+	repository.On("Create", testify.Anything, productEntityService).Return(&entity.Product{
 		Name:        "Macbook Pro",
 		Description: "O poderoso computador da Apple",
-		Price:       23000.00,
+		Price:       money.New(2300000, "BRL"),
 	}, nil)
-	repository.On("FindByID", 1).Return(&entity.Product{
+	repository.On("FindByIDForUpdate", testify.Anything, 1).Return(&entity.Product{
 		Name:        "Macbook Pro",
 		Description: "O poderoso computador da Apple",
-		Price:       23000.00,
+		Price:       money.New(2300000, "BRL"),
 	}, nil)
-	repository.On("Delete", &entity.Product{
+	repository.On("Delete", testify.Anything, &entity.Product{
 		Name:        "Macbook Pro",
 		Description: "O poderoso computador da Apple",
-		Price:       23000.00,
+		Price:       money.New(2300000, "BRL"),
 	}).Return(nil)
-	service := ProductService(repository)
+	service := ProductService(repository, &mock.UnitOfWork{})
 
-	product, err := service.Create(productRequest)
+	product, err := service.Create(context.Background(), productRequest)
 	assert.NoError(t, err)
 	assert.Equal(t, "Macbook Pro", product.Name)
 	assert.Equal(t, "O poderoso computador da Apple", product.Description)
-	assert.Equal(t, 23000.00, product.Price)
+	assert.Equal(t, money.New(2300000, "BRL"), product.Price)
 
-	err = service.Delete(int(product.ID))
+	err = service.Delete(context.Background(), int(product.ID), dto.AnyVersion)
 	assert.NoError(t, err)
 
 	repository.AssertExpectations(t)
@@ -86,72 +96,224 @@ func TestGivenAValidParams_WhenICallUpdateProductService_ThenShouldReceiveSucces
 	productRequest := dto.CreateProductRequest{
 		Name:        "Macbook Pro",
 		Description: "O poderoso computador da Apple",
-		Price:       23000.00,
+		Price:       money.New(2300000, "BRL"),
 	}
 
 	productEntityService := &entity.Product{
 		Name:        "Macbook Pro",
 		Description: "O poderoso computador da Apple",
-		Price:       23000.00,
+		Price:       money.New(2300000, "BRL"),
 	}
 
 	repository := &mock.ProductRepositoryMock{}
-	repository.On("Create", productEntityService).Return(&entity.Product{
+	repository.On("Create", testify.Anything, productEntityService).Return(&entity.Product{
 		Name:        "Macbook Pro",
 		Description: "O poderoso computador da Apple",
-		Price:       23000.00,
+		Price:       money.New(2300000, "BRL"),
 	}, nil)
-	repository.On("FindByID", 1).Return(&entity.Product{
+	repository.On("FindByIDForUpdate", testify.Anything, 1).Return(&entity.Product{
 		Name:        "Macbook Pro",
 		Description: "O poderoso computador da Apple",
-		Price:       23000.00,
+		Price:       money.New(2300000, "BRL"),
 	}, nil).Once()
-	repository.On("Update", &entity.Product{
+	repository.On("Update", testify.Anything, &entity.Product{
 		Name:        "Macbook Pro 2024",
 		Description: "O poderoso computador da Apple",
-		Price:       15000.00,
+		Price:       money.New(1500000, "BRL"),
 	}).Return(nil)
-	repository.On("FindByID", 1).Return(&entity.Product{
+	repository.On("FindByID", testify.Anything, 1).Return(&entity.Product{
 		Name:        "Macbook Pro 2024",
 		Description: "O poderoso computador da Apple",
-		Price:       15000.00,
+		Price:       money.New(1500000, "BRL"),
 	}, nil).Once()
-	service := ProductService(repository)
+	service := ProductService(repository, &mock.UnitOfWork{})
 
-	product, err := service.Create(productRequest)
+	product, err := service.Create(context.Background(), productRequest)
 	assert.NoError(t, err)
 	assert.Equal(t, "Macbook Pro", product.Name)
 	assert.Equal(t, "O poderoso computador da Apple", product.Description)
-	assert.Equal(t, 23000.00, product.Price)
+	assert.Equal(t, money.New(2300000, "BRL"), product.Price)
 
-	product, err = service.Update(int(product.ID), dto.PutProductRequest{
+	product, err = service.Update(context.Background(), int(product.ID), dto.AnyVersion, dto.PutProductRequest{
 		Name:        "Macbook Pro 2024",
 		Description: "O poderoso computador da Apple",
-		Price:       15000.00,
+		Price:       money.New(1500000, "BRL"),
 	})
 	assert.NoError(t, err)
 	assert.Equal(t, "Macbook Pro 2024", product.Name)
-	assert.Equal(t, 15000.00, product.Price)
+	assert.Equal(t, money.New(1500000, "BRL"), product.Price)
 
 	repository.AssertExpectations(t)
 }
 
+func TestGivenOnlyAName_WhenICallPatchProductService_ThenShouldKeepTheOtherFields(t *testing.T) {
+	repository := &mock.ProductRepositoryMock{}
+	repository.On("FindByIDForUpdate", testify.Anything, 1).Return(&entity.Product{
+		Name:        "Macbook Pro",
+		Description: "O poderoso computador da Apple",
+		Price:       money.New(2300000, "BRL"),
+	}, nil).Once()
+	repository.On("Update", testify.Anything, &entity.Product{
+		Name:        "Macbook Pro 2024",
+		Description: "O poderoso computador da Apple",
+		Price:       money.New(2300000, "BRL"),
+	}).Return(nil)
+	repository.On("FindByID", testify.Anything, 1).Return(&entity.Product{
+		Name:        "Macbook Pro 2024",
+		Description: "O poderoso computador da Apple",
+		Price:       money.New(2300000, "BRL"),
+	}, nil).Once()
+	service := ProductService(repository, &mock.UnitOfWork{})
+
+	product, err := service.Patch(context.Background(), 1, dto.AnyVersion, dto.UpdateProductRequest{Name: optional.Of("Macbook Pro 2024")})
+	assert.NoError(t, err)
+	assert.Equal(t, "Macbook Pro 2024", product.Name)
+	assert.Equal(t, money.New(2300000, "BRL"), product.Price)
+	repository.AssertExpectations(t)
+}
+
+func TestGivenARequiredFieldSentAsNull_WhenICallPatchProductService_ThenShouldReceiveAnError(t *testing.T) {
+	repository := &mock.ProductRepositoryMock{}
+	repository.On("FindByIDForUpdate", testify.Anything, 1).Return(&entity.Product{
+		Name:        "Macbook Pro",
+		Description: "O poderoso computador da Apple",
+		Price:       money.New(2300000, "BRL"),
+	}, nil)
+	service := ProductService(repository, &mock.UnitOfWork{})
+
+	_, err := service.Patch(context.Background(), 1, dto.AnyVersion, dto.UpdateProductRequest{Description: optional.Null[string]()})
+	assert.ErrorIs(t, err, entity.ErrInvalidDescription)
+	repository.AssertNotCalled(t, "Update", testify.Anything, testify.Anything)
+}
+
+func TestGivenAMergePatch_WhenICallMergePatchProductService_ThenShouldKeepTheCurrency(t *testing.T) {
+	repository := &mock.ProductRepositoryMock{}
+	repository.On("FindByIDForUpdate", testify.Anything, 1).Return(&entity.Product{
+		Name:        "Macbook Pro",
+		Description: "O poderoso computador da Apple",
+		Price:       money.New(2300000, "USD"),
+	}, nil).Once()
+	repository.On("Update", testify.Anything, &entity.Product{
+		Name:        "Macbook Pro",
+		Description: "O poderoso computador da Apple",
+		Price:       money.New(1999990, "USD"),
+	}).Return(nil)
+	repository.On("FindByID", testify.Anything, 1).Return(&entity.Product{
+		Name:        "Macbook Pro",
+		Description: "O poderoso computador da Apple",
+		Price:       money.New(1999990, "USD"),
+	}, nil).Once()
+	service := ProductService(repository, &mock.UnitOfWork{})
+
+	product, err := service.MergePatch(context.Background(), 1, dto.AnyVersion, []byte(`{"price": {"amount": "19999.90"}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, money.New(1999990, "USD"), product.Price)
+	repository.AssertExpectations(t)
+}
+
+func TestGivenAJSONPatch_WhenICallJSONPatchProductService_ThenShouldValidateTheResult(t *testing.T) {
+	repository := &mock.ProductRepositoryMock{}
+	repository.On("FindByIDForUpdate", testify.Anything, 1).Return(&entity.Product{
+		Name:        "Macbook Pro",
+		Description: "O poderoso computador da Apple",
+		Price:       money.New(2300000, "BRL"),
+	}, nil)
+	service := ProductService(repository, &mock.UnitOfWork{})
+
+	_, err := service.JSONPatch(context.Background(), 1, dto.AnyVersion, []byte(`[{"op": "remove", "path": "/name"}]`))
+	assert.ErrorIs(t, err, entity.ErrInvalidName)
+
+	_, err = service.JSONPatch(context.Background(), 1, dto.AnyVersion, []byte(`[{"op": "add", "path": "/stock", "value": 10}]`))
+	assert.ErrorIs(t, err, ErrInvalidProductDocument)
+
+	_, err = service.JSONPatch(context.Background(), 1, dto.AnyVersion, []byte(`[{"op": "test", "path": "/name", "value": "Macbook Air"}]`))
+	assert.ErrorIs(t, err, jsonpatch.ErrTestFailed)
+	repository.AssertNotCalled(t, "Update", testify.Anything, testify.Anything)
+}
+
+func TestGivenAStaleVersion_WhenICallUpdateProductService_ThenShouldReceiveAVersionConflict(t *testing.T) {
+	repository := &mock.ProductRepositoryMock{}
+	repository.On("FindByIDForUpdate", testify.Anything, 1).Return(&entity.Product{
+		Name:        "Macbook Pro",
+		Description: "O poderoso computador da Apple",
+		Price:       money.New(2300000, "BRL"),
+		Version:     3,
+	}, nil)
+	service := ProductService(repository, &mock.UnitOfWork{})
+
+	_, err := service.Update(context.Background(), 1, dto.Precondition{Versions: []uint{2}}, dto.PutProductRequest{
+		Name:        "Macbook Pro 2024",
+		Description: "O poderoso computador da Apple",
+		Price:       money.New(1500000, "BRL"),
+	})
+	assert.ErrorIs(t, err, entity.ErrVersionConflict)
+
+	err = service.Delete(context.Background(), 1, dto.Precondition{Versions: []uint{2}})
+	assert.ErrorIs(t, err, entity.ErrVersionConflict)
+	repository.AssertNotCalled(t, "Update", testify.Anything, testify.Anything)
+	repository.AssertNotCalled(t, "Delete", testify.Anything, testify.Anything)
+}
+
+func TestGivenAConcurrentWrite_WhenICallPatchProductService_ThenShouldReceiveTheRepositoryConflict(t *testing.T) {
+	repository := &mock.ProductRepositoryMock{}
+	repository.On("FindByIDForUpdate", testify.Anything, 1).Return(&entity.Product{
+		Name:        "Macbook Pro",
+		Description: "O poderoso computador da Apple",
+		Price:       money.New(2300000, "BRL"),
+		Version:     3,
+	}, nil)
+	repository.On("Update", testify.Anything, testify.Anything).Return(entity.ErrVersionConflict)
+	service := ProductService(repository, &mock.UnitOfWork{})
+
+	_, err := service.Patch(context.Background(), 1, dto.Precondition{Versions: []uint{3}}, dto.UpdateProductRequest{Name: optional.Of("Macbook Air")})
+	assert.ErrorIs(t, err, entity.ErrVersionConflict)
+	repository.AssertExpectations(t)
+}
+
+func TestGivenAFailedWrite_WhenICallProductServiceWrites_ThenShouldRollBackOnlyItsUnitOfWork(t *testing.T) {
+	repository := &mock.ProductRepositoryMock{}
+	repository.On("FindByIDForUpdate", testify.Anything, 1).Return(&entity.Product{
+		Name:        "Macbook Pro",
+		Description: "O poderoso computador da Apple",
+		Price:       money.New(2300000, "BRL"),
+	}, nil)
+	repository.On("Update", testify.Anything, testify.Anything).Return(nil)
+	repository.On("FindByID", testify.Anything, 1).Return(&entity.Product{
+		Name:        "Macbook Air",
+		Description: "O poderoso computador da Apple",
+		Price:       money.New(2300000, "BRL"),
+	}, nil)
+	unitOfWork := &mock.UnitOfWork{}
+	service := ProductService(repository, unitOfWork)
+
+	_, err := service.Patch(context.Background(), 1, dto.AnyVersion, dto.UpdateProductRequest{Name: optional.Of("Macbook Air")})
+	assert.NoError(t, err)
+
+	_, err = service.Patch(context.Background(), 1, dto.AnyVersion, dto.UpdateProductRequest{Name: optional.Null[string]()})
+	assert.ErrorIs(t, err, entity.ErrInvalidName)
+
+	assert.Equal(t, 1, unitOfWork.Committed)
+	assert.Equal(t, 1, unitOfWork.RolledBack)
+	repository.AssertNumberOfCalls(t, "Update", 1)
+	assert.Equal(t, []dto.ProductSuggestion{{Id: 0, Name: "Macbook Air"}}, service.Suggest(dto.SuggestProductsQuery{Prefix: "macbook"}))
+}
+
 func TestGivenAValidParams_WhenICallFindAllProductService_ThenShouldReceiveSuccess(t *testing.T) {
 	repository := &mock.ProductRepositoryMock{}
-	repository.On("FindAll").Return([]entity.Product{
-		{Name: "Macbook Pro", Description: "Description", Price: 100.0},
-		{Name: "iPhone 15 Pro Max", Description: "Description", Price: 50.60},
-		{Name: "Livro Domain-Driven Design", Description: "Description", Price: 1599.99},
+	repository.On("FindAll", testify.Anything).Return([]entity.Product{
+		{Name: "Macbook Pro", Description: "Description", Price: money.New(10000, "BRL")},
+		{Name: "iPhone 15 Pro Max", Description: "Description", Price: money.New(5060, "BRL")},
+		{Name: "Livro Domain-Driven Design", Description: "Description", Price: money.New(159999, "BRL")},
 	}, nil)
-	service := ProductService(repository)
+	service := ProductService(repository, &mock.UnitOfWork{})
 
 	expectedProducts := []entity.Product{
-		{Name: "Macbook Pro", Description: "Description", Price: 100.0},
-		{Name: "iPhone 15 Pro Max", Description: "Description", Price: 50.60},
-		{Name: "Livro Domain-Driven Design", Description: "Description", Price: 1599.99},
+		{Name: "Macbook Pro", Description: "Description", Price: money.New(10000, "BRL")},
+		{Name: "iPhone 15 Pro Max", Description: "Description", Price: money.New(5060, "BRL")},
+		{Name: "Livro Domain-Driven Design", Description: "Description", Price: money.New(159999, "BRL")},
 	}
 
-	products, err := service.FindAll()
+	products, err := service.FindAll(context.Background())
 	assert.NoError(t, err)
 	assert.Equal(t, expectedProducts, products)
 	repository.AssertExpectations(t)
@@ -161,36 +323,472 @@ func TestGivenAValidParams_WhenICallFindOneProductService_ThenShouldReceiveSucce
 	productRequest := dto.CreateProductRequest{
 		Name:        "Macbook Pro",
 		Description: "O poderoso computador da Apple",
-		Price:       23000.00,
+		Price:       money.New(2300000, "BRL"),
 	}
 
 	productEntityService := &entity.Product{
 		Name:        "Macbook Pro",
 		Description: "O poderoso computador da Apple",
-		Price:       23000.00,
+		Price:       money.New(2300000, "BRL"),
 	}
 
 	repository := &mock.ProductRepositoryMock{}
-	repository.On("Create", productEntityService).Return(&entity.Product{
+	repository.On("Create", testify.Anything, productEntityService).Return(&entity.Product{
 		Name:        "Macbook Pro",
 		Description: "O poderoso computador da Apple",
-		Price:       23000.00,
+		Price:       money.New(2300000, "BRL"),
 	}, nil)
-	repository.On("FindByID", 1).Return(&entity.Product{
+	repository.On("FindByID", testify.Anything, 1).Return(&entity.Product{
 		Name:        "Macbook Pro",
 		Description: "O poderoso computador da Apple",
-		Price:       23000.00,
+		Price:       money.New(2300000, "BRL"),
 	}, nil).Once()
-	service := ProductService(repository)
+	service := ProductService(repository, &mock.UnitOfWork{})
 
-	product, err := service.Create(productRequest)
+	product, err := service.Create(context.Background(), productRequest)
 	assert.NoError(t, err)
 	assert.Equal(t, "Macbook Pro", product.Name)
 	assert.Equal(t, "O poderoso computador da Apple", product.Description)
-	assert.Equal(t, 23000.00, product.Price)
+	assert.Equal(t, money.New(2300000, "BRL"), product.Price)
 
-	product, err = service.FindOne(int(product.ID))
+	product, err = service.FindOne(context.Background(), int(product.ID))
 	assert.NoError(t, err)
 	assert.Equal(t, "Macbook Pro", product.Name)
 	repository.AssertExpectations(t)
 }
+
+func TestGivenAPageQuery_WhenICallListProductService_ThenShouldReceiveThePageAndTotal(t *testing.T) {
+	repository := &mock.ProductRepositoryMock{}
+	repository.On("List", testify.Anything, database.ListOptions{Offset: 20, Limit: 10}).Return([]entity.Product{
+		{Name: "Macbook Pro", Description: "Description", Price: money.New(10000, "BRL")},
+	}, int64(21), nil)
+	service := ProductService(repository, &mock.UnitOfWork{})
+
+	products, total, err := service.List(context.Background(), dto.ListProductsQuery{PageQuery: dto.PageQuery{Page: 3, PageSize: 10}})
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+	assert.Equal(t, int64(21), total)
+	repository.AssertExpectations(t)
+}
+
+func TestGivenAPageSizeAboveTheMax_WhenICallListProductService_ThenShouldCapThePageSize(t *testing.T) {
+	repository := &mock.ProductRepositoryMock{}
+	repository.On("List", testify.Anything, database.ListOptions{Offset: 0, Limit: dto.MaxPageSize}).Return([]entity.Product{}, int64(0), nil)
+	service := ProductService(repository, &mock.UnitOfWork{})
+
+	_, _, err := service.List(context.Background(), dto.ListProductsQuery{PageQuery: dto.PageQuery{PageSize: 5000}})
+	assert.NoError(t, err)
+	repository.AssertExpectations(t)
+}
+
+func TestGivenMoreProductsThanThePageSize_WhenICallListAfterProductService_ThenShouldReceiveTheNextPosition(t *testing.T) {
+	createdAt := time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC)
+	after := &cursor.Position{ID: 1, CreatedAt: createdAt}
+
+	repository := &mock.ProductRepositoryMock{}
+	repository.On("ListAfter", testify.Anything, database.ListOptions{Limit: 3, After: after}).Return([]entity.Product{
+		{Model: gorm.Model{ID: 2, CreatedAt: createdAt}, Name: "Macbook Pro"},
+		{Model: gorm.Model{ID: 3, CreatedAt: createdAt}, Name: "iPhone 15 Pro Max"},
+		{Model: gorm.Model{ID: 4, CreatedAt: createdAt}, Name: "Livro Domain-Driven Design"},
+	}, nil)
+	service := ProductService(repository, &mock.UnitOfWork{})
+
+	products, next, err := service.ListAfter(context.Background(), dto.ListProductsQuery{PageQuery: dto.PageQuery{PageSize: 2}}, after)
+	assert.NoError(t, err)
+	assert.Len(t, products, 2)
+	assert.Equal(t, &cursor.Position{ID: 3, CreatedAt: createdAt}, next)
+	repository.AssertExpectations(t)
+}
+
+func TestGivenTheLastProducts_WhenICallListAfterProductService_ThenShouldNotReceiveTheNextPosition(t *testing.T) {
+	repository := &mock.ProductRepositoryMock{}
+	repository.On("ListAfter", testify.Anything, database.ListOptions{Limit: 3}).Return([]entity.Product{
+		{Model: gorm.Model{ID: 1}, Name: "Macbook Pro"},
+	}, nil)
+	service := ProductService(repository, &mock.UnitOfWork{})
+
+	products, next, err := service.ListAfter(context.Background(), dto.ListProductsQuery{PageQuery: dto.PageQuery{PageSize: 2}}, nil)
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+	assert.Nil(t, next)
+	repository.AssertExpectations(t)
+}
+
+func TestGivenSortAndFilters_WhenICallListProductService_ThenShouldReceiveTheRepositoryOptions(t *testing.T) {
+	repository := &mock.ProductRepositoryMock{}
+	repository.On("List", testify.Anything, database.ListOptions{
+		Offset: 0,
+		Limit:  dto.DefaultPageSize,
+		Sort: []database.Sort{
+			{Field: "price"},
+			{Field: "created_at", Desc: true},
+		},
+		Filter: database.ProductFilter{Currency: "BRL", MinPrice: 1000, MaxPrice: 10000, NameContains: "Pro"},
+	}).Return([]entity.Product{}, int64(0), nil)
+	service := ProductService(repository, &mock.UnitOfWork{})
+
+	_, _, err := service.List(context.Background(), dto.ListProductsQuery{
+		Sort: "price,-created_at",
+		ProductFilterQuery: dto.ProductFilterQuery{
+			MinPrice:     10,
+			MaxPrice:     100,
+			NameContains: "Pro",
+		},
+	})
+	assert.NoError(t, err)
+	repository.AssertExpectations(t)
+}
+
+func TestGivenASearchQuery_WhenICallSearchProductService_ThenShouldReceiveScoredResultsWithHighlights(t *testing.T) {
+	repository := &mock.ProductRepositoryMock{}
+	repository.On("Search", testify.Anything, "apple", dto.DefaultSearchLimit).Return([]database.SearchResult{
+		{
+			Product: entity.Product{Name: "Macbook Pro", Description: "O poderoso computador da Apple", Price: money.New(2300000, "BRL")},
+			Score:   1.5,
+		},
+	}, nil)
+	service := ProductService(repository, &mock.UnitOfWork{})
+
+	results, err := service.Search(context.Background(), dto.SearchProductsQuery{Q: "apple"})
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+	assert.Equal(t, 1.5, results[0].Score)
+	assert.Equal(t, map[string]string{"description": "O poderoso computador da <em>Apple</em>"}, results[0].Highlights)
+	repository.AssertExpectations(t)
+}
+
+func TestGivenLoadedSuggestions_WhenICallSuggestProductService_ThenShouldReceiveTheCurrentNames(t *testing.T) {
+	repository := &mock.ProductRepositoryMock{}
+	repository.On("FindAll", testify.Anything).Return([]entity.Product{
+		{Model: gorm.Model{ID: 1}, Name: "Macbook Pro"},
+		{Model: gorm.Model{ID: 2}, Name: "Macbook Air"},
+	}, nil)
+	repository.On("FindByIDForUpdate", testify.Anything, 2).Return(&entity.Product{Model: gorm.Model{ID: 2}, Name: "Macbook Air"}, nil)
+	repository.On("Delete", testify.Anything, &entity.Product{Model: gorm.Model{ID: 2}, Name: "Macbook Air"}).Return(nil)
+	service := ProductService(repository, &mock.UnitOfWork{})
+
+	assert.NoError(t, service.LoadSuggestions(context.Background()))
+	assert.Equal(t, []dto.ProductSuggestion{
+		{Id: 2, Name: "Macbook Air"},
+		{Id: 1, Name: "Macbook Pro"},
+	}, service.Suggest(dto.SuggestProductsQuery{Prefix: "mcbook"}))
+
+	assert.NoError(t, service.Delete(context.Background(), 2, dto.AnyVersion))
+	assert.Equal(t, []dto.ProductSuggestion{
+		{Id: 1, Name: "Macbook Pro"},
+	}, service.Suggest(dto.SuggestProductsQuery{Prefix: "mcbook"}))
+	repository.AssertExpectations(t)
+}
+
+func TestGivenFilteredProducts_WhenICallStatsProductService_ThenShouldReceiveAggregatesAndHistogram(t *testing.T) {
+	filter := database.ProductFilter{Currency: "BRL", MinPrice: 1000}
+
+	repository := &mock.ProductRepositoryMock{}
+	repository.On("PriceStats", testify.Anything, filter).Return(database.PriceStats{
+		Count:    4,
+		MinPrice: 1000,
+		MaxPrice: 5000,
+		AvgPrice: 2500,
+	}, nil)
+	repository.On("PricesAt", testify.Anything, filter, 1, 2).Return([]int64{1500, 2500}, nil)
+	repository.On("PriceHistogram", testify.Anything, filter, int64(1000), int64(2000)).Return(map[int]int64{0: 3, 2: 1}, nil)
+	service := ProductService(repository, &mock.UnitOfWork{})
+
+	stats, err := service.Stats(context.Background(), dto.ProductStatsQuery{
+		Buckets:            2,
+		ProductFilterQuery: dto.ProductFilterQuery{MinPrice: 10},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), stats.Count)
+	assert.Equal(t, money.New(2000, "BRL"), stats.MedianPrice)
+	assert.Equal(t, []dto.PriceBucketResponse{
+		{From: money.New(1000, "BRL"), To: money.New(3000, "BRL"), Count: 3},
+		{From: money.New(3000, "BRL"), To: money.New(5000, "BRL"), Count: 1},
+	}, stats.Histogram)
+	repository.AssertExpectations(t)
+}
+
+func TestGivenProductsInManyCurrencies_WhenICallStatsProductService_ThenShouldOnlyAggregateTheRequestedCurrency(t *testing.T) {
+	filter := database.ProductFilter{Currency: "USD", MinPrice: 1000}
+
+	repository := &mock.ProductRepositoryMock{}
+	repository.On("PriceStats", testify.Anything, filter).Return(database.PriceStats{
+		Count:    2,
+		MinPrice: 1000,
+		MaxPrice: 3000,
+		AvgPrice: 2000,
+	}, nil)
+	repository.On("PricesAt", testify.Anything, filter, 0, 2).Return([]int64{1000, 3000}, nil)
+	repository.On("PriceHistogram", testify.Anything, filter, int64(1000), int64(1000)).Return(map[int]int64{0: 1, 2: 1}, nil)
+	service := ProductService(repository, &mock.UnitOfWork{})
+
+	stats, err := service.Stats(context.Background(), dto.ProductStatsQuery{
+		Buckets:            2,
+		Currency:           "USD",
+		ProductFilterQuery: dto.ProductFilterQuery{MinPrice: 10},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, money.New(1000, "USD"), stats.MinPrice)
+	assert.Equal(t, money.New(2000, "USD"), stats.MedianPrice)
+	assert.Equal(t, []dto.PriceBucketResponse{
+		{From: money.New(1000, "USD"), To: money.New(2000, "USD"), Count: 1},
+		{From: money.New(2000, "USD"), To: money.New(3000, "USD"), Count: 1},
+	}, stats.Histogram)
+	repository.AssertExpectations(t)
+}
+
+func TestGivenProductsWithTheSamePrice_WhenICallStatsProductService_ThenShouldReceiveASingleBucket(t *testing.T) {
+	repository := &mock.ProductRepositoryMock{}
+	repository.On("PriceStats", testify.Anything, database.ProductFilter{Currency: "BRL"}).Return(database.PriceStats{
+		Count:    3,
+		MinPrice: 2500,
+		MaxPrice: 2500,
+		AvgPrice: 2500,
+	}, nil)
+	repository.On("PricesAt", testify.Anything, database.ProductFilter{Currency: "BRL"}, 1, 1).Return([]int64{2500}, nil)
+	service := ProductService(repository, &mock.UnitOfWork{})
+
+	stats, err := service.Stats(context.Background(), dto.ProductStatsQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, []dto.PriceBucketResponse{
+		{From: money.New(2500, "BRL"), To: money.New(2500, "BRL"), Count: 3},
+	}, stats.Histogram)
+	repository.AssertExpectations(t)
+}
+
+func TestGivenTooManyBuckets_WhenICallStatsProductService_ThenShouldCapTheHistogram(t *testing.T) {
+	repository := &mock.ProductRepositoryMock{}
+	repository.On("PriceStats", testify.Anything, database.ProductFilter{Currency: "BRL"}).Return(database.PriceStats{
+		Count:    1,
+		MinPrice: 0,
+		MaxPrice: 100000,
+		AvgPrice: 100000,
+	}, nil)
+	repository.On("PricesAt", testify.Anything, database.ProductFilter{Currency: "BRL"}, 0, 1).Return([]int64{100000}, nil)
+	repository.On("PriceHistogram", testify.Anything, database.ProductFilter{Currency: "BRL"}, int64(0), int64(2000)).Return(map[int]int64{}, nil)
+	repository.On("PriceHistogram", testify.Anything, database.ProductFilter{Currency: "BRL"}, int64(0), int64(2001)).Return(map[int]int64{}, nil)
+	service := ProductService(repository, &mock.UnitOfWork{})
+
+	stats, err := service.Stats(context.Background(), dto.ProductStatsQuery{Buckets: 1000})
+	assert.NoError(t, err)
+	assert.Len(t, stats.Histogram, dto.MaxStatsBuckets)
+
+	stats, err = service.Stats(context.Background(), dto.ProductStatsQuery{BucketSize: 0.01})
+	assert.NoError(t, err)
+	assert.Len(t, stats.Histogram, dto.MaxStatsBuckets)
+	repository.AssertExpectations(t)
+}
+
+func TestGivenNoProducts_WhenICallStatsProductService_ThenShouldReceiveAnEmptyHistogram(t *testing.T) {
+	repository := &mock.ProductRepositoryMock{}
+	repository.On("PriceStats", testify.Anything, database.ProductFilter{Currency: "BRL"}).Return(database.PriceStats{}, nil)
+	service := ProductService(repository, &mock.UnitOfWork{})
+
+	stats, err := service.Stats(context.Background(), dto.ProductStatsQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), stats.Count)
+	assert.Empty(t, stats.Histogram)
+	repository.AssertExpectations(t)
+}
+
+func TestGivenRequestedFields_WhenICallFindOneFieldsProductService_ThenShouldSelectOnlyThoseFields(t *testing.T) {
+	repository := &mock.ProductRepositoryMock{}
+	repository.On("FindByIDFields", testify.Anything, 1, []string{"name", "price"}).Return(&entity.Product{
+		Name:  "Macbook Pro",
+		Price: money.New(2300000, "BRL"),
+	}, nil)
+	service := ProductService(repository, &mock.UnitOfWork{})
+
+	product, err := service.FindOneFields(context.Background(), 1, []string{"name", "price"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Macbook Pro", product.Name)
+	repository.AssertExpectations(t)
+}
+
+func TestGivenTheCatalogState_WhenICallCatalogValidatorsProductService_ThenShouldDeriveAWeakETag(t *testing.T) {
+	lastModified := time.Date(2024, 5, 2, 12, 0, 0, 0, time.UTC)
+
+	repository := &mock.ProductRepositoryMock{}
+	repository.On("CatalogState", testify.Anything).Return(database.CatalogState{Count: 3, LastModified: lastModified}, nil)
+	service := ProductService(repository, &mock.UnitOfWork{})
+
+	validators, err := service.CatalogValidators(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, `W/"3-1714651200000000000"`, validators.ETag)
+	assert.Equal(t, lastModified, validators.LastModified)
+	repository.AssertExpectations(t)
+}
+
+func bulkOperations() []dto.BulkProductOperation {
+	return []dto.BulkProductOperation{
+		{Op: dto.BulkCreate, Index: 0, Create: dto.CreateProductRequest{
+			Name: "Kindle", Description: "O leitor da Amazon", Price: money.New(59900, "BRL"),
+		}},
+		{Op: dto.BulkUpdate, Index: 1, ID: 1, Update: dto.UpdateProductRequest{Name: optional.Of("Macbook Air")}},
+		{Op: dto.BulkDelete, Index: 2, ID: 2, Version: 1},
+		{Op: dto.BulkDelete, Index: 3, ID: 3},
+	}
+}
+
+func bulkProducts() []entity.Product {
+	return []entity.Product{
+		{Model: gorm.Model{ID: 1}, Name: "Macbook Pro", Description: "O poderoso computador da Apple", Price: money.New(2300000, "BRL"), Version: 1},
+		{Model: gorm.Model{ID: 2}, Name: "iPhone 15 Pro Max", Description: "O iPhone mais poderoso", Price: money.New(1000000, "BRL"), Version: 2},
+	}
+}
+
+func TestGivenAFailingOperation_WhenICallAtomicBulkProductService_ThenShouldNotWriteAnything(t *testing.T) {
+	repository := &mock.ProductRepositoryMock{}
+	repository.On("FindByIDs", testify.Anything, []uint{1, 2, 3}).Return(bulkProducts(), nil)
+	service := ProductService(repository, &mock.UnitOfWork{})
+
+	results, err := service.Bulk(context.Background(), bulkOperations(), true)
+	assert.NoError(t, err)
+	assert.Len(t, results, 4)
+	assert.ErrorIs(t, results[0].Err, ErrBulkNotApplied)
+	assert.ErrorIs(t, results[1].Err, ErrBulkNotApplied)
+	assert.ErrorIs(t, results[2].Err, entity.ErrVersionConflict)
+	assert.ErrorIs(t, results[3].Err, entity.ErrProductNotFound)
+	repository.AssertNotCalled(t, "BulkWrite", testify.Anything, testify.Anything)
+}
+
+func TestGivenAConcurrentWrite_WhenICallPartialBulkProductService_ThenShouldWriteTheOtherOperations(t *testing.T) {
+	operations := bulkOperations()[:2]
+	operations = append(operations, dto.BulkProductOperation{Op: dto.BulkDelete, Index: 2, ID: 2})
+
+	repository := &mock.ProductRepositoryMock{}
+	repository.On("FindByIDs", testify.Anything, []uint{1, 2}).Return(bulkProducts(), nil)
+	repository.On("BulkWrite", testify.Anything, testify.MatchedBy(func(writes database.BulkWrites) bool {
+		return writes.Len() == 3
+	})).Return(&database.BulkConflictError{IDs: []uint{2}}).Once()
+	repository.On("BulkWrite", testify.Anything, testify.MatchedBy(func(writes database.BulkWrites) bool {
+		return len(writes.Create) == 1 && len(writes.Update) == 1 && len(writes.Delete) == 0
+	})).Return(nil).Once()
+	service := ProductService(repository, &mock.UnitOfWork{})
+
+	results, err := service.Bulk(context.Background(), operations, false)
+	assert.NoError(t, err)
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, "Kindle", results[0].Product.Name)
+	assert.NoError(t, results[1].Err)
+	assert.Equal(t, "Macbook Air", results[1].Product.Name)
+	assert.Equal(t, "O poderoso computador da Apple", results[1].Product.Description)
+	assert.ErrorIs(t, results[2].Err, entity.ErrVersionConflict)
+	assert.Nil(t, results[2].Product)
+	repository.AssertExpectations(t)
+}
+
+type importRows []dto.ImportRow
+
+func (r *importRows) Next() (dto.ImportRow, error) {
+	if len(*r) == 0 {
+		return dto.ImportRow{}, io.EOF
+	}
+
+	row := (*r)[0]
+	*r = (*r)[1:]
+	return row, nil
+}
+
+func TestGivenAnImportFile_WhenICallImportProductService_ThenShouldUpsertByName(t *testing.T) {
+	rows := importRows{
+		{Line: 2, Product: dto.CreateProductRequest{Name: "macbook pro", Description: "O novo Macbook", Price: money.New(2000000, "BRL")}},
+		{Line: 3, Product: dto.CreateProductRequest{Name: "iPhone 15 Pro Max", Description: "O iPhone mais poderoso", Price: money.New(1000000, "BRL")}},
+		{Line: 4, Errors: []dto.ImportRowError{{Line: 4, Field: "price", Message: "invalid amount"}}},
+		{Line: 5, Product: dto.CreateProductRequest{Name: "Kindle", Description: "O leitor da Amazon", Price: money.New(59900, "BRL")}},
+	}
+
+	repository := &mock.ProductRepositoryMock{}
+	repository.On("FindByNames", testify.Anything, []string{"macbook pro", "iPhone 15 Pro Max", "Kindle"}).Return(bulkProducts(), nil)
+	repository.On("BulkWrite", testify.Anything, testify.MatchedBy(func(writes database.BulkWrites) bool {
+		return len(writes.Create) == 1 && writes.Create[0].Name == "Kindle" &&
+			len(writes.Update) == 1 && writes.Update[0].ID == 1 && writes.Update[0].Description == "O novo Macbook"
+	})).Return(nil)
+	service := ProductService(repository, &mock.UnitOfWork{})
+
+	result, err := service.Import(context.Background(), &rows, false)
+	assert.NoError(t, err)
+	assert.Equal(t, &dto.ImportResult{
+		Rows:      4,
+		Created:   1,
+		Updated:   1,
+		Unchanged: 1,
+		Failed:    1,
+		Errors:    []dto.ImportRowError{{Line: 4, Field: "price", Message: "invalid amount"}},
+	}, result)
+	repository.AssertExpectations(t)
+}
+
+func TestGivenADryRun_WhenICallImportProductService_ThenShouldNotWriteAnything(t *testing.T) {
+	rows := importRows{
+		{Line: 2, Product: dto.CreateProductRequest{Name: "Kindle", Description: "O leitor da Amazon", Price: money.New(59900, "BRL")}},
+	}
+
+	repository := &mock.ProductRepositoryMock{}
+	repository.On("FindByNames", testify.Anything, []string{"Kindle"}).Return([]entity.Product{}, nil)
+	service := ProductService(repository, &mock.UnitOfWork{})
+
+	result, err := service.Import(context.Background(), &rows, true)
+	assert.NoError(t, err)
+	assert.True(t, result.DryRun)
+	assert.Equal(t, 1, result.Created)
+	repository.AssertNotCalled(t, "BulkWrite", testify.Anything, testify.Anything)
+}
+
+func TestGivenATrashedProduct_WhenICallRestoreProductService_ThenShouldReceiveItBackInTheSuggestions(t *testing.T) {
+	repository := &mock.ProductRepositoryMock{}
+	trashed := &entity.Product{
+		Model:   gorm.Model{ID: 1, DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true}},
+		Name:    "Macbook Pro",
+		Version: 2,
+	}
+	repository.On("FindWithTrashedForUpdate", testify.Anything, 1).Return(trashed, nil)
+	repository.On("Restore", testify.Anything, trashed).Return(nil)
+	repository.On("FindByID", testify.Anything, 1).Return(&entity.Product{Model: gorm.Model{ID: 1}, Name: "Macbook Pro", Version: 3}, nil)
+	service := ProductService(repository, &mock.UnitOfWork{})
+
+	product, err := service.Restore(context.Background(), 1, dto.Precondition{Versions: []uint{2}})
+	assert.NoError(t, err)
+	assert.Equal(t, uint(3), product.Version)
+	assert.Equal(t, []dto.ProductSuggestion{{Id: 1, Name: "Macbook Pro"}}, service.Suggest(dto.SuggestProductsQuery{Prefix: "macbook"}))
+	repository.AssertExpectations(t)
+}
+
+func TestGivenAProductNotTrashed_WhenICallRestoreProductService_ThenShouldReceiveAConflict(t *testing.T) {
+	repository := &mock.ProductRepositoryMock{}
+	repository.On("FindWithTrashedForUpdate", testify.Anything, 1).Return(&entity.Product{Model: gorm.Model{ID: 1}, Name: "Macbook Pro"}, nil)
+	unitOfWork := &mock.UnitOfWork{}
+	service := ProductService(repository, unitOfWork)
+
+	_, err := service.Restore(context.Background(), 1, dto.AnyVersion)
+	assert.ErrorIs(t, err, ErrProductNotTrashed)
+	assert.Equal(t, 1, unitOfWork.RolledBack)
+	repository.AssertNotCalled(t, "Restore", testify.Anything, testify.Anything)
+}
+
+func TestGivenAProduct_WhenICallPurgeProductService_ThenShouldRemoveItForGood(t *testing.T) {
+	repository := &mock.ProductRepositoryMock{}
+	product := &entity.Product{Model: gorm.Model{ID: 2}, Name: "Macbook Air", Version: 1}
+	repository.On("FindAll", testify.Anything).Return([]entity.Product{*product}, nil)
+	repository.On("FindWithTrashedForUpdate", testify.Anything, 2).Return(product, nil)
+	repository.On("Purge", testify.Anything, product).Return(nil)
+	service := ProductService(repository, &mock.UnitOfWork{})
+
+	assert.NoError(t, service.LoadSuggestions(context.Background()))
+	err := service.Purge(context.Background(), 2, dto.Precondition{Versions: []uint{3}})
+	assert.ErrorIs(t, err, entity.ErrVersionConflict)
+
+	assert.NoError(t, service.Purge(context.Background(), 2, dto.AnyVersion))
+	assert.Empty(t, service.Suggest(dto.SuggestProductsQuery{Prefix: "macbook"}))
+	repository.AssertNumberOfCalls(t, "Purge", 1)
+}
+
+func TestGivenAPageOfTheTrash_WhenICallTrashProductService_ThenShouldReadItsOffset(t *testing.T) {
+	repository := &mock.ProductRepositoryMock{}
+	repository.On("ListTrashed", testify.Anything, 200, 100).Return([]entity.Product{}, int64(0), nil)
+	service := ProductService(repository, &mock.UnitOfWork{})
+
+	_, _, err := service.Trash(context.Background(), dto.ListTrashQuery{PageQuery: dto.PageQuery{Page: 3, PageSize: 500}})
+	assert.NoError(t, err)
+	repository.AssertExpectations(t)
+}
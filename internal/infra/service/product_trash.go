@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+
+	"github.com/waldrey/eulabs/internal/dto"
+	"github.com/waldrey/eulabs/internal/entity"
+)
+
+var ErrProductNotTrashed = entity.Conflict("product_not_trashed", "product is not in the trash")
+
+// Trash lists the products deleted and not purged yet, the last deleted first.
+func (p *Product) Trash(ctx context.Context, query dto.ListTrashQuery) ([]entity.Product, int64, error) {
+	query.Normalize()
+
+	return p.repository.ListTrashed(ctx, query.Offset(), query.PageSize)
+}
+
+// Restore takes a deleted product out of the trash, failing with
+// ErrProductNotTrashed when it was not deleted.
+func (p *Product) Restore(ctx context.Context, id int, precondition dto.Precondition) (*entity.Product, error) {
+	var product *entity.Product
+	err := p.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		var err error
+		product, err = p.findTrashedForWrite(ctx, id, precondition)
+		if err != nil {
+			return err
+		}
+
+		if !product.DeletedAt.Valid {
+			return ErrProductNotTrashed
+		}
+
+		err = p.repository.Restore(ctx, product)
+		if err != nil {
+			return err
+		}
+
+		product, err = p.repository.FindByID(ctx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	p.suggestions.Add(product.ID, product.Name)
+
+	return product, nil
+}
+
+// Purge removes the product for good, whether it is in the trash or not.
+func (p *Product) Purge(ctx context.Context, id int, precondition dto.Precondition) error {
+	var product *entity.Product
+	err := p.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		var err error
+		product, err = p.findTrashedForWrite(ctx, id, precondition)
+		if err != nil {
+			return err
+		}
+
+		return p.repository.Purge(ctx, product)
+	})
+	if err != nil {
+		return err
+	}
+	p.suggestions.Remove(product.ID)
+
+	return nil
+}
+
+// findTrashedForWrite is findForWrite reading the deleted products too.
+func (p *Product) findTrashedForWrite(ctx context.Context, id int, precondition dto.Precondition) (*entity.Product, error) {
+	product, err := p.repository.FindWithTrashedForUpdate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !precondition.Matches(product.Version) {
+		return nil, entity.ErrVersionConflict
+	}
+
+	return product, nil
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/waldrey/eulabs/internal/infra/database"
+)
+
+// retentionInterval is how often the purges run.
+const retentionInterval = time.Hour
+
+// Purge removes for good the data kept past its time, Name tells what it
+// removes in the logs.
+type Purge struct {
+	Name string
+	Run  func(ctx context.Context, now time.Time) (int64, error)
+}
+
+// TrashPurge purges the products kept in the trash longer than period.
+func TrashPurge(repository database.ProductInterface, period time.Duration) Purge {
+	return Purge{
+		Name: "products from the trash",
+		Run: func(ctx context.Context, now time.Time) (int64, error) {
+			return repository.PurgeTrashed(ctx, now.Add(-period))
+		},
+	}
+}
+
+// ExpiredKeysPurge purges the idempotency keys no longer replayed, along with
+// their stored responses.
+func ExpiredKeysPurge(repository database.IdempotencyInterface) Purge {
+	return Purge{
+		Name: "expired idempotency keys",
+		Run:  repository.DeleteExpired,
+	}
+}
+
+// Retention runs the purges when it starts and then every retentionInterval.
+type Retention struct {
+	purges   []Purge
+	interval time.Duration
+
+	stop   chan struct{}
+	done   chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func RetentionService(purges ...Purge) *Retention {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Retention{
+		purges:   purges,
+		interval: retentionInterval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+func (r *Retention) Start() {
+	go r.run()
+}
+
+// Shutdown stops the purges and waits for the running one, which is cancelled
+// when the context ends first.
+func (r *Retention) Shutdown(ctx context.Context) error {
+	close(r.stop)
+
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		r.cancel()
+		<-r.done
+		return ctx.Err()
+	}
+}
+
+func (r *Retention) run() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		for _, purge := range r.purges {
+			purged, err := purge.Run(r.ctx, time.Now())
+			if err != nil {
+				log.Printf("failed purge %s: %v", purge.Name, err)
+			} else if purged > 0 {
+				log.Printf("purged %d %s", purged, purge.Name)
+			}
+		}
+
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
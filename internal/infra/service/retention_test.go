@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	testify "github.com/stretchr/testify/mock"
+	"github.com/waldrey/eulabs/test/mock"
+)
+
+func TestGivenARetentionPeriod_WhenTheRetentionStarts_ThenShouldPurgeTheProductsTrashedBeforeIt(t *testing.T) {
+	repository := &mock.ProductRepositoryMock{}
+	purged := make(chan time.Time, 10)
+	repository.On("PurgeTrashed", testify.Anything, testify.Anything).Return(int64(2), nil).Run(func(args testify.Arguments) {
+		purged <- args.Get(1).(time.Time)
+	})
+
+	retention := RetentionService(TrashPurge(repository, 30*24*time.Hour))
+	retention.interval = 10 * time.Millisecond
+	retention.Start()
+
+	first := <-purged
+	assert.WithinDuration(t, time.Now().Add(-30*24*time.Hour), first, time.Minute)
+	<-purged
+
+	assert.NoError(t, retention.Shutdown(context.Background()))
+}
+
+func TestGivenExpiredIdempotencyKeys_WhenTheRetentionStarts_ThenShouldDeleteThem(t *testing.T) {
+	repository := &mock.IdempotencyRepositoryMock{}
+	deleted := make(chan time.Time, 10)
+	repository.On("DeleteExpired", testify.Anything, testify.Anything).Return(int64(3), nil).Run(func(args testify.Arguments) {
+		deleted <- args.Get(1).(time.Time)
+	})
+
+	retention := RetentionService(ExpiredKeysPurge(repository))
+	retention.interval = 10 * time.Millisecond
+	retention.Start()
+
+	assert.WithinDuration(t, time.Now(), <-deleted, time.Minute)
+	<-deleted
+
+	assert.NoError(t, retention.Shutdown(context.Background()))
+}
@@ -0,0 +1,46 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/waldrey/eulabs/pkg/requests"
+)
+
+type BodyLimitConfig struct {
+	Skipper middleware.Skipper
+
+	// Limit is the largest body accepted in bytes, zero leaves bodies unlimited
+	Limit int64
+}
+
+// BodyLimit refuses the requests whose body is longer than the limit with
+// 413, up front when they declare their Content-Length and otherwise once the
+// handler reads past the limit.
+func BodyLimit(limit int64) echo.MiddlewareFunc {
+	return BodyLimitWithConfig(BodyLimitConfig{Limit: limit})
+}
+
+func BodyLimitWithConfig(config BodyLimitConfig) echo.MiddlewareFunc {
+	if config.Skipper == nil {
+		config.Skipper = middleware.DefaultSkipper
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) || config.Limit <= 0 {
+				return next(c)
+			}
+
+			request := c.Request()
+			if request.ContentLength > config.Limit {
+				return requests.ErrBodyTooLarge
+			}
+
+			// unlike a reader failing after the limit, nothing past it is read
+			request.Body = http.MaxBytesReader(c.Response(), request.Body, config.Limit)
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package middlewares
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/waldrey/eulabs/pkg/requests"
+)
+
+func serveWithBodyLimit(config BodyLimitConfig, request *http.Request) *httptest.ResponseRecorder {
+	e := echo.New()
+	e.HTTPErrorHandler = requests.HTTPErrorHandler
+	e.Use(BodyLimitWithConfig(config))
+	e.POST("/products", func(c echo.Context) error {
+		body, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			return err
+		}
+		return c.String(http.StatusOK, string(body))
+	})
+
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestGivenABodyOverTheLimit_WhenItIsSent_ThenShouldReceiveRequestEntityTooLarge(t *testing.T) {
+	declared := httptest.NewRequest(http.MethodPost, "/products", strings.NewReader("0123456789"))
+	recorder := serveWithBodyLimit(BodyLimitConfig{Limit: 8}, declared)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"code":"body_too_large"`)
+
+	chunked := httptest.NewRequest(http.MethodPost, "/products", io.NopCloser(strings.NewReader("0123456789")))
+	chunked.ContentLength = -1
+	recorder = serveWithBodyLimit(BodyLimitConfig{Limit: 8}, chunked)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"code":"body_too_large"`)
+}
+
+func TestGivenASkippedRoute_WhenItsBodyIsOverTheLimit_ThenShouldReadItWhole(t *testing.T) {
+	request := httptest.NewRequest(http.MethodPost, "/products", strings.NewReader("0123456789"))
+	recorder := serveWithBodyLimit(BodyLimitConfig{
+		Skipper: RouteSkipper(http.MethodPost, "/products"),
+		Limit:   8,
+	}, request)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "0123456789", recorder.Body.String())
+}
@@ -0,0 +1,244 @@
+package middlewares
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/waldrey/eulabs/internal/dto"
+	"github.com/waldrey/eulabs/internal/infra/service"
+	"github.com/waldrey/eulabs/pkg/requests"
+)
+
+const (
+	HeaderIdempotencyKey     = "Idempotency-Key"
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+	MaxIdempotencyKeyLength  = 255
+
+	// DefaultIdempotencyBodyLimit caps the request and response bodies kept
+	// in memory for a key
+	DefaultIdempotencyBodyLimit = 1 << 20
+)
+
+// transientStatus are not replayed, the client may fix them and retry with
+// the same key.
+var transientStatus = []int{
+	http.StatusUnauthorized,
+	http.StatusForbidden,
+	http.StatusRequestTimeout,
+	http.StatusConflict,
+	http.StatusTooEarly,
+	http.StatusTooManyRequests,
+}
+
+var (
+	errIdempotencyKeyTooLong   = requests.NewProblem(http.StatusBadRequest, "idempotency_key_too_long", "Idempotency-Key is too long")
+	errIdempotentBodyTooLarge  = requests.NewProblem(http.StatusRequestEntityTooLarge, "idempotent_body_too_large", "request body too large to be sent with an Idempotency-Key")
+	errIdempotentBodyMalformed = requests.NewProblem(http.StatusBadRequest, "invalid_body", "invalid request body")
+)
+
+type IdempotencyConfig struct {
+	// Skipper leaves out the routes the key does not apply to, like streamed
+	// uploads that are never held in memory
+	Skipper middleware.Skipper
+
+	Store service.IdempotencyInterface
+
+	// BodyLimit caps the bodies kept in memory, larger requests are refused
+	// and larger responses are not replayed. Zero is DefaultIdempotencyBodyLimit
+	BodyLimit int64
+
+	// RefreshInterval is how often the key of a running request is extended,
+	// zero is a third of service.IdempotencyLockTimeout
+	RefreshInterval time.Duration
+}
+
+// Idempotency replays the stored response of unsafe requests retried with the
+// same Idempotency-Key, the key is refused with 422 when reused with another
+// request. Requests without the header are not affected.
+func Idempotency(store service.IdempotencyInterface) echo.MiddlewareFunc {
+	return IdempotencyWithConfig(IdempotencyConfig{Store: store})
+}
+
+func IdempotencyWithConfig(config IdempotencyConfig) echo.MiddlewareFunc {
+	if config.Skipper == nil {
+		config.Skipper = middleware.DefaultSkipper
+	}
+
+	if config.BodyLimit <= 0 {
+		config.BodyLimit = DefaultIdempotencyBodyLimit
+	}
+
+	if config.RefreshInterval <= 0 {
+		config.RefreshInterval = service.IdempotencyLockTimeout / 3
+	}
+	store := config.Store
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			request := c.Request()
+			key := request.Header.Get(HeaderIdempotencyKey)
+			if key == "" || isSafe(request.Method) || config.Skipper(c) {
+				return next(c)
+			}
+
+			if len(key) > MaxIdempotencyKeyLength {
+				return errIdempotencyKeyTooLong
+			}
+
+			// the body is hashed as it is read, one byte past the limit is
+			// enough to refuse it
+			digest := fingerprint(request)
+			var body bytes.Buffer
+			_, err := body.ReadFrom(io.TeeReader(io.LimitReader(request.Body, config.BodyLimit+1), digest))
+			if err != nil {
+				return errIdempotentBodyMalformed
+			}
+
+			if int64(body.Len()) > config.BodyLimit {
+				return errIdempotentBodyTooLarge
+			}
+			request.Body = io.NopCloser(&body)
+
+			stored, err := store.Begin(request.Context(), key, hex.EncodeToString(digest.Sum(nil)))
+			if err != nil {
+				return err
+			}
+
+			if stored != nil {
+				return replay(c, *stored)
+			}
+
+			recorder := &responseRecorder{ResponseWriter: c.Response().Writer, limit: config.BodyLimit}
+			c.Response().Writer = recorder
+
+			// the response is stored even when the client is gone or the
+			// deadline of the request is over, the retry is replayed
+			ctx := context.WithoutCancel(request.Context())
+
+			// a retry must not run the request again while it still runs,
+			// however long it takes
+			stop := keepReserved(ctx, store, key, config.RefreshInterval)
+
+			// errors are rendered here so their response is recorded too
+			if err := next(c); err != nil {
+				c.Error(err)
+			}
+			stop()
+
+			status := c.Response().Status
+			if status >= http.StatusInternalServerError || slices.Contains(transientStatus, status) || recorder.truncated {
+				err = store.Release(ctx, key)
+			} else {
+				err = store.Complete(ctx, key, dto.StoredResponse{
+					StatusCode: status,
+					Header:     c.Response().Header(),
+					Body:       recorder.body.Bytes(),
+				})
+			}
+
+			if err != nil {
+				log.Printf("failed store idempotency key: %v", err)
+			}
+
+			return nil
+		}
+	}
+}
+
+// keepReserved extends the key every interval until the returned func is
+// called, which waits for the last extension.
+func keepReserved(ctx context.Context, store service.IdempotencyInterface, key string, interval time.Duration) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := store.Extend(ctx, key); err != nil {
+					log.Printf("failed extend idempotency key: %v", err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+func isSafe(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// fingerprint starts the hash identifying the request a key was first used
+// with, the body is written to it next.
+func fingerprint(request *http.Request) hash.Hash {
+	digest := sha256.New()
+	digest.Write([]byte(request.Method + " " + request.URL.RequestURI() + "\n"))
+
+	return digest
+}
+
+func replay(c echo.Context, stored dto.StoredResponse) error {
+	header := c.Response().Header()
+	for name := range stored.Header {
+		header.Set(name, stored.Header.Get(name))
+	}
+	header.Set(HeaderIdempotentReplayed, "true")
+
+	c.Response().WriteHeader(stored.StatusCode)
+	_, err := c.Response().Write(stored.Body)
+	return err
+}
+
+// responseRecorder keeps a copy of the body written to the client up to the
+// limit, past it the copy is dropped and the response marked truncated.
+type responseRecorder struct {
+	http.ResponseWriter
+	body      bytes.Buffer
+	limit     int64
+	truncated bool
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	if !r.truncated && int64(r.body.Len()+len(data)) > r.limit {
+		r.truncated = true
+		r.body = bytes.Buffer{}
+	}
+
+	if !r.truncated {
+		r.body.Write(data)
+	}
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (r *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(r.ResponseWriter).Hijack()
+}
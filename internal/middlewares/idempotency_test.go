@@ -0,0 +1,194 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/waldrey/eulabs/internal/dto"
+	"github.com/waldrey/eulabs/internal/infra/service"
+	"github.com/waldrey/eulabs/pkg/requests"
+)
+
+// memoryStore keeps the keys in memory, like the database store does.
+type memoryStore struct {
+	fingerprints map[string]string
+	responses    map[string]dto.StoredResponse
+	extended     atomic.Int32
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{fingerprints: map[string]string{}, responses: map[string]dto.StoredResponse{}}
+}
+
+func (m *memoryStore) Begin(ctx context.Context, key string, fingerprint string) (*dto.StoredResponse, error) {
+	stored, ok := m.fingerprints[key]
+	if !ok {
+		m.fingerprints[key] = fingerprint
+		return nil, nil
+	}
+
+	if stored != fingerprint {
+		return nil, service.ErrIdempotencyKeyReused
+	}
+
+	response, ok := m.responses[key]
+	if !ok {
+		return nil, service.ErrIdempotencyKeyInFlight
+	}
+	return &response, nil
+}
+
+func (m *memoryStore) Complete(ctx context.Context, key string, response dto.StoredResponse) error {
+	m.responses[key] = dto.StoredResponse{StatusCode: response.StatusCode, Header: response.Header.Clone(), Body: response.Body}
+	return nil
+}
+
+func (m *memoryStore) Extend(ctx context.Context, key string) error {
+	m.extended.Add(1)
+	return nil
+}
+
+func (m *memoryStore) Release(ctx context.Context, key string) error {
+	delete(m.fingerprints, key)
+	return nil
+}
+
+func newServer(store service.IdempotencyInterface, status *int) (*echo.Echo, *int) {
+	return newServerWithConfig(IdempotencyConfig{Store: store}, status)
+}
+
+func newServerWithConfig(config IdempotencyConfig, status *int) (*echo.Echo, *int) {
+	calls := 0
+	e := echo.New()
+	e.HTTPErrorHandler = requests.HTTPErrorHandler
+	e.Use(IdempotencyWithConfig(config))
+	e.POST("/products", func(c echo.Context) error {
+		calls++
+		return c.JSON(*status, map[string]int{"call": calls})
+	})
+
+	return e, &calls
+}
+
+func post(e *echo.Echo, key string, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodPost, "/products", strings.NewReader(body))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	if key != "" {
+		request.Header.Set(HeaderIdempotencyKey, key)
+	}
+
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestGivenARetriedKey_WhenIPostAgain_ThenShouldReplayTheFirstResponse(t *testing.T) {
+	status := http.StatusCreated
+	e, calls := newServer(newMemoryStore(), &status)
+
+	first := post(e, "checkout-1", `{"name": "Macbook Pro"}`)
+	second := post(e, "checkout-1", `{"name": "Macbook Pro"}`)
+
+	assert.Equal(t, 1, *calls)
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.JSONEq(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, echo.MIMEApplicationJSON, second.Header().Get(echo.HeaderContentType))
+	assert.Equal(t, "true", second.Header().Get(HeaderIdempotentReplayed))
+}
+
+func TestGivenAReusedKey_WhenIPostAnotherBody_ThenShouldReceiveUnprocessableEntity(t *testing.T) {
+	status := http.StatusCreated
+	e, calls := newServer(newMemoryStore(), &status)
+
+	post(e, "checkout-1", `{"name": "Macbook Pro"}`)
+	response := post(e, "checkout-1", `{"name": "Macbook Air"}`)
+
+	assert.Equal(t, 1, *calls)
+	assert.Equal(t, http.StatusUnprocessableEntity, response.Code)
+}
+
+func TestGivenAServerError_WhenIRetryTheKey_ThenShouldRunTheRequestAgain(t *testing.T) {
+	status := http.StatusInternalServerError
+	e, calls := newServer(newMemoryStore(), &status)
+
+	post(e, "checkout-1", `{"name": "Macbook Pro"}`)
+	status = http.StatusCreated
+	response := post(e, "checkout-1", `{"name": "Macbook Pro"}`)
+
+	assert.Equal(t, 2, *calls)
+	assert.Equal(t, http.StatusCreated, response.Code)
+	assert.Empty(t, response.Header().Get(HeaderIdempotentReplayed))
+}
+
+func TestGivenNoKey_WhenIPostTwice_ThenShouldRunBothRequests(t *testing.T) {
+	status := http.StatusCreated
+	e, calls := newServer(newMemoryStore(), &status)
+
+	post(e, "", `{"name": "Macbook Pro"}`)
+	post(e, "", `{"name": "Macbook Pro"}`)
+
+	assert.Equal(t, 2, *calls)
+}
+
+func TestGivenABodyOverTheLimit_WhenIPostWithAKey_ThenShouldReceiveRequestEntityTooLarge(t *testing.T) {
+	status := http.StatusCreated
+	e, calls := newServerWithConfig(IdempotencyConfig{Store: newMemoryStore(), BodyLimit: 16}, &status)
+
+	response := post(e, "checkout-1", `{"name": "Macbook Pro"}`)
+
+	assert.Equal(t, 0, *calls)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, response.Code)
+}
+
+func TestGivenAResponseOverTheLimit_WhenIRetryTheKey_ThenShouldRunTheRequestAgain(t *testing.T) {
+	status := http.StatusCreated
+	e, calls := newServerWithConfig(IdempotencyConfig{Store: newMemoryStore(), BodyLimit: 8}, &status)
+
+	first := post(e, "checkout-1", `{}`)
+	second := post(e, "checkout-1", `{}`)
+
+	assert.Equal(t, 2, *calls)
+	assert.JSONEq(t, `{"call": 1}`, first.Body.String())
+	assert.Empty(t, second.Header().Get(HeaderIdempotentReplayed))
+}
+
+func TestGivenASkippedRoute_WhenIPostWithAKey_ThenShouldNotReadTheBody(t *testing.T) {
+	status := http.StatusCreated
+	e, calls := newServerWithConfig(IdempotencyConfig{
+		Skipper:   func(c echo.Context) bool { return true },
+		Store:     newMemoryStore(),
+		BodyLimit: 16,
+	}, &status)
+
+	post(e, "checkout-1", `{"name": "Macbook Pro"}`)
+	response := post(e, "checkout-1", `{"name": "Macbook Pro"}`)
+
+	assert.Equal(t, 2, *calls)
+	assert.Equal(t, http.StatusCreated, response.Code)
+}
+
+func TestGivenALongRequest_WhenItOutlivesTheRefreshInterval_ThenShouldKeepItsKeyReserved(t *testing.T) {
+	store := newMemoryStore()
+	e := echo.New()
+	e.Use(IdempotencyWithConfig(IdempotencyConfig{Store: store, RefreshInterval: 5 * time.Millisecond}))
+	e.POST("/products/bulk", func(c echo.Context) error {
+		time.Sleep(30 * time.Millisecond)
+		return c.JSON(http.StatusOK, map[string]bool{"applied": true})
+	})
+
+	request := httptest.NewRequest(http.MethodPost, "/products/bulk", strings.NewReader(`{}`))
+	request.Header.Set(HeaderIdempotencyKey, "bulk-1")
+	e.ServeHTTP(httptest.NewRecorder(), request)
+	extended := store.extended.Load()
+
+	assert.Greater(t, extended, int32(1))
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, extended, store.extended.Load())
+}
@@ -0,0 +1,25 @@
+package middlewares
+
+import (
+	"slices"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// RouteSkipper skips the requests of the method to any of the routes, given
+// as registered, like /api/v1/jobs/:id/result.
+func RouteSkipper(method string, routes ...string) middleware.Skipper {
+	return func(c echo.Context) bool {
+		return c.Request().Method == method && slices.Contains(routes, c.Path())
+	}
+}
+
+// AnySkipper skips the requests skipped by any of the skippers.
+func AnySkipper(skippers ...middleware.Skipper) middleware.Skipper {
+	return func(c echo.Context) bool {
+		return slices.ContainsFunc(skippers, func(skipper middleware.Skipper) bool {
+			return skipper(c)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/waldrey/eulabs/pkg/requests"
+)
+
+type TimeoutConfig struct {
+	Skipper middleware.Skipper
+
+	// Timeout is the deadline of each request, zero leaves requests without one
+	Timeout time.Duration
+}
+
+// Timeout sets a deadline on the context of each request, the queries still
+// running when it is exceeded are cancelled and the request fails with 504.
+// A request cancelled before, by the client or the shutdown, fails with 503.
+func Timeout(timeout time.Duration) echo.MiddlewareFunc {
+	return TimeoutWithConfig(TimeoutConfig{Timeout: timeout})
+}
+
+func TimeoutWithConfig(config TimeoutConfig) echo.MiddlewareFunc {
+	if config.Skipper == nil {
+		config.Skipper = middleware.DefaultSkipper
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			ctx := c.Request().Context()
+			if config.Timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, config.Timeout)
+				defer cancel()
+				c.SetRequest(c.Request().WithContext(ctx))
+			}
+
+			err := next(c)
+			if err == nil || ctx.Err() == nil {
+				return err
+			}
+
+			// whatever failed first, the request failed for being out of time
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return requests.ErrRequestTimeout
+			}
+			return requests.ErrRequestCanceled
+		}
+	}
+}
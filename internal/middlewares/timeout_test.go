@@ -0,0 +1,86 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/waldrey/eulabs/pkg/requests"
+)
+
+func serveWithTimeout(timeout time.Duration, request *http.Request, handler echo.HandlerFunc) *httptest.ResponseRecorder {
+	e := echo.New()
+	e.HTTPErrorHandler = requests.HTTPErrorHandler
+	e.Use(Timeout(timeout))
+	e.GET("/products", handler)
+
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func waitContext(c echo.Context) error {
+	<-c.Request().Context().Done()
+	return c.Request().Context().Err()
+}
+
+func TestGivenASlowRequest_WhenTheDeadlineIsExceeded_ThenShouldReceiveGatewayTimeout(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/products", nil)
+	recorder := serveWithTimeout(10*time.Millisecond, request, waitContext)
+
+	assert.Equal(t, http.StatusGatewayTimeout, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"code":"request_timeout"`)
+}
+
+func TestGivenACanceledRequest_WhenTheHandlerFails_ThenShouldReceiveServiceUnavailable(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	request := httptest.NewRequest(http.MethodGet, "/products", nil).WithContext(ctx)
+	recorder := serveWithTimeout(time.Minute, request, waitContext)
+
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"code":"request_canceled"`)
+}
+
+func TestGivenAFastRequest_WhenItEndsBeforeTheDeadline_ThenShouldReceiveItsResponse(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/products", nil)
+	recorder := serveWithTimeout(time.Minute, request, func(c echo.Context) error {
+		_, deadline := c.Request().Context().Deadline()
+		return c.JSON(http.StatusOK, map[string]bool{"deadline": deadline})
+	})
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"deadline": true}`, recorder.Body.String())
+}
+
+func TestGivenASkippedRoute_WhenItOutlivesTheTimeout_ThenShouldReceiveItsResponse(t *testing.T) {
+	e := echo.New()
+	e.HTTPErrorHandler = requests.HTTPErrorHandler
+	e.Use(TimeoutWithConfig(TimeoutConfig{
+		Skipper: AnySkipper(RouteSkipper(http.MethodGet, "/export"), RouteSkipper(http.MethodPost, "/products/import")),
+		Timeout: 10 * time.Millisecond,
+	}))
+	slow := func(c echo.Context) error {
+		select {
+		case <-time.After(30 * time.Millisecond):
+			return c.JSON(http.StatusOK, map[string]bool{"imported": true})
+		case <-c.Request().Context().Done():
+			return c.Request().Context().Err()
+		}
+	}
+	e.POST("/products/import", slow)
+	e.POST("/products", slow)
+
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/products/import", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"imported": true}`, recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	e.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/products", nil))
+	assert.Equal(t, http.StatusGatewayTimeout, recorder.Code)
+}
@@ -0,0 +1,75 @@
+package cursor
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Position is the keyset of the last row returned to the client, rows are
+// walked ordered by created_at and id.
+type Position struct {
+	ID        uint      `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Signer struct {
+	secret []byte
+}
+
+// NewSigner creates a signer for the given secret. When the secret is empty a
+// random one is generated, so cursors will not survive a server restart.
+func NewSigner(secret string) *Signer {
+	if secret == "" {
+		key := make([]byte, 32)
+		_, _ = rand.Read(key)
+		return &Signer{secret: key}
+	}
+
+	return &Signer{secret: []byte(secret)}
+}
+
+func (s *Signer) Encode(position Position) string {
+	payload, _ := json.Marshal(position)
+	encodedPayload := base64.RawURLEncoding.EncodeToString(payload)
+
+	return encodedPayload + "." + base64.RawURLEncoding.EncodeToString(s.sign(encodedPayload))
+}
+
+func (s *Signer) Decode(token string) (Position, error) {
+	var position Position
+
+	encodedPayload, encodedSignature, found := strings.Cut(token, ".")
+	if !found {
+		return position, ErrInvalidCursor
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, s.sign(encodedPayload)) {
+		return position, ErrInvalidCursor
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return position, ErrInvalidCursor
+	}
+
+	if err := json.Unmarshal(payload, &position); err != nil {
+		return position, ErrInvalidCursor
+	}
+
+	return position, nil
+}
+
+func (s *Signer) sign(payload string) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,30 @@
+package cursor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGivenAPosition_WhenIEncodeAndDecode_ThenShouldReceiveTheSamePosition(t *testing.T) {
+	signer := NewSigner("secret")
+	position := Position{ID: 42, CreatedAt: time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC)}
+
+	decoded, err := signer.Decode(signer.Encode(position))
+	assert.NoError(t, err)
+	assert.Equal(t, position.ID, decoded.ID)
+	assert.True(t, position.CreatedAt.Equal(decoded.CreatedAt))
+}
+
+func TestGivenATokenSignedWithAnotherSecret_WhenIDecode_ThenShouldReceiveAnError(t *testing.T) {
+	token := NewSigner("another").Encode(Position{ID: 42})
+
+	_, err := NewSigner("secret").Decode(token)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestGivenAMalformedToken_WhenIDecode_ThenShouldReceiveAnError(t *testing.T) {
+	_, err := NewSigner("secret").Decode("not-a-cursor")
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
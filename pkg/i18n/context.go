@@ -0,0 +1,25 @@
+package i18n
+
+import (
+	ut "github.com/go-playground/universal-translator"
+	"github.com/labstack/echo/v4"
+)
+
+const contextKey = "i18n.translator"
+
+// Localize negotiates the language of each request from its Accept-Language
+// header, for the messages sent back.
+func Localize(catalog *Catalog) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set(contextKey, catalog.Translator(c.Request().Header.Get("Accept-Language")))
+			return next(c)
+		}
+	}
+}
+
+// FromContext is the translator negotiated by Localize, nil without it.
+func FromContext(c echo.Context) ut.Translator {
+	translator, _ := c.Get(contextKey).(ut.Translator)
+	return translator
+}
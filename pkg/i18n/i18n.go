@@ -0,0 +1,179 @@
+package i18n
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/en_US"
+	"github.com/go-playground/locales/pt_BR"
+	ut "github.com/go-playground/universal-translator"
+)
+
+// catalogs are the built-in messages, en-US is the fallback language.
+//
+//go:embed catalogs/*.json
+var catalogs embed.FS
+
+// Catalog holds the messages of every language, in the JSON format of
+// universal-translator: a list of {"locale", "key", "trans"} entries, with
+// locales named like pt_BR and the params of the message as {0}, {1}...
+type Catalog struct {
+	universal *ut.UniversalTranslator
+	locales   []string
+}
+
+// NewCatalog returns the catalog of the built-in languages.
+func NewCatalog() (*Catalog, error) {
+	catalog := &Catalog{
+		universal: ut.New(en_US.New(), en_US.New(), pt_BR.New()),
+		locales:   []string{en_US.New().Locale(), pt_BR.New().Locale()},
+	}
+	err := fs.WalkDir(catalogs, "catalogs", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		data, err := catalogs.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return catalog.importMessages(path, data)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return catalog, nil
+}
+
+// Load adds the messages of a catalog file, or of every .json file of a
+// directory. Other languages are added as they appear, the messages of the
+// built-in languages are only replaced by entries with "override": true.
+func (c *Catalog) Load(path string) error {
+	return filepath.WalkDir(path, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || filepath.Ext(path) != ".json" {
+			return err
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return c.importMessages(path, data)
+	})
+}
+
+func (c *Catalog) importMessages(path string, data []byte) error {
+	var entries []struct {
+		Locale string `json:"locale"`
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("catalog %s: %w", path, err)
+	}
+
+	for _, entry := range entries {
+		if _, found := c.universal.GetTranslator(entry.Locale); !found {
+			// only plain messages are used, the plural rules of English do
+			if err := c.universal.AddTranslator(&language{Translator: en_US.New(), locale: entry.Locale}, false); err != nil {
+				return fmt.Errorf("catalog %s: %w", path, err)
+			}
+			c.locales = append(c.locales, entry.Locale)
+		}
+	}
+
+	if err := c.universal.ImportByReader(ut.FormatJSON, bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("catalog %s: %w", path, err)
+	}
+	return nil
+}
+
+// Translator negotiates the language of an Accept-Language header, a tag like
+// pt matches pt-BR. The fallback is en-US.
+func (c *Catalog) Translator(acceptLanguage string) ut.Translator {
+	for _, tag := range parseAcceptLanguage(acceptLanguage) {
+		locale := strings.ReplaceAll(tag, "-", "_")
+		if translator, found := c.universal.GetTranslator(locale); found {
+			return translator
+		}
+		if translator, found := c.regionOf(locale); found {
+			return translator
+		}
+	}
+
+	return c.universal.GetFallback()
+}
+
+// regionOf finds the first loaded region of a language given without one.
+func (c *Catalog) regionOf(language string) (ut.Translator, bool) {
+	if strings.Contains(language, "_") {
+		return nil, false
+	}
+
+	for _, locale := range c.locales {
+		if base, _, _ := strings.Cut(locale, "_"); strings.EqualFold(base, language) {
+			return c.universal.GetTranslator(locale)
+		}
+	}
+	return nil, false
+}
+
+// parseAcceptLanguage lists the language tags by quality, in the order of the
+// header when equal. The wildcard and refused tags are left out.
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag     string
+		quality float64
+	}
+
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		tag = strings.TrimSpace(tag)
+		quality := 1.0
+		if name, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(name) == "q" {
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if tag == "" || tag == "*" || quality <= 0 {
+			continue
+		}
+		tags = append(tags, weighted{tag: tag, quality: quality})
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool {
+		return tags[i].quality > tags[j].quality
+	})
+
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = tag.tag
+	}
+	return names
+}
+
+// Tag is the BCP 47 tag of the language of the translator, like pt-BR.
+func Tag(translator ut.Translator) string {
+	return strings.ReplaceAll(translator.Locale(), "_", "-")
+}
+
+// language is a locale without built-in rules, added by a catalog file.
+type language struct {
+	locales.Translator
+	locale string
+}
+
+func (l *language) Locale() string {
+	return l.locale
+}
@@ -0,0 +1,64 @@
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGivenAnAcceptLanguage_WhenINegotiate_ThenShouldReceiveThePreferredLanguageOfTheCatalog(t *testing.T) {
+	catalog, err := NewCatalog()
+	assert.NoError(t, err)
+
+	assert.Equal(t, "pt-BR", Tag(catalog.Translator("pt-BR")))
+	assert.Equal(t, "pt-BR", Tag(catalog.Translator("pt")))
+	assert.Equal(t, "pt-BR", Tag(catalog.Translator("fr-FR, en;q=0.5, pt;q=0.8")))
+	assert.Equal(t, "en-US", Tag(catalog.Translator("en-GB")))
+	assert.Equal(t, "en-US", Tag(catalog.Translator("fr, pt;q=0")))
+	assert.Equal(t, "en-US", Tag(catalog.Translator("")))
+}
+
+func TestGivenARefusedField_WhenIAskItsMessage_ThenShouldReceiveItInTheLanguageOfTheTranslator(t *testing.T) {
+	catalog, err := NewCatalog()
+	assert.NoError(t, err)
+
+	assert.Equal(t, "the field 'price' is gt", FieldMessage(nil, "price", "gt", "0"))
+	assert.Equal(t, "the field 'price' is gt", FieldMessage(catalog.Translator("en-US"), "price", "gt", "0"))
+	assert.Equal(t, "o campo 'price' deve ser maior que 0", FieldMessage(catalog.Translator("pt-BR"), "price", "gt", "0"))
+	assert.Equal(t, "o campo 'name' é inválido (isbn)", FieldMessage(catalog.Translator("pt-BR"), "name", "isbn", ""))
+}
+
+func TestGivenACatalogFile_WhenILoadIt_ThenShouldAddItsLanguageAndOverrides(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "fr_FR.json"), []byte(`[
+		{"locale": "fr_FR", "key": "problem.product_not_found", "trans": "produit introuvable"},
+		{"locale": "fr_FR", "key": "problem.invalid_parameter", "trans": "paramètre {0} invalide, {1}"}
+	]`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "pt_BR.json"), []byte(`[
+		{"locale": "pt_BR", "key": "problem.product_not_found", "trans": "produto inexistente", "override": true}
+	]`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("not a catalog"), 0644))
+
+	catalog, err := NewCatalog()
+	assert.NoError(t, err)
+	assert.NoError(t, catalog.Load(dir))
+
+	french := catalog.Translator("fr")
+	assert.Equal(t, "fr-FR", Tag(french))
+	assert.Equal(t, "produit introuvable", Message(french, "problem.product_not_found", "product not found"))
+	assert.Equal(t, "invalid dry_run parameter", Message(french, "problem.invalid_parameter", "invalid dry_run parameter", "dry_run"))
+	assert.Equal(t, "produto inexistente", Message(catalog.Translator("pt-BR"), "problem.product_not_found", "product not found"))
+}
+
+func TestGivenAConflictingCatalogFile_WhenILoadIt_ThenShouldReceiveAnError(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "pt_BR.json"), []byte(`[
+		{"locale": "pt_BR", "key": "problem.product_not_found", "trans": "produto inexistente"}
+	]`), 0644))
+
+	catalog, err := NewCatalog()
+	assert.NoError(t, err)
+	assert.Error(t, catalog.Load(dir))
+}
@@ -0,0 +1,51 @@
+package i18n
+
+import (
+	"log"
+	"strconv"
+	"strings"
+
+	ut "github.com/go-playground/universal-translator"
+)
+
+// fieldMessage is the message of a refused field when the catalog has none,
+// with the field name and the validation tag.
+const fieldMessage = "the field '{0}' is {1}"
+
+// Message translates the message of the key, the fallback is returned when
+// there is no translator or the catalog has no such message.
+func Message(translator ut.Translator, key string, fallback string, params ...string) (message string) {
+	if translator == nil {
+		return fallback
+	}
+
+	defer func() {
+		// a message of the catalog can ask for more params than it is given
+		if recovered := recover(); recovered != nil {
+			log.Printf("failed translate %s to %s: %v", key, translator.Locale(), recovered)
+			message = fallback
+		}
+	}()
+
+	message, err := translator.T(key, params...)
+	if err != nil {
+		return fallback
+	}
+	return message
+}
+
+// FieldMessage is the message of a field refused by a validation tag, looked
+// up as field.<tag> with the field and the param of the tag, then as field
+// with the field and the tag.
+func FieldMessage(translator ut.Translator, field string, tag string, param string) string {
+	fallback := Message(translator, "field", replaceParams(fieldMessage, field, tag), field, tag)
+	return Message(translator, "field."+tag, fallback, field, param)
+}
+
+// replaceParams fills the params of a message without a translator.
+func replaceParams(message string, params ...string) string {
+	for i, param := range params {
+		message = strings.ReplaceAll(message, "{"+strconv.Itoa(i)+"}", param)
+	}
+	return message
+}
@@ -0,0 +1,84 @@
+package jsonpatch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const product = `{"name": "Macbook Pro", "description": "O poderoso computador da Apple", "price": {"amount": "23000.00", "currency": "BRL"}}`
+
+func TestGivenAMergePatch_WhenIApplyIt_ThenShouldMergeObjectsAndRemoveNulls(t *testing.T) {
+	result, err := MergePatch([]byte(product), []byte(`{"name": "Macbook Air", "price": {"amount": "9999.90"}, "description": null}`))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"name": "Macbook Air", "price": {"amount": "9999.90", "currency": "BRL"}}`, string(result))
+}
+
+func TestGivenTheRFC7396Examples_WhenIMergePatch_ThenShouldReceiveTheExpectedResults(t *testing.T) {
+	cases := []struct{ document, patch, expected string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, c := range cases {
+		result, err := MergePatch([]byte(c.document), []byte(c.patch))
+		assert.NoError(t, err)
+		assert.JSONEq(t, c.expected, string(result), c.patch)
+	}
+}
+
+func TestGivenAJSONPatch_WhenIApplyIt_ThenShouldRunEveryOperation(t *testing.T) {
+	result, err := Apply([]byte(product), []byte(`[
+		{"op": "test", "path": "/price/currency", "value": "BRL"},
+		{"op": "replace", "path": "/name", "value": "Macbook Air"},
+		{"op": "add", "path": "/tags", "value": ["apple"]},
+		{"op": "add", "path": "/tags/0", "value": "laptop"},
+		{"op": "add", "path": "/tags/-", "value": "m3"},
+		{"op": "remove", "path": "/description"}
+	]`))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"name": "Macbook Air", "price": {"amount": "23000.00", "currency": "BRL"}, "tags": ["laptop", "apple", "m3"]}`, string(result))
+}
+
+func TestGivenAFailingTest_WhenIApplyAJSONPatch_ThenShouldNotApplyAnyOperation(t *testing.T) {
+	_, err := Apply([]byte(product), []byte(`[
+		{"op": "replace", "path": "/name", "value": "Macbook Air"},
+		{"op": "test", "path": "/price/currency", "value": "USD"}
+	]`))
+	assert.ErrorIs(t, err, ErrTestFailed)
+}
+
+func TestGivenEqualNumbersInOtherNotation_WhenITestThem_ThenShouldPass(t *testing.T) {
+	_, err := Apply([]byte(`{"stock": 10}`), []byte(`[{"op": "test", "path": "/stock", "value": 10.0}]`))
+	assert.NoError(t, err)
+}
+
+func TestGivenEscapedPointers_WhenIApplyAJSONPatch_ThenShouldUnescapeThem(t *testing.T) {
+	result, err := Apply([]byte(`{"a/b": 1, "m~n": 2}`), []byte(`[
+		{"op": "replace", "path": "/a~1b", "value": 3},
+		{"op": "remove", "path": "/m~0n"}
+	]`))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"a/b": 3}`, string(result))
+}
+
+func TestGivenInvalidOperations_WhenIApplyAJSONPatch_ThenShouldReceiveTheReason(t *testing.T) {
+	_, err := Apply([]byte(product), []byte(`[{"op": "move", "from": "/name", "path": "/title"}]`))
+	assert.ErrorIs(t, err, ErrUnsupportedOperation)
+
+	_, err = Apply([]byte(product), []byte(`[{"op": "replace", "path": "/stock", "value": 1}]`))
+	assert.ErrorIs(t, err, ErrPathNotFound)
+
+	_, err = Apply([]byte(product), []byte(`[{"op": "replace", "path": "/name"}]`))
+	assert.ErrorIs(t, err, ErrInvalidPatch)
+
+	_, err = Apply([]byte(product), []byte(`{"op": "remove", "path": "/name"}`))
+	assert.ErrorIs(t, err, ErrInvalidPatch)
+}
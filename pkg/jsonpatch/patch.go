@@ -0,0 +1,277 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidPatch         = errors.New("invalid patch")
+	ErrUnsupportedOperation = errors.New("unsupported operation")
+	ErrPathNotFound         = errors.New("path not found")
+	ErrTestFailed           = errors.New("test failed")
+)
+
+// Operation is one step of a RFC 6902 JSON Patch, the value is only used by
+// add, replace and test.
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Apply runs the add, remove, replace and test operations of a RFC 6902 JSON
+// Patch over the document. The patch is atomic, the document is only returned
+// when every operation succeeds.
+func Apply(document []byte, patch []byte) ([]byte, error) {
+	target, err := decode(document)
+	if err != nil {
+		return nil, ErrInvalidDocument
+	}
+
+	var operations []Operation
+	if err := json.Unmarshal(patch, &operations); err != nil {
+		return nil, ErrInvalidPatch
+	}
+
+	for i, operation := range operations {
+		target, err = apply(target, operation)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+
+	return json.Marshal(target)
+}
+
+func apply(target interface{}, operation Operation) (interface{}, error) {
+	tokens, err := parsePointer(operation.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+	switch operation.Op {
+	case "add", "replace", "test":
+		if len(operation.Value) == 0 {
+			return nil, fmt.Errorf("%w: %s without value", ErrInvalidPatch, operation.Op)
+		}
+
+		value, err = decode(operation.Value)
+		if err != nil {
+			return nil, ErrInvalidPatch
+		}
+	case "remove":
+	case "":
+		return nil, fmt.Errorf("%w: missing op", ErrInvalidPatch)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedOperation, operation.Op)
+	}
+
+	if operation.Op == "test" {
+		current, err := get(target, tokens)
+		if err != nil {
+			return nil, err
+		}
+
+		if !equal(current, value) {
+			return nil, fmt.Errorf("%w: %s", ErrTestFailed, operation.Path)
+		}
+		return target, nil
+	}
+
+	return update(target, tokens, operation.Op, value, operation.Path)
+}
+
+// parsePointer splits a RFC 6901 JSON Pointer in its unescaped tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: bad path %q", ErrInvalidPatch, pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+
+	return tokens, nil
+}
+
+func get(target interface{}, tokens []string) (interface{}, error) {
+	for _, token := range tokens {
+		switch node := target.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s", ErrPathNotFound, token)
+			}
+			target = value
+		case []interface{}:
+			index, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			target = node[index]
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrPathNotFound, token)
+		}
+	}
+
+	return target, nil
+}
+
+// update walks to the parent of the last token and changes it, returning the
+// new target since the root itself may be replaced.
+func update(target interface{}, tokens []string, op string, value interface{}, path string) (interface{}, error) {
+	if len(tokens) == 0 {
+		if op == "remove" {
+			return nil, fmt.Errorf("%w: cannot remove the root", ErrInvalidPatch)
+		}
+		return value, nil
+	}
+
+	token := tokens[0]
+	last := len(tokens) == 1
+
+	switch node := target.(type) {
+	case map[string]interface{}:
+		child, ok := node[token]
+		if !last {
+			if !ok {
+				return nil, fmt.Errorf("%w: %s", ErrPathNotFound, path)
+			}
+
+			updated, err := update(child, tokens[1:], op, value, path)
+			if err != nil {
+				return nil, err
+			}
+			node[token] = updated
+			return node, nil
+		}
+
+		if !ok && op != "add" {
+			return nil, fmt.Errorf("%w: %s", ErrPathNotFound, path)
+		}
+
+		if op == "remove" {
+			delete(node, token)
+		} else {
+			node[token] = value
+		}
+		return node, nil
+
+	case []interface{}:
+		if !last {
+			index, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s", err, path)
+			}
+
+			updated, err := update(node[index], tokens[1:], op, value, path)
+			if err != nil {
+				return nil, err
+			}
+			node[index] = updated
+			return node, nil
+		}
+
+		if op == "add" {
+			index := len(node)
+			if token != "-" {
+				var err error
+				index, err = arrayIndex(token, len(node))
+				if err != nil {
+					return nil, fmt.Errorf("%w: %s", err, path)
+				}
+			}
+
+			node = append(node, nil)
+			copy(node[index+1:], node[index:])
+			node[index] = value
+			return node, nil
+		}
+
+		index, err := arrayIndex(token, len(node)-1)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", err, path)
+		}
+
+		if op == "remove" {
+			return append(node[:index], node[index+1:]...), nil
+		}
+		node[index] = value
+		return node, nil
+
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrPathNotFound, path)
+	}
+}
+
+// arrayIndex reads a decimal array index no greater than max, leading zeros
+// are not allowed by RFC 6901.
+func arrayIndex(token string, max int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, ErrPathNotFound
+	}
+
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || index > max {
+		return 0, ErrPathNotFound
+	}
+
+	return index, nil
+}
+
+// equal compares decoded JSON values, numbers are equal when they have the
+// same value whatever their notation.
+func equal(a interface{}, b interface{}) bool {
+	switch a := a.(type) {
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+
+		for name, value := range a {
+			other, ok := b[name]
+			if !ok || !equal(value, other) {
+				return false
+			}
+		}
+		return true
+
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+
+		for i := range a {
+			if !equal(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+
+		x, okX := new(big.Rat).SetString(a.String())
+		y, okY := new(big.Rat).SetString(b.String())
+		return okX && okY && x.Cmp(y) == 0
+
+	default:
+		return a == b
+	}
+}
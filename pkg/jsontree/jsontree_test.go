@@ -0,0 +1,31 @@
+package jsontree
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGivenAJSONDocument_WhenIDecodeIt_ThenShouldKeepTheMemberOrder(t *testing.T) {
+	value, err := Decode([]byte(`{"name": "Macbook Pro", "price": {"amount": "23000.00", "currency": "BRL"}, "id": 1, "tags": [true, null]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, Object{
+		{Key: "name", Value: "Macbook Pro"},
+		{Key: "price", Value: Object{{Key: "amount", Value: "23000.00"}, {Key: "currency", Value: "BRL"}}},
+		{Key: "id", Value: json.Number("1")},
+		{Key: "tags", Value: []interface{}{true, nil}},
+	}, value)
+
+	encoded, err := json.Marshal(value)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name":"Macbook Pro","price":{"amount":"23000.00","currency":"BRL"},"id":1,"tags":[true,null]}`, string(encoded))
+}
+
+func TestGivenTrailingData_WhenIDecodeIt_ThenShouldReceiveAnError(t *testing.T) {
+	_, err := Decode([]byte(`{} {}`))
+	assert.Error(t, err)
+
+	_, err = Decode([]byte(`{"name": }`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,48 @@
+package money
+
+import "strings"
+
+// DefaultCurrency is used when a price is sent without currency and to read
+// the price filters and stats of the catalog.
+var DefaultCurrency = "BRL"
+
+// currencies maps the ISO 4217 codes accepted by the API to the number of
+// digits of their minor unit.
+var currencies = map[string]int{
+	"ARS": 2,
+	"AUD": 2,
+	"BRL": 2,
+	"CAD": 2,
+	"CHF": 2,
+	"CLP": 0,
+	"CNY": 2,
+	"COP": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"JPY": 0,
+	"KRW": 0,
+	"KWD": 3,
+	"MXN": 2,
+	"PYG": 0,
+	"USD": 2,
+	"UYU": 2,
+}
+
+func IsValidCurrency(currency string) bool {
+	_, ok := currencies[currency]
+	return ok
+}
+
+// Exponent returns the digits of the minor unit of the currency.
+func Exponent(currency string) int {
+	return currencies[strings.ToUpper(currency)]
+}
+
+func factor(currency string) int64 {
+	result := int64(1)
+	for i := 0; i < Exponent(currency); i++ {
+		result *= 10
+	}
+
+	return result
+}
@@ -0,0 +1,174 @@
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidAmount   = errors.New("invalid amount")
+	ErrInvalidCurrency = errors.New("invalid currency")
+)
+
+// Money is an exact amount in the minor unit of an ISO 4217 currency, so 1199
+// BRL cents is R$ 11,99. It is stored as two columns and serialized as
+// {"amount": "11.99", "currency": "BRL"}.
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency" gorm:"size:3"`
+}
+
+func New(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+
+// Parse reads a decimal amount in the major unit, like "11.99", rejecting more
+// decimal places than the currency has.
+func Parse(amount string, currency string) (Money, error) {
+	currency = strings.ToUpper(currency)
+	if !IsValidCurrency(currency) {
+		return Money{}, ErrInvalidCurrency
+	}
+
+	negative := strings.HasPrefix(amount, "-")
+	amount = strings.TrimPrefix(amount, "-")
+
+	whole, fraction, _ := strings.Cut(amount, ".")
+	if whole == "" || !isDigits(whole) || !isDigits(fraction) || len(fraction) > Exponent(currency) {
+		return Money{}, ErrInvalidAmount
+	}
+	fraction += strings.Repeat("0", Exponent(currency)-len(fraction))
+
+	minor, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return Money{}, ErrInvalidAmount
+	}
+
+	if negative {
+		minor = -minor
+	}
+
+	return Money{Amount: minor, Currency: currency}, nil
+}
+
+// FromMajor converts a major unit value, like a query parameter, rounding it
+// to the minor unit of the currency.
+func FromMajor(value float64, currency string) Money {
+	return New(int64(math.Round(value*float64(factor(currency)))), currency)
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0 && m.Currency == ""
+}
+
+// String formats the amount in the major unit without currency symbol.
+func (m Money) String() string {
+	exponent := Exponent(m.Currency)
+	if exponent == 0 {
+		return strconv.FormatInt(m.Amount, 10)
+	}
+
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	digits := fmt.Sprintf("%0*d", exponent+1, amount)
+	return sign + digits[:len(digits)-exponent] + "." + digits[len(digits)-exponent:]
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount   string `json:"amount"`
+		Currency string `json:"currency"`
+	}{
+		Amount:   m.String(),
+		Currency: m.Currency,
+	})
+}
+
+// UnmarshalJSON accepts {"amount": "11.99", "currency": "BRL"}, the amount may
+// also be a JSON number and the currency defaults to DefaultCurrency. A bare
+// amount is accepted as well, for clients sending "price": 11.99.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	var value struct {
+		Amount   json.RawMessage `json:"amount"`
+		Currency string          `json:"currency"`
+	}
+
+	if len(data) > 0 && data[0] == '{' {
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+	} else {
+		value.Amount = data
+	}
+
+	amount, err := rawAmount(value.Amount)
+	if err != nil {
+		return err
+	}
+
+	currency := value.Currency
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+
+	parsed, err := Parse(amount, currency)
+	if err != nil {
+		return err
+	}
+
+	*m = parsed
+	return nil
+}
+
+// rawAmount returns the decimal text of a JSON string or number without going
+// through float64.
+func rawAmount(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 {
+		return "", ErrInvalidAmount
+	}
+
+	if raw[0] == '"' {
+		var amount string
+		if err := json.Unmarshal(raw, &amount); err != nil {
+			return "", ErrInvalidAmount
+		}
+		return amount, nil
+	}
+
+	var number json.Number
+	if err := json.Unmarshal(raw, &number); err != nil {
+		return "", ErrInvalidAmount
+	}
+
+	amount := number.String()
+	if strings.ContainsAny(amount, "eE") {
+		return "", ErrInvalidAmount
+	}
+
+	return amount, nil
+}
+
+func isDigits(value string) bool {
+	for _, char := range value {
+		if char < '0' || char > '9' {
+			return false
+		}
+	}
+
+	return true
+}
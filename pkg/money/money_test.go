@@ -0,0 +1,56 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGivenADecimalAmount_WhenIParse_ThenShouldReceiveTheMinorUnits(t *testing.T) {
+	price, err := Parse("0.3", "brl")
+	assert.NoError(t, err)
+	assert.Equal(t, New(30, "BRL"), price)
+
+	price, err = Parse("1500", "JPY")
+	assert.NoError(t, err)
+	assert.Equal(t, New(1500, "JPY"), price)
+}
+
+func TestGivenTooManyDecimals_WhenIParse_ThenShouldReceiveAnError(t *testing.T) {
+	_, err := Parse("10.999", "BRL")
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+}
+
+func TestGivenAnUnknownCurrency_WhenIParse_ThenShouldReceiveAnError(t *testing.T) {
+	_, err := Parse("10.00", "XYZ")
+	assert.ErrorIs(t, err, ErrInvalidCurrency)
+}
+
+func TestGivenMoney_WhenIFormat_ThenShouldReceiveTheMajorUnitAmount(t *testing.T) {
+	assert.Equal(t, "1199.00", New(119900, "BRL").String())
+	assert.Equal(t, "0.05", New(5, "USD").String())
+	assert.Equal(t, "-1.50", New(-150, "EUR").String())
+	assert.Equal(t, "0.125", New(125, "KWD").String())
+}
+
+func TestGivenMoney_WhenIMarshalJSON_ThenShouldReceiveAStringAmount(t *testing.T) {
+	data, err := json.Marshal(New(30, "BRL"))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"amount": "0.30", "currency": "BRL"}`, string(data))
+}
+
+func TestGivenJSONAmounts_WhenIUnmarshalJSON_ThenShouldNotLosePrecision(t *testing.T) {
+	var price Money
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"amount": "0.30", "currency": "USD"}`), &price))
+	assert.Equal(t, New(30, "USD"), price)
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"amount": 1199.99}`), &price))
+	assert.Equal(t, New(119999, DefaultCurrency), price)
+
+	assert.NoError(t, json.Unmarshal([]byte(`1199.0`), &price))
+	assert.Equal(t, New(119900, DefaultCurrency), price)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"amount": 1e3}`), &price))
+}
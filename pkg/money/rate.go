@@ -0,0 +1,58 @@
+package money
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+)
+
+var ErrInvalidRate = errors.New("invalid rate")
+
+// rateDecimals is the precision kept when a rate is formatted, it matches
+// the scale of the exchange rate column.
+const rateDecimals = 12
+
+// ParseRate reads a positive decimal exchange rate, like "5.0731".
+func ParseRate(rate string) (*big.Rat, error) {
+	if strings.ContainsAny(rate, "eE/") {
+		return nil, ErrInvalidRate
+	}
+
+	value, ok := new(big.Rat).SetString(strings.TrimSpace(rate))
+	if !ok || value.Sign() <= 0 {
+		return nil, ErrInvalidRate
+	}
+
+	return value, nil
+}
+
+// FormatRate writes the rate as a decimal without trailing zeros.
+func FormatRate(rate *big.Rat) string {
+	value := rate.FloatString(rateDecimals)
+	value = strings.TrimRight(value, "0")
+
+	return strings.TrimSuffix(value, ".")
+}
+
+// Convert multiplies the amount by the rate into the minor unit of the other
+// currency, rounding half away from zero.
+func (m Money) Convert(currency string, rate *big.Rat) Money {
+	currency = strings.ToUpper(currency)
+
+	value := new(big.Rat).SetInt64(m.Amount)
+	value.Mul(value, rate)
+	value.Mul(value, new(big.Rat).SetInt64(factor(currency)))
+	value.Quo(value, new(big.Rat).SetInt64(factor(m.Currency)))
+
+	quotient, remainder := new(big.Int).QuoRem(value.Num(), value.Denom(), new(big.Int))
+	if remainder.Sign() != 0 {
+		// the denominator is positive, the remainder has the sign of the amount
+		twice := new(big.Int).Abs(remainder)
+		twice.Lsh(twice, 1)
+		if twice.Cmp(value.Denom()) >= 0 {
+			quotient.Add(quotient, big.NewInt(int64(remainder.Sign())))
+		}
+	}
+
+	return New(quotient.Int64(), currency)
+}
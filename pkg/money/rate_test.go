@@ -0,0 +1,38 @@
+package money
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGivenADecimalRate_WhenIParseRate_ThenShouldKeepItExact(t *testing.T) {
+	rate, err := ParseRate("5.0731")
+	assert.NoError(t, err)
+	assert.Equal(t, "5.0731", FormatRate(rate))
+
+	rate, err = ParseRate("2.000000000000")
+	assert.NoError(t, err)
+	assert.Equal(t, "2", FormatRate(rate))
+}
+
+func TestGivenAnInvalidRate_WhenIParseRate_ThenShouldReceiveAnError(t *testing.T) {
+	for _, rate := range []string{"", "0", "-1.5", "1e3", "1/3", "abc"} {
+		_, err := ParseRate(rate)
+		assert.ErrorIs(t, err, ErrInvalidRate, rate)
+	}
+}
+
+func TestGivenMoney_WhenIConvert_ThenShouldRoundToTheMinorUnitOfTheOtherCurrency(t *testing.T) {
+	rate, _ := ParseRate("0.1971")
+	assert.Equal(t, New(1971, "USD"), New(10000, "BRL").Convert("USD", rate))
+	assert.Equal(t, New(20, "USD"), New(99, "BRL").Convert("usd", rate))
+
+	rate, _ = ParseRate("150.255")
+	assert.Equal(t, New(1503, "JPY"), New(1000, "USD").Convert("JPY", rate))
+	assert.Equal(t, New(-1503, "JPY"), New(-1000, "USD").Convert("JPY", rate))
+
+	inverse := new(big.Rat).Inv(big.NewRat(5, 1))
+	assert.Equal(t, New(200, "USD"), New(1000, "BRL").Convert("USD", inverse))
+}
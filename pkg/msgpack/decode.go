@@ -0,0 +1,239 @@
+package msgpack
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/waldrey/eulabs/pkg/jsontree"
+)
+
+var ErrInvalidData = errors.New("msgpack: invalid data")
+
+// maxDepth bounds the nesting of arrays and maps of a decoded document.
+const maxDepth = 100
+
+// Unmarshal decodes a MessagePack document in jsontree values, so it can be
+// written as JSON: numbers become json.Number, binaries base64 strings and
+// map keys must be strings. Extension types are not supported.
+func Unmarshal(data []byte) (interface{}, error) {
+	d := decoder{data: data}
+
+	value, err := d.decode(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.offset != len(d.data) {
+		return nil, fmt.Errorf("%w: unexpected data after the document", ErrInvalidData)
+	}
+
+	return value, nil
+}
+
+type decoder struct {
+	data   []byte
+	offset int
+}
+
+func (d *decoder) decode(depth int) (interface{}, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("%w: nested too deep", ErrInvalidData)
+	}
+
+	format, err := d.read(1)
+	if err != nil {
+		return nil, err
+	}
+
+	switch b := format[0]; {
+	case b <= 0x7f:
+		return json.Number(strconv.Itoa(int(b))), nil
+	case b >= 0xe0:
+		return json.Number(strconv.Itoa(int(int8(b)))), nil
+	case b&0xe0 == 0xa0:
+		return d.decodeString(int(b & 0x1f))
+	case b&0xf0 == 0x90:
+		return d.decodeArray(int(b&0x0f), depth)
+	case b&0xf0 == 0x80:
+		return d.decodeMap(int(b&0x0f), depth)
+	}
+
+	switch format[0] {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.length(format[0] - 0xc4)
+		if err != nil {
+			return nil, err
+		}
+
+		bin, err := d.read(n)
+		if err != nil {
+			return nil, err
+		}
+		return base64.StdEncoding.EncodeToString(bin), nil
+	case 0xca:
+		bits, err := d.read(4)
+		if err != nil {
+			return nil, err
+		}
+		return float(float64(math.Float32frombits(binary.BigEndian.Uint32(bits))))
+	case 0xcb:
+		bits, err := d.read(8)
+		if err != nil {
+			return nil, err
+		}
+		return float(math.Float64frombits(binary.BigEndian.Uint64(bits)))
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		u, err := d.uint(1 << (format[0] - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		return json.Number(strconv.FormatUint(u, 10)), nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (format[0] - 0xd0)
+		u, err := d.uint(size)
+		if err != nil {
+			return nil, err
+		}
+
+		// sign extends the value from its size
+		shift := 64 - 8*size
+		return json.Number(strconv.FormatInt(int64(u<<shift)>>shift, 10)), nil
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.length(format[0] - 0xd9)
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeString(n)
+	case 0xdc, 0xdd:
+		n, err := d.length(format[0] - 0xdc + 1)
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeArray(n, depth)
+	case 0xde, 0xdf:
+		n, err := d.length(format[0] - 0xde + 1)
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeMap(n, depth)
+	}
+
+	return nil, fmt.Errorf("%w: format 0x%02x", ErrUnsupportedType, format[0])
+}
+
+func (d *decoder) decodeString(n int) (interface{}, error) {
+	s, err := d.read(n)
+	if err != nil {
+		return nil, err
+	}
+
+	if !utf8.Valid(s) {
+		return nil, fmt.Errorf("%w: string is not UTF-8", ErrInvalidData)
+	}
+
+	return string(s), nil
+}
+
+func (d *decoder) decodeArray(n int, depth int) (interface{}, error) {
+	// every element takes at least a byte, so n is bounded by the data
+	if n > len(d.data)-d.offset {
+		return nil, fmt.Errorf("%w: unexpected end of data", ErrInvalidData)
+	}
+
+	array := make([]interface{}, 0, n)
+	for i := 0; i < n; i++ {
+		value, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		array = append(array, value)
+	}
+
+	return array, nil
+}
+
+func (d *decoder) decodeMap(n int, depth int) (interface{}, error) {
+	if 2*n > len(d.data)-d.offset {
+		return nil, fmt.Errorf("%w: unexpected end of data", ErrInvalidData)
+	}
+
+	object := make(jsontree.Object, 0, n)
+	for i := 0; i < n; i++ {
+		key, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		name, ok := key.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: map key is not a string", ErrUnsupportedType)
+		}
+
+		value, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		object = append(object, jsontree.Member{Key: name, Value: value})
+	}
+
+	return object, nil
+}
+
+// length reads the 8, 16 or 32 bits length selected by width 0, 1 or 2.
+func (d *decoder) length(width byte) (int, error) {
+	u, err := d.uint(1 << width)
+	if err != nil {
+		return 0, err
+	}
+
+	if u > uint64(len(d.data)) {
+		return 0, fmt.Errorf("%w: unexpected end of data", ErrInvalidData)
+	}
+
+	return int(u), nil
+}
+
+func (d *decoder) uint(size int) (uint64, error) {
+	b, err := d.read(size)
+	if err != nil {
+		return 0, err
+	}
+
+	var u uint64
+	for _, c := range b {
+		u = u<<8 | uint64(c)
+	}
+
+	return u, nil
+}
+
+func (d *decoder) read(n int) ([]byte, error) {
+	if n > len(d.data)-d.offset {
+		return nil, fmt.Errorf("%w: unexpected end of data", ErrInvalidData)
+	}
+
+	b := d.data[d.offset : d.offset+n]
+	d.offset += n
+
+	return b, nil
+}
+
+func float(f float64) (interface{}, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, fmt.Errorf("%w: %v has no JSON representation", ErrUnsupportedType, f)
+	}
+
+	return json.Number(strconv.FormatFloat(f, 'g', -1, 64)), nil
+}
@@ -0,0 +1,156 @@
+// Package msgpack converts between MessagePack and the generic values of
+// jsontree, so anything rendered as JSON can be sent as MessagePack too.
+package msgpack
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/waldrey/eulabs/pkg/jsontree"
+)
+
+var ErrUnsupportedType = errors.New("msgpack: unsupported type")
+
+// Marshal encodes a jsontree value, json.Number is written as an integer when
+// it has no fraction and fits 64 bits, otherwise as a float64.
+func Marshal(v interface{}) ([]byte, error) {
+	var e encoder
+	if err := e.encode(v); err != nil {
+		return nil, err
+	}
+
+	return e.buffer, nil
+}
+
+type encoder struct {
+	buffer []byte
+}
+
+func (e *encoder) encode(v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		e.buffer = append(e.buffer, 0xc0)
+	case bool:
+		if v {
+			e.buffer = append(e.buffer, 0xc3)
+		} else {
+			e.buffer = append(e.buffer, 0xc2)
+		}
+	case string:
+		e.encodeString(v)
+	case json.Number:
+		return e.encodeNumber(v)
+	case int64:
+		e.encodeInt(v)
+	case uint64:
+		e.encodeUint(v)
+	case float64:
+		e.encodeFloat(v)
+	case []interface{}:
+		e.encodeLength(len(v), 0x90, 0xdc)
+		for _, item := range v {
+			if err := e.encode(item); err != nil {
+				return err
+			}
+		}
+	case jsontree.Object:
+		e.encodeLength(len(v), 0x80, 0xde)
+		for _, member := range v {
+			e.encodeString(member.Key)
+			if err := e.encode(member.Value); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("%w: %T", ErrUnsupportedType, v)
+	}
+
+	return nil
+}
+
+func (e *encoder) encodeNumber(n json.Number) error {
+	if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+		e.encodeInt(i)
+		return nil
+	}
+
+	if u, err := strconv.ParseUint(string(n), 10, 64); err == nil {
+		e.encodeUint(u)
+		return nil
+	}
+
+	f, err := strconv.ParseFloat(string(n), 64)
+	if err != nil {
+		return fmt.Errorf("msgpack: invalid number %q", n)
+	}
+	e.encodeFloat(f)
+
+	return nil
+}
+
+func (e *encoder) encodeInt(i int64) {
+	switch {
+	case i >= 0:
+		e.encodeUint(uint64(i))
+	case i >= -32:
+		e.buffer = append(e.buffer, byte(i))
+	case i >= math.MinInt8:
+		e.buffer = append(e.buffer, 0xd0, byte(i))
+	case i >= math.MinInt16:
+		e.buffer = binary.BigEndian.AppendUint16(append(e.buffer, 0xd1), uint16(i))
+	case i >= math.MinInt32:
+		e.buffer = binary.BigEndian.AppendUint32(append(e.buffer, 0xd2), uint32(i))
+	default:
+		e.buffer = binary.BigEndian.AppendUint64(append(e.buffer, 0xd3), uint64(i))
+	}
+}
+
+func (e *encoder) encodeUint(u uint64) {
+	switch {
+	case u <= 0x7f:
+		e.buffer = append(e.buffer, byte(u))
+	case u <= math.MaxUint8:
+		e.buffer = append(e.buffer, 0xcc, byte(u))
+	case u <= math.MaxUint16:
+		e.buffer = binary.BigEndian.AppendUint16(append(e.buffer, 0xcd), uint16(u))
+	case u <= math.MaxUint32:
+		e.buffer = binary.BigEndian.AppendUint32(append(e.buffer, 0xce), uint32(u))
+	default:
+		e.buffer = binary.BigEndian.AppendUint64(append(e.buffer, 0xcf), u)
+	}
+}
+
+func (e *encoder) encodeFloat(f float64) {
+	e.buffer = binary.BigEndian.AppendUint64(append(e.buffer, 0xcb), math.Float64bits(f))
+}
+
+func (e *encoder) encodeString(s string) {
+	switch n := len(s); {
+	case n <= 31:
+		e.buffer = append(e.buffer, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		e.buffer = append(e.buffer, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		e.buffer = binary.BigEndian.AppendUint16(append(e.buffer, 0xda), uint16(n))
+	default:
+		e.buffer = binary.BigEndian.AppendUint32(append(e.buffer, 0xdb), uint32(n))
+	}
+	e.buffer = append(e.buffer, s...)
+}
+
+// encodeLength writes the header of an array or map, fix is the format of up
+// to 15 elements and wide the 16 bits one, followed by the 32 bits one.
+func (e *encoder) encodeLength(n int, fix byte, wide byte) {
+	switch {
+	case n <= 15:
+		e.buffer = append(e.buffer, fix|byte(n))
+	case n <= math.MaxUint16:
+		e.buffer = binary.BigEndian.AppendUint16(append(e.buffer, wide), uint16(n))
+	default:
+		e.buffer = binary.BigEndian.AppendUint32(append(e.buffer, wide+1), uint32(n))
+	}
+}
@@ -0,0 +1,67 @@
+package msgpack
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/waldrey/eulabs/pkg/jsontree"
+)
+
+func TestGivenAJSONDocument_WhenIMarshalIt_ThenShouldReceiveTheMessagePackBytes(t *testing.T) {
+	value, _ := jsontree.Decode([]byte(`{"id": 1, "name": "Kindle", "tags": [true, null], "rate": 0.5, "stock": -200}`))
+
+	data, err := Marshal(value)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{
+		0x85,
+		0xa2, 'i', 'd', 0x01,
+		0xa4, 'n', 'a', 'm', 'e', 0xa6, 'K', 'i', 'n', 'd', 'l', 'e',
+		0xa4, 't', 'a', 'g', 's', 0x92, 0xc3, 0xc0,
+		0xa4, 'r', 'a', 't', 'e', 0xcb, 0x3f, 0xe0, 0, 0, 0, 0, 0, 0,
+		0xa5, 's', 't', 'o', 'c', 'k', 0xd1, 0xff, 0x38,
+	}, data)
+}
+
+func TestGivenEveryFormat_WhenIRoundTripIt_ThenShouldReceiveTheSameDocument(t *testing.T) {
+	document := `{"small":[0,127,128,255,256,65535,65536,4294967296,18446744073709551615],` +
+		`"negative":[-1,-32,-33,-128,-129,-32768,-32769,-2147483648,-2147483649],` +
+		`"string":"` + strings.Repeat("a", 40) + `","long":"` + strings.Repeat("b", 300) + `",` +
+		`"float":1.25,"list":[` + strings.Repeat(`{},`, 20) + `{}]}`
+	value, err := jsontree.Decode([]byte(document))
+	assert.NoError(t, err)
+
+	data, err := Marshal(value)
+	assert.NoError(t, err)
+
+	decoded, err := Unmarshal(data)
+	assert.NoError(t, err)
+
+	encoded, err := json.Marshal(decoded)
+	assert.NoError(t, err)
+	assert.JSONEq(t, document, string(encoded))
+}
+
+func TestGivenInvalidData_WhenIUnmarshalIt_ThenShouldReceiveAnError(t *testing.T) {
+	_, err := Unmarshal([]byte{0x92, 0x01})
+	assert.ErrorIs(t, err, ErrInvalidData)
+
+	_, err = Unmarshal([]byte{0xdd, 0xff, 0xff, 0xff, 0xff})
+	assert.ErrorIs(t, err, ErrInvalidData)
+
+	_, err = Unmarshal([]byte{0x81, 0x01, 0x01})
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+
+	_, err = Unmarshal([]byte{0xd4, 0x01, 0x01})
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+
+	_, err = Unmarshal([]byte{0xc0, 0xc0})
+	assert.ErrorIs(t, err, ErrInvalidData)
+}
+
+func TestGivenBinaryData_WhenIUnmarshalIt_ThenShouldReceiveBase64(t *testing.T) {
+	value, err := Unmarshal([]byte{0xc4, 0x03, 'a', 'b', 'c'})
+	assert.NoError(t, err)
+	assert.Equal(t, "YWJj", value)
+}
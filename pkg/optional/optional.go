@@ -0,0 +1,53 @@
+package optional
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Field is a JSON field that remembers whether it was sent, so a partial
+// update can tell an absent field from an explicit null or a zero value.
+type Field[T any] struct {
+	Set   bool
+	Null  bool
+	Value T
+}
+
+// Of returns a field sent with the value.
+func Of[T any](value T) Field[T] {
+	return Field[T]{Set: true, Value: value}
+}
+
+// Null returns a field sent as null.
+func Null[T any]() Field[T] {
+	return Field[T]{Set: true, Null: true}
+}
+
+// Present is true when the field was sent with a value other than null.
+func (f Field[T]) Present() bool {
+	return f.Set && !f.Null
+}
+
+// UnmarshalJSON is only called for fields present in the document, absent
+// fields keep Set false.
+func (f *Field[T]) UnmarshalJSON(data []byte) error {
+	f.Set = true
+
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		var zero T
+		f.Null = true
+		f.Value = zero
+		return nil
+	}
+
+	f.Null = false
+	return json.Unmarshal(data, &f.Value)
+}
+
+func (f Field[T]) MarshalJSON() ([]byte, error) {
+	if !f.Present() {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(f.Value)
+}
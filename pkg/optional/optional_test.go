@@ -0,0 +1,43 @@
+package optional
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type document struct {
+	Name        Field[string] `json:"name"`
+	Description Field[string] `json:"description"`
+	Stock       Field[int]    `json:"stock"`
+}
+
+func TestGivenAPartialDocument_WhenIUnmarshalJSON_ThenShouldTellAbsentFromNullAndZero(t *testing.T) {
+	var value document
+	err := json.Unmarshal([]byte(`{"name": "Macbook Pro", "description": null, "stock": 0}`), &value)
+	assert.NoError(t, err)
+
+	assert.Equal(t, Of("Macbook Pro"), value.Name)
+	assert.Equal(t, Null[string](), value.Description)
+	assert.Equal(t, Of(0), value.Stock)
+	assert.True(t, value.Stock.Present())
+
+	value = document{}
+	err = json.Unmarshal([]byte(`{}`), &value)
+	assert.NoError(t, err)
+	assert.False(t, value.Name.Set)
+	assert.False(t, value.Name.Present())
+}
+
+func TestGivenAWrongType_WhenIUnmarshalJSON_ThenShouldReceiveAnError(t *testing.T) {
+	var value document
+	err := json.Unmarshal([]byte(`{"stock": "ten"}`), &value)
+	assert.Error(t, err)
+}
+
+func TestGivenFields_WhenIMarshalJSON_ThenShouldWriteTheValueOrNull(t *testing.T) {
+	data, err := json.Marshal(document{Name: Of("Kindle"), Description: Null[string]()})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"name": "Kindle", "description": null, "stock": null}`, string(data))
+}
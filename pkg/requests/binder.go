@@ -0,0 +1,225 @@
+package requests
+
+import (
+	"encoding"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/waldrey/eulabs/pkg/jsontree"
+	"github.com/waldrey/eulabs/pkg/msgpack"
+)
+
+// Binder decodes XML and MessagePack bodies through their JSON equivalent,
+// so the json tags and decoders of the requests apply to every media type
+// rendered by Render. Other bodies are bound by the echo.DefaultBinder.
+type Binder struct {
+	echo.DefaultBinder
+}
+
+// bodyDecoders read a body in jsontree values for a target type.
+var bodyDecoders = map[string]func(body []byte, target reflect.Type) (interface{}, error){
+	echo.MIMEApplicationXML:   decodeXMLBody,
+	echo.MIMETextXML:          decodeXMLBody,
+	MIMEApplicationMsgpack:    decodeMsgpackBody,
+	MIMEApplicationXMsgpack:   decodeMsgpackBody,
+	MIMEApplicationVndMsgpack: decodeMsgpackBody,
+}
+
+func (b *Binder) Bind(i interface{}, c echo.Context) error {
+	request := c.Request()
+	mediaType, _, _ := mime.ParseMediaType(request.Header.Get(echo.HeaderContentType))
+	decode, ok := bodyDecoders[mediaType]
+	if !ok {
+		return b.DefaultBinder.Bind(i, c)
+	}
+
+	if err := b.BindPathParams(c, i); err != nil {
+		return err
+	}
+
+	method := request.Method
+	if method == http.MethodGet || method == http.MethodDelete || method == http.MethodHead {
+		if err := b.BindQueryParams(c, i); err != nil {
+			return err
+		}
+	}
+
+	if request.ContentLength == 0 {
+		return nil
+	}
+
+	body, err := io.ReadAll(request.Body)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	if len(body) == 0 {
+		return nil
+	}
+
+	value, err := decode(body, reflect.TypeOf(i))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	document, err := json.Marshal(value)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	if err := json.Unmarshal(document, i); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	return nil
+}
+
+func decodeXMLBody(body []byte, target reflect.Type) (interface{}, error) {
+	value, err := decodeXML(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return coerce(value, target), nil
+}
+
+func decodeMsgpackBody(body []byte, _ reflect.Type) (interface{}, error) {
+	return msgpack.Unmarshal(body)
+}
+
+var (
+	jsonUnmarshaler = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// coerce gives the XML scalars, all read as strings, the JSON type expected by
+// the target: numbers and booleans for those fields, arrays for empty
+// elements of slices. Types decoding themselves, like money.Money, get the
+// values unchanged.
+func coerce(value interface{}, target reflect.Type) interface{} {
+	for target.Kind() == reflect.Pointer {
+		target = target.Elem()
+	}
+
+	if value == nil || reflect.PointerTo(target).Implements(jsonUnmarshaler) || reflect.PointerTo(target).Implements(textUnmarshaler) {
+		return value
+	}
+
+	switch target.Kind() {
+	case reflect.Struct:
+		object, ok := value.(jsontree.Object)
+		if !ok {
+			if value == "" {
+				return jsontree.Object{}
+			}
+			return value
+		}
+
+		coerced := make(jsontree.Object, 0, len(object))
+		for _, member := range object {
+			if field, ok := jsonField(target, member.Key); ok {
+				member.Value = coerce(member.Value, field.Type)
+			}
+			coerced = append(coerced, member)
+		}
+		return coerced
+
+	case reflect.Map:
+		object, ok := value.(jsontree.Object)
+		if !ok {
+			if value == "" {
+				return jsontree.Object{}
+			}
+			return value
+		}
+
+		coerced := make(jsontree.Object, 0, len(object))
+		for _, member := range object {
+			member.Value = coerce(member.Value, target.Elem())
+			coerced = append(coerced, member)
+		}
+		return coerced
+
+	case reflect.Slice, reflect.Array:
+		if target.Elem().Kind() == reflect.Uint8 {
+			return value
+		}
+
+		items, ok := value.([]interface{})
+		if !ok {
+			if value == "" {
+				return []interface{}{}
+			}
+			items = []interface{}{value}
+		}
+
+		coerced := make([]interface{}, 0, len(items))
+		for _, item := range items {
+			coerced = append(coerced, coerce(item, target.Elem()))
+		}
+		return coerced
+
+	case reflect.Bool:
+		if text, ok := value.(string); ok {
+			if b, err := strconv.ParseBool(strings.TrimSpace(text)); err == nil {
+				return b
+			}
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if text, ok := value.(string); ok {
+			number := json.Number(strings.TrimSpace(text))
+			if _, err := number.Float64(); err == nil {
+				return number
+			}
+		}
+	}
+
+	return value
+}
+
+// jsonField finds the field decoded from the key like encoding/json, embedded
+// structs included and ignoring the case.
+func jsonField(target reflect.Type, key string) (reflect.StructField, bool) {
+	var folded *reflect.StructField
+	for _, field := range reflect.VisibleFields(target) {
+		if !field.IsExported() {
+			continue
+		}
+
+		tag, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if tag == "-" {
+			continue
+		}
+
+		name := tag
+		if name == "" {
+			if field.Anonymous && field.Type.Kind() == reflect.Struct {
+				continue
+			}
+			name = field.Name
+		}
+
+		if name == key {
+			return field, true
+		}
+
+		if folded == nil && strings.EqualFold(name, key) {
+			folded = &field
+		}
+	}
+
+	if folded != nil {
+		return *folded, true
+	}
+
+	return reflect.StructField{}, false
+}
@@ -0,0 +1,79 @@
+package requests
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/waldrey/eulabs/pkg/jsontree"
+	"github.com/waldrey/eulabs/pkg/money"
+	"github.com/waldrey/eulabs/pkg/msgpack"
+	"github.com/waldrey/eulabs/pkg/optional"
+)
+
+type boundOperation struct {
+	Op      string                 `json:"op"`
+	ID      uint                   `json:"id"`
+	Atomic  bool                   `json:"atomic"`
+	Name    optional.Field[string] `json:"name"`
+	Price   money.Money            `json:"price"`
+	Tags    []string               `json:"tags"`
+	Ignored string                 `json:"-"`
+}
+
+type boundRequest struct {
+	Operations []boundOperation `json:"operations"`
+}
+
+func bind(contentType string, body []byte, target interface{}) error {
+	request := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	request.Header.Set(echo.HeaderContentType, contentType)
+
+	return (&Binder{}).Bind(target, echo.New().NewContext(request, httptest.NewRecorder()))
+}
+
+func TestGivenAnXMLBody_WhenIBindIt_ThenShouldDecodeItLikeTheJSONOne(t *testing.T) {
+	var request boundRequest
+	err := bind("application/xml; charset=utf-8", []byte(`<?xml version="1.0"?>
+		<request xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+			<operations>
+				<item><op>update</op><id>7</id><atomic>true</atomic><name xsi:nil="true"/>
+					<price><amount>23000.00</amount><currency>BRL</currency></price><tags><item>apple</item></tags></item>
+				<item><op>create</op><name>Kindle &amp; case</name><price>99.99</price><tags/></item>
+			</operations>
+		</request>`), &request)
+
+	assert.NoError(t, err)
+	assert.Equal(t, boundRequest{Operations: []boundOperation{
+		{Op: "update", ID: 7, Atomic: true, Name: optional.Null[string](), Price: money.New(2300000, "BRL"), Tags: []string{"apple"}},
+		{Op: "create", Name: optional.Of("Kindle & case"), Price: money.New(9999, money.DefaultCurrency), Tags: []string{}},
+	}}, request)
+}
+
+func TestGivenAMessagePackBody_WhenIBindIt_ThenShouldDecodeItLikeTheJSONOne(t *testing.T) {
+	body, _ := msgpack.Marshal(jsontree.Object{
+		{Key: "op", Value: "update"},
+		{Key: "id", Value: int64(7)},
+		{Key: "price", Value: jsontree.Object{{Key: "amount", Value: "10.50"}, {Key: "currency", Value: "USD"}}},
+	})
+
+	var operation boundOperation
+	assert.NoError(t, bind(MIMEApplicationMsgpack, body, &operation))
+	assert.Equal(t, boundOperation{Op: "update", ID: 7, Price: money.New(1050, "USD")}, operation)
+}
+
+func TestGivenAnInvalidBody_WhenIBindIt_ThenShouldReceiveABadRequest(t *testing.T) {
+	var operation boundOperation
+
+	err := bind(echo.MIMETextXML, []byte(`<request><op>update</request>`), &operation)
+	assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code)
+
+	err = bind(echo.MIMETextXML, []byte(`<request><id>seven</id></request>`), &operation)
+	assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code)
+
+	err = bind(MIMEApplicationMsgpack, []byte{0x92}, &operation)
+	assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code)
+}
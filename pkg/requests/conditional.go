@@ -0,0 +1,41 @@
+package requests
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// NotModified evaluates If-None-Match and, only when it is absent,
+// If-Modified-Since of a GET against the current validators of the resource,
+// as in RFC 9110. A zero lastModified never satisfies If-Modified-Since.
+func NotModified(header http.Header, etag string, lastModified time.Time) bool {
+	if ifNoneMatch := header.Get("If-None-Match"); ifNoneMatch != "" {
+		if strings.TrimSpace(ifNoneMatch) == "*" {
+			return true
+		}
+
+		// If-None-Match uses the weak comparison
+		for _, tag := range strings.Split(ifNoneMatch, ",") {
+			if etag != "" && opaqueTag(tag) == opaqueTag(etag) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if lastModified.IsZero() {
+		return false
+	}
+
+	since, err := http.ParseTime(header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+func opaqueTag(tag string) string {
+	return strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+}
@@ -0,0 +1,39 @@
+package requests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGivenAMatchingIfNoneMatch_WhenICallNotModified_ThenShouldReceiveTrue(t *testing.T) {
+	header := http.Header{}
+	header.Set("If-None-Match", `"2", W/"3"`)
+
+	assert.True(t, NotModified(header, `"3"`, time.Time{}))
+	assert.False(t, NotModified(header, `"4"`, time.Time{}))
+
+	header.Set("If-None-Match", "*")
+	assert.True(t, NotModified(header, `"4"`, time.Time{}))
+}
+
+func TestGivenIfModifiedSince_WhenICallNotModified_ThenShouldCompareBySecond(t *testing.T) {
+	lastModified := time.Date(2024, 5, 2, 12, 0, 0, 500000000, time.UTC)
+
+	header := http.Header{}
+	header.Set("If-Modified-Since", lastModified.Format(http.TimeFormat))
+	assert.True(t, NotModified(header, `"3"`, lastModified))
+	assert.False(t, NotModified(header, `"3"`, lastModified.Add(time.Second)))
+	assert.False(t, NotModified(header, `"3"`, time.Time{}))
+}
+
+func TestGivenBothConditions_WhenICallNotModified_ThenShouldIgnoreIfModifiedSince(t *testing.T) {
+	lastModified := time.Date(2024, 5, 2, 12, 0, 0, 0, time.UTC)
+
+	header := http.Header{}
+	header.Set("If-None-Match", `"2"`)
+	header.Set("If-Modified-Since", lastModified.Format(http.TimeFormat))
+	assert.False(t, NotModified(header, `"3"`, lastModified))
+}
@@ -0,0 +1,104 @@
+package requests
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+
+	"github.com/waldrey/eulabs/pkg/jsontree"
+)
+
+// renderCSV writes the data of a response listing objects, one row each.
+// Nested objects are flattened in columns joined by dots, like price.amount,
+// and arrays are written as JSON. The meta of the response is not sent.
+func renderCSV(value interface{}) ([]byte, bool, error) {
+	object, ok := value.(jsontree.Object)
+	if !ok {
+		return nil, false, nil
+	}
+
+	data, _ := object.Get("data")
+	items, ok := data.([]interface{})
+	if !ok {
+		return nil, false, nil
+	}
+
+	var columns []string
+	index := map[string]int{}
+	rows := make([]map[string]string, 0, len(items))
+	for _, item := range items {
+		object, ok := item.(jsontree.Object)
+		if !ok {
+			return nil, false, nil
+		}
+
+		row := map[string]string{}
+		if err := flatten(row, "", object); err != nil {
+			return nil, false, err
+		}
+
+		for _, column := range flattenedColumns("", object) {
+			if _, ok := index[column]; !ok {
+				index[column] = len(columns)
+				columns = append(columns, column)
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	if len(columns) > 0 {
+		if err := writer.Write(columns); err != nil {
+			return nil, false, err
+		}
+	}
+
+	record := make([]string, len(columns))
+	for _, row := range rows {
+		for i, column := range columns {
+			record[i] = row[column]
+		}
+
+		if err := writer.Write(record); err != nil {
+			return nil, false, err
+		}
+	}
+	writer.Flush()
+
+	return buffer.Bytes(), true, writer.Error()
+}
+
+func flatten(row map[string]string, prefix string, object jsontree.Object) error {
+	for _, member := range object {
+		switch value := member.Value.(type) {
+		case jsontree.Object:
+			if err := flatten(row, prefix+member.Key+".", value); err != nil {
+				return err
+			}
+		case []interface{}:
+			text, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			row[prefix+member.Key] = string(text)
+		default:
+			row[prefix+member.Key] = scalarText(value)
+		}
+	}
+
+	return nil
+}
+
+func flattenedColumns(prefix string, object jsontree.Object) []string {
+	var columns []string
+	for _, member := range object {
+		if value, ok := member.Value.(jsontree.Object); ok {
+			columns = append(columns, flattenedColumns(prefix+member.Key+".", value)...)
+			continue
+		}
+		columns = append(columns, prefix+member.Key)
+	}
+
+	return columns
+}
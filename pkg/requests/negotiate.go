@@ -0,0 +1,232 @@
+package requests
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/waldrey/eulabs/pkg/jsontree"
+	"github.com/waldrey/eulabs/pkg/msgpack"
+)
+
+const (
+	MIMEApplicationMsgpack    = echo.MIMEApplicationMsgpack
+	MIMEApplicationXMsgpack   = "application/x-msgpack"
+	MIMEApplicationVndMsgpack = "application/vnd.msgpack"
+	MIMETextCSV               = "text/csv"
+)
+
+// format renders a response in a media type, render returns false when the
+// response has no representation in it.
+type format struct {
+	mediaType   string
+	contentType string
+	render      func(value interface{}) ([]byte, bool, error)
+}
+
+// formats are listed in the server preference, JSON being the default.
+var formats = []format{
+	{echo.MIMEApplicationJSON, echo.MIMEApplicationJSON, renderJSON},
+	{echo.MIMEApplicationXML, echo.MIMEApplicationXMLCharsetUTF8, renderXML},
+	{echo.MIMETextXML, echo.MIMETextXMLCharsetUTF8, renderXML},
+	{MIMEApplicationMsgpack, MIMEApplicationMsgpack, renderMsgpack},
+	{MIMEApplicationXMsgpack, MIMEApplicationXMsgpack, renderMsgpack},
+	{MIMEApplicationVndMsgpack, MIMEApplicationVndMsgpack, renderMsgpack},
+	{MIMETextCSV, MIMETextCSV + "; charset=UTF-8", renderCSV},
+}
+
+// Render writes the response in the media type preferred by the Accept header
+// of the request among JSON, XML, MessagePack and CSV, the latter only for
+// responses listing objects. A response with no representation acceptable
+// to the client fails with a 406 problem, errors go through RenderProblem.
+func Render(c echo.Context, status int, response interface{}) error {
+	AddVary(c.Response().Header(), "Accept")
+
+	acceptable := acceptableFormats(c.Request().Header.Get(echo.HeaderAccept))
+	if len(acceptable) > 0 && acceptable[0].mediaType == echo.MIMEApplicationJSON {
+		return c.JSON(status, response)
+	}
+
+	value, err := jsontree.From(response)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range acceptable {
+		body, ok, err := f.render(value)
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			return c.Blob(status, f.contentType, body)
+		}
+	}
+
+	return notAcceptable()
+}
+
+// Negotiate refuses with 406 the requests accepting none of the media types
+// of Render, before the handler runs.
+func Negotiate() echo.MiddlewareFunc {
+	return NegotiateWithConfig(NegotiateConfig{})
+}
+
+type NegotiateConfig struct {
+	// Skipper lets through the routes answering in other media types
+	Skipper middleware.Skipper
+}
+
+func NegotiateWithConfig(config NegotiateConfig) echo.MiddlewareFunc {
+	if config.Skipper == nil {
+		config.Skipper = middleware.DefaultSkipper
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			if len(acceptableFormats(c.Request().Header.Get(echo.HeaderAccept))) == 0 {
+				return notAcceptable()
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// notAcceptable lists the media types the client may ask for instead.
+func notAcceptable() error {
+	types := make([]string, 0, len(formats))
+	for _, f := range formats {
+		types = append(types, f.mediaType)
+	}
+
+	problem := NewProblem(http.StatusNotAcceptable, "not_acceptable", "none of the accepted media types can represent the response")
+	return problem.WithDetails(types)
+}
+
+type mediaRange struct {
+	mediaType   string
+	quality     float64
+	specificity int
+}
+
+// acceptableFormats orders the formats by the quality the Accept header gives
+// them, the most specific range matching a format sets its quality, as in
+// RFC 9110. Ties keep the most specific match and then the server preference.
+func acceptableFormats(accept string) []format {
+	if strings.TrimSpace(accept) == "" {
+		return formats
+	}
+
+	ranges := parseAccept(accept)
+
+	type candidate struct {
+		format
+		mediaRange
+	}
+
+	var candidates []candidate
+	for _, f := range formats {
+		match := mediaRange{specificity: -1}
+		for _, r := range ranges {
+			if r.specificity > match.specificity && matchesRange(r.mediaType, f.mediaType) {
+				match = r
+			}
+		}
+
+		if match.specificity >= 0 && match.quality > 0 {
+			candidates = append(candidates, candidate{f, match})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].quality != candidates[j].quality {
+			return candidates[i].quality > candidates[j].quality
+		}
+		return candidates[i].specificity > candidates[j].specificity
+	})
+
+	acceptable := make([]format, 0, len(candidates))
+	for _, c := range candidates {
+		acceptable = append(acceptable, c.format)
+	}
+
+	return acceptable
+}
+
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			quality, err = strconv.ParseFloat(q, 64)
+			if err != nil || quality < 0 || quality > 1 {
+				continue
+			}
+		}
+
+		specificity := 2
+		switch {
+		case mediaType == "*/*":
+			specificity = 0
+		case strings.HasSuffix(mediaType, "/*"):
+			specificity = 1
+		}
+
+		ranges = append(ranges, mediaRange{mediaType: mediaType, quality: quality, specificity: specificity})
+	}
+
+	return ranges
+}
+
+func matchesRange(mediaRange string, mediaType string) bool {
+	if mediaRange == "*/*" || mediaRange == mediaType {
+		return true
+	}
+
+	prefix, ok := strings.CutSuffix(mediaRange, "*")
+	return ok && strings.HasSuffix(prefix, "/") && strings.HasPrefix(mediaType, prefix)
+}
+
+func renderJSON(value interface{}) ([]byte, bool, error) {
+	body, err := json.Marshal(value)
+	return body, true, err
+}
+
+func renderMsgpack(value interface{}) ([]byte, bool, error) {
+	body, err := msgpack.Marshal(value)
+	return body, true, err
+}
+
+// AddVary adds the request headers a response depends on to its Vary header,
+// those already listed are not repeated.
+func AddVary(header http.Header, names ...string) {
+	var listed []string
+	for _, value := range header.Values(echo.HeaderVary) {
+		for _, name := range strings.Split(value, ",") {
+			listed = append(listed, strings.ToLower(strings.TrimSpace(name)))
+		}
+	}
+
+	for _, name := range names {
+		if !slices.Contains(listed, strings.ToLower(name)) {
+			header.Add(echo.HeaderVary, name)
+			listed = append(listed, strings.ToLower(name))
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package requests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/waldrey/eulabs/pkg/jsontree"
+	"github.com/waldrey/eulabs/pkg/msgpack"
+)
+
+type renderedProduct struct {
+	ID          uint    `json:"id"`
+	Name        string  `json:"name"`
+	Description *string `json:"description"`
+	Price       struct {
+		Amount   string `json:"amount"`
+		Currency string `json:"currency"`
+	} `json:"price"`
+	Tags []string `json:"tags,omitempty"`
+}
+
+func renderProducts() []renderedProduct {
+	product := renderedProduct{ID: 1, Name: "Macbook Pro", Tags: []string{"apple"}}
+	product.Price.Amount = "23000.00"
+	product.Price.Currency = "BRL"
+
+	other := renderedProduct{ID: 2, Name: "Kindle, 11th"}
+	other.Price.Amount = "99.99"
+	other.Price.Currency = "USD"
+
+	return []renderedProduct{product, other}
+}
+
+func render(accept string, status int, response interface{}) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set(echo.HeaderAccept, accept)
+	recorder := httptest.NewRecorder()
+
+	c := echo.New().NewContext(request, recorder)
+	if err := Render(c, status, response); err != nil {
+		HTTPErrorHandler(err, c)
+	}
+	return recorder
+}
+
+func TestGivenAnAcceptHeader_WhenIListTheAcceptableFormats_ThenShouldOrderThemByQualityAndSpecificity(t *testing.T) {
+	mediaTypes := func(accept string) []string {
+		var types []string
+		for _, f := range acceptableFormats(accept) {
+			types = append(types, f.mediaType)
+		}
+		return types
+	}
+
+	assert.Equal(t, "application/json", mediaTypes("")[0])
+	assert.Equal(t, []string{"application/xml"}, mediaTypes("application/xml"))
+	assert.Equal(t, "text/xml", mediaTypes("text/xml, */*")[0])
+	assert.Equal(t, "application/json", mediaTypes("application/xml;q=0.5, application/json")[0])
+	assert.Equal(t, []string{"text/xml", "text/csv"}, mediaTypes("text/*"))
+	assert.Equal(t, []string{"text/xml"}, mediaTypes("text/*, text/csv;q=0"))
+	assert.Empty(t, mediaTypes("image/png"))
+	assert.Empty(t, mediaTypes("application/json;q=0"))
+}
+
+func TestGivenAcceptXML_WhenIRender_ThenShouldReceiveTheResponseAsXML(t *testing.T) {
+	recorder := render("application/xml", http.StatusOK, SuccessDataResponse(renderProducts()[:1]))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, echo.MIMEApplicationXMLCharsetUTF8, recorder.Header().Get(echo.HeaderContentType))
+	assert.Equal(t, "Accept", recorder.Header().Get(echo.HeaderVary))
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>`+"\n"+
+		`<response xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"><data><item>`+
+		`<id>1</id><name>Macbook Pro</name><description xsi:nil="true"></description>`+
+		`<price><amount>23000.00</amount><currency>BRL</currency></price><tags><item>apple</item></tags>`+
+		`</item></data></response>`, recorder.Body.String())
+}
+
+func TestGivenAcceptMsgpack_WhenIRender_ThenShouldReceiveTheResponseAsMessagePack(t *testing.T) {
+	recorder := render("application/x-msgpack", http.StatusCreated, SuccessDataResponse(map[string]string{"error": "Product not found"}))
+
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	assert.Equal(t, MIMEApplicationXMsgpack, recorder.Header().Get(echo.HeaderContentType))
+
+	value, err := msgpack.Unmarshal(recorder.Body.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, jsontree.Object{{Key: "data", Value: jsontree.Object{{Key: "error", Value: "Product not found"}}}}, value)
+}
+
+func TestGivenAcceptCSV_WhenIRenderAList_ThenShouldReceiveAFlattenedRowByObject(t *testing.T) {
+	recorder := render("text/csv", http.StatusOK, SuccessPageResponse(renderProducts(), PaginationMeta{Page: 1}))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "text/csv; charset=UTF-8", recorder.Header().Get(echo.HeaderContentType))
+	assert.Equal(t, "id,name,description,price.amount,price.currency,tags\n"+
+		"1,Macbook Pro,,23000.00,BRL,\"[\"\"apple\"\"]\"\n"+
+		"2,\"Kindle, 11th\",,99.99,USD,\n", recorder.Body.String())
+}
+
+func TestGivenAcceptCSV_WhenIRenderAnObject_ThenShouldFallBackOrRefuseIt(t *testing.T) {
+	recorder := render("text/csv, application/json;q=0.1", http.StatusOK, SuccessDataResponse(renderProducts()[0]))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, echo.MIMEApplicationJSON, recorder.Header().Get(echo.HeaderContentType))
+
+	recorder = render("text/csv", http.StatusOK, SuccessDataResponse(renderProducts()[0]))
+	assert.Equal(t, http.StatusNotAcceptable, recorder.Code)
+
+	assert.Equal(t, MIMEApplicationProblemJSON, recorder.Header().Get(echo.HeaderContentType))
+	assert.Contains(t, recorder.Body.String(), `"code":"not_acceptable"`)
+}
+
+func TestGivenAnUnsupportedAccept_WhenIRequestThroughNegotiate_ThenShouldReceiveNotAcceptable(t *testing.T) {
+	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandler
+	e.Use(Negotiate())
+	e.POST("/products", func(c echo.Context) error {
+		return Render(c, http.StatusCreated, SuccessDataResponse("created"))
+	})
+
+	request := httptest.NewRequest(http.MethodPost, "/products", nil)
+	request.Header.Set(echo.HeaderAccept, "image/png")
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusNotAcceptable, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "application/msgpack")
+
+	request = httptest.NewRequest(http.MethodPost, "/products", nil)
+	request.Header.Set(echo.HeaderAccept, "image/png, */*;q=0.1")
+	recorder = httptest.NewRecorder()
+	e.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+}
@@ -0,0 +1,52 @@
+package requests
+
+import (
+	"net/url"
+	"strconv"
+)
+
+type PaginationMeta struct {
+	Page       int    `json:"page"`
+	PageSize   int    `json:"page_size"`
+	Total      int64  `json:"total"`
+	TotalPages int    `json:"total_pages"`
+	Next       string `json:"next,omitempty"`
+	Prev       string `json:"prev,omitempty"`
+}
+
+type CursorMeta struct {
+	PageSize   int    `json:"page_size"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// NewPaginationMeta builds the meta block of a paginated listing, the next and
+// prev links keep every query parameter of the original request.
+func NewPaginationMeta(requestURL *url.URL, page int, pageSize int, total int64) PaginationMeta {
+	totalPages := int((total + int64(pageSize) - 1) / int64(pageSize))
+
+	meta := PaginationMeta{
+		Page:       page,
+		PageSize:   pageSize,
+		Total:      total,
+		TotalPages: totalPages,
+	}
+
+	if page < totalPages {
+		meta.Next = pageLink(requestURL, page+1, pageSize)
+	}
+
+	if page > 1 && totalPages > 0 {
+		meta.Prev = pageLink(requestURL, min(page-1, totalPages), pageSize)
+	}
+
+	return meta
+}
+
+func pageLink(requestURL *url.URL, page int, pageSize int) string {
+	query := requestURL.Query()
+	query.Set("page", strconv.Itoa(page))
+	query.Set("page_size", strconv.Itoa(pageSize))
+
+	link := url.URL{Path: requestURL.Path, RawQuery: query.Encode()}
+	return link.String()
+}
@@ -0,0 +1,26 @@
+package requests
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGivenAMiddlePage_WhenICallNewPaginationMeta_ThenShouldReceiveNextAndPrevLinks(t *testing.T) {
+	requestURL, _ := url.Parse("/api/v1/products?page=2&page_size=10")
+
+	meta := NewPaginationMeta(requestURL, 2, 10, 35)
+	assert.Equal(t, 4, meta.TotalPages)
+	assert.Equal(t, "/api/v1/products?page=3&page_size=10", meta.Next)
+	assert.Equal(t, "/api/v1/products?page=1&page_size=10", meta.Prev)
+}
+
+func TestGivenTheLastPage_WhenICallNewPaginationMeta_ThenShouldNotReceiveNextLink(t *testing.T) {
+	requestURL, _ := url.Parse("/api/v1/products")
+
+	meta := NewPaginationMeta(requestURL, 1, 20, 20)
+	assert.Equal(t, 1, meta.TotalPages)
+	assert.Empty(t, meta.Next)
+	assert.Empty(t, meta.Prev)
+}
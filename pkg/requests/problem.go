@@ -0,0 +1,322 @@
+package requests
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/waldrey/eulabs/internal/entity"
+	"github.com/waldrey/eulabs/pkg/i18n"
+	"github.com/waldrey/eulabs/pkg/jsontree"
+)
+
+const (
+	MIMEApplicationProblemJSON = "application/problem+json"
+	MIMEApplicationProblemXML  = "application/problem+xml"
+
+	HeaderContentLanguage = "Content-Language"
+
+	// ProblemTypePrefix makes the problem types URIs out of the error codes
+	ProblemTypePrefix = "urn:eulabs:problem:"
+
+	// xmlProblemNamespace is the namespace of RFC 7807 XML problems
+	xmlProblemNamespace = "urn:ietf:rfc:7807"
+)
+
+// Problem is the error response of the API, a RFC 7807 problem detail. Code is
+// stable and machine readable, Errors lists the refused fields of the request
+// and Details carries what else the client needs, like the results of a bulk.
+type Problem struct {
+	Type      string              `json:"type"`
+	Title     string              `json:"title"`
+	Status    int                 `json:"status"`
+	Detail    string              `json:"detail,omitempty"`
+	Instance  string              `json:"instance,omitempty"`
+	Code      string              `json:"code"`
+	RequestID string              `json:"request_id,omitempty"`
+	Errors    []entity.FieldError `json:"errors,omitempty"`
+	Details   interface{}         `json:"details,omitempty"`
+
+	// args are the params of the translations of the detail
+	args []string
+}
+
+// NewProblem is an error the handlers return for failures of the HTTP layer,
+// like a missing header, the domain failures come as entity.Error.
+func NewProblem(status int, code string, detail string) *Problem {
+	return &Problem{
+		Type:   ProblemTypePrefix + code,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return p.Detail
+	}
+
+	return p.Title
+}
+
+// Problems of the requests out of time, whatever error they led to.
+var (
+	ErrRequestTimeout  = NewProblem(http.StatusGatewayTimeout, "request_timeout", "the request took too long")
+	ErrRequestCanceled = NewProblem(http.StatusServiceUnavailable, "request_canceled", "the request was canceled")
+)
+
+// ErrBodyTooLarge is the problem of a body read past its http.MaxBytesReader.
+var ErrBodyTooLarge = NewProblem(http.StatusRequestEntityTooLarge, "body_too_large", "request body too large")
+
+// WithDetails returns a copy of the problem with the details.
+func (p *Problem) WithDetails(details interface{}) *Problem {
+	copied := *p
+	copied.Details = details
+	return &copied
+}
+
+// WithArgs returns a copy of the problem with the params of the translations
+// of its detail, like the name of an invalid parameter.
+func (p *Problem) WithArgs(args ...string) *Problem {
+	copied := *p
+	copied.args = args
+	return &copied
+}
+
+// Localize returns a copy of the problem with the title, the detail and the
+// field messages of the catalog of the translator, keyed by status.<status>,
+// problem.<code> and field.<code>. The messages missing from the catalog are
+// kept.
+func (p *Problem) Localize(translator ut.Translator) *Problem {
+	copied := *p
+	copied.Title = i18n.Message(translator, "status."+strconv.Itoa(p.Status), p.Title)
+	copied.Detail = i18n.Message(translator, "problem."+p.Code, p.Detail, p.args...)
+	if p.Errors != nil {
+		copied.Errors = make([]entity.FieldError, len(p.Errors))
+		for i, field := range p.Errors {
+			field.Message = i18n.FieldMessage(translator, fieldName(field.Field), field.Code, field.Param)
+			copied.Errors[i] = field
+		}
+	}
+
+	return &copied
+}
+
+// kindStatus is the status of each kind of domain error.
+var kindStatus = map[entity.Kind]int{
+	entity.KindInvalid:      http.StatusBadRequest,
+	entity.KindNotFound:     http.StatusNotFound,
+	entity.KindConflict:     http.StatusConflict,
+	entity.KindValidation:   http.StatusUnprocessableEntity,
+	entity.KindPrecondition: http.StatusPreconditionFailed,
+}
+
+// ProblemFor describes any error as a problem. Problems, domain errors,
+// validation errors, echo.HTTPError and the context errors keep their meaning,
+// anything else is an internal error whose message is not disclosed. The detail
+// of a domain error is its own message, without the errors it wraps.
+func ProblemFor(err error) *Problem {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		err = ErrBodyTooLarge
+	case errors.Is(err, context.DeadlineExceeded):
+		err = ErrRequestTimeout
+	case errors.Is(err, context.Canceled):
+		err = ErrRequestCanceled
+	}
+
+	var problem *Problem
+	if errors.As(err, &problem) {
+		copied := *problem
+		return &copied
+	}
+
+	var domainErr *entity.Error
+	if errors.As(err, &domainErr) {
+		status, ok := kindStatus[domainErr.Kind]
+		if !ok {
+			status = http.StatusInternalServerError
+		}
+
+		// the causes wrapped in the error are logged, never disclosed
+		problem := NewProblem(status, domainErr.Code, domainErr.Message)
+		problem.Errors = fieldMessages(domainErr.Fields)
+		return problem
+	}
+
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		problem := NewProblem(http.StatusUnprocessableEntity, "validation_failed", "the request has invalid fields")
+		problem.Errors = FieldErrors(validationErrors)
+		return problem
+	}
+
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		detail := http.StatusText(httpErr.Code)
+		if message, ok := httpErr.Message.(string); ok {
+			detail = message
+		}
+		return NewProblem(httpErr.Code, statusCode(httpErr.Code), detail)
+	}
+
+	return NewProblem(http.StatusInternalServerError, "internal_error", "Internal Server Error")
+}
+
+// FieldErrors describes the fields refused by the validator, by their path in
+// the request and the tag that failed.
+func FieldErrors(validationErrors validator.ValidationErrors) []entity.FieldError {
+	fields := make([]entity.FieldError, 0, len(validationErrors))
+	for _, fieldErr := range validationErrors {
+		fields = append(fields, entity.FieldError{
+			Field:   fieldPath(fieldErr),
+			Code:    fieldErr.Tag(),
+			Param:   fieldErr.Param(),
+			Message: i18n.FieldMessage(nil, strings.ToLower(fieldErr.Field()), fieldErr.Tag(), fieldErr.Param()),
+		})
+	}
+
+	return fields
+}
+
+// fieldMessages returns a copy of the refused fields of a domain error, the
+// ones sent without a message get the one of their code.
+func fieldMessages(fields []entity.FieldError) []entity.FieldError {
+	if fields == nil {
+		return nil
+	}
+
+	copied := make([]entity.FieldError, len(fields))
+	for i, field := range fields {
+		if field.Message == "" {
+			field.Message = i18n.FieldMessage(nil, fieldName(field.Field), field.Code, field.Param)
+		}
+		copied[i] = field
+	}
+
+	return copied
+}
+
+// fieldName is the last segment of the path of a field, the name its messages
+// refer to.
+func fieldName(path string) string {
+	return strings.ToLower(path[strings.LastIndex(path, ".")+1:])
+}
+
+// fieldPath is the path of the field in the request. The namespace starts
+// with the name of the validated struct, and names the embedded structs, like
+// the filters shared by the queries, which are not part of the request.
+func fieldPath(fieldErr validator.FieldError) string {
+	names := strings.Split(fieldErr.Namespace(), ".")
+	goNames := strings.Split(fieldErr.StructNamespace(), ".")
+
+	var path []string
+	for i := 1; i < len(names); i++ {
+		embedded := i < len(names)-1 && names[i] == goNames[i] && names[i] != strings.ToLower(names[i])
+		if !embedded {
+			path = append(path, names[i])
+		}
+	}
+
+	return strings.Join(path, ".")
+}
+
+// statusCode turns a status in a code, like not_found.
+func statusCode(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "error"
+	}
+
+	return strings.ReplaceAll(strings.ToLower(strings.NewReplacer("-", " ", "'", "").Replace(text)), " ", "_")
+}
+
+// HTTPErrorHandler renders every error returned by the handlers and the
+// middlewares as a problem, with the request path and the request ID set by
+// the RequestID middleware, in the language negotiated by i18n.Localize.
+// Internal errors are logged.
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	problem := ProblemFor(err)
+	problem.Instance = c.Request().URL.Path
+	problem.RequestID = c.Response().Header().Get(echo.HeaderXRequestID)
+	if translator := i18n.FromContext(c); translator != nil {
+		problem = problem.Localize(translator)
+		AddVary(c.Response().Header(), "Accept-Language")
+		c.Response().Header().Set(HeaderContentLanguage, i18n.Tag(translator))
+	}
+	if problem.Status >= http.StatusInternalServerError || hasCause(err) {
+		log.Printf("request %s failed: %v", problem.RequestID, err)
+	}
+
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(problem.Status)
+	} else {
+		err = RenderProblem(c, problem)
+	}
+
+	if err != nil {
+		log.Printf("failed render problem: %v", err)
+	}
+}
+
+// hasCause reports whether err tells more than the message of its domain
+// error, like the database error it wraps.
+func hasCause(err error) bool {
+	var domainErr *entity.Error
+	return errors.As(err, &domainErr) && err.Error() != domainErr.Message
+}
+
+// RenderProblem writes the problem in the format preferred by the client like
+// Render, JSON and XML as application/problem+json and application/problem+xml.
+// Problems are never refused, JSON is used when no format is acceptable.
+func RenderProblem(c echo.Context, problem *Problem) error {
+	AddVary(c.Response().Header(), "Accept")
+
+	value, err := jsontree.From(problem)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range acceptableFormats(c.Request().Header.Get(echo.HeaderAccept)) {
+		var body []byte
+		contentType := f.contentType
+		switch f.mediaType {
+		case echo.MIMEApplicationJSON:
+			body, _, err = renderJSON(value)
+			contentType = MIMEApplicationProblemJSON
+		case echo.MIMEApplicationXML, echo.MIMETextXML:
+			body, err = xmlDocument(xml.Name{Space: xmlProblemNamespace, Local: "problem"}, value)
+			contentType = MIMEApplicationProblemXML
+		case MIMETextCSV:
+			continue
+		default:
+			body, _, err = f.render(value)
+		}
+		if err != nil {
+			return err
+		}
+
+		return c.Blob(problem.Status, contentType, body)
+	}
+
+	body, _, err := renderJSON(value)
+	if err != nil {
+		return err
+	}
+
+	return c.Blob(problem.Status, MIMEApplicationProblemJSON, body)
+}
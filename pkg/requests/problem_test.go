@@ -0,0 +1,144 @@
+package requests
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/waldrey/eulabs/internal/entity"
+	"github.com/waldrey/eulabs/pkg/i18n"
+)
+
+func serveError(accept string, err error) *httptest.ResponseRecorder {
+	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandler
+	e.Use(middleware.RequestIDWithConfig(middleware.RequestIDConfig{
+		Generator: func() string { return "request-1" },
+	}))
+	e.GET("/products/:id", func(c echo.Context) error {
+		return err
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/products/1", nil)
+	request.Header.Set(echo.HeaderAccept, accept)
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestGivenADomainError_WhenTheHandlerReturnsIt_ThenShouldReceiveAProblemWithItsCode(t *testing.T) {
+	err := fmt.Errorf("find product: %w", entity.NotFound("product_not_found", "product not found"))
+	recorder := serveError("application/json", err)
+
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Equal(t, MIMEApplicationProblemJSON, recorder.Header().Get(echo.HeaderContentType))
+	assert.JSONEq(t, `{
+		"type": "urn:eulabs:problem:product_not_found",
+		"title": "Not Found",
+		"status": 404,
+		"detail": "product not found",
+		"instance": "/products/1",
+		"code": "product_not_found",
+		"request_id": "request-1"
+	}`, recorder.Body.String())
+}
+
+func TestGivenADomainErrorWithACause_WhenTheHandlerReturnsIt_ThenShouldNotDiscloseTheCause(t *testing.T) {
+	cause := errors.New("Error 1205 (HY000): Lock wait timeout exceeded on table products")
+	err := entity.Conflict("version_conflict", "product version conflict").Wrap(cause)
+	recorder := serveError("application/json", fmt.Errorf("update product: %w", err))
+
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"detail":"product version conflict"`)
+	assert.NotContains(t, recorder.Body.String(), "Lock wait timeout")
+	assert.NotContains(t, recorder.Body.String(), "update product")
+}
+
+func TestGivenValidationErrors_WhenTheHandlerReturnsThem_ThenShouldReceiveTheRefusedFields(t *testing.T) {
+	type price struct {
+		Amount float64 `json:"amount" validate:"gt=0"`
+	}
+	type product struct {
+		Name  string `json:"name" validate:"required"`
+		Price price  `json:"price"`
+	}
+
+	validate := validator.New()
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		return strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+	})
+	recorder := serveError("application/json", validate.Struct(product{}))
+
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.JSONEq(t, `{
+		"type": "urn:eulabs:problem:validation_failed",
+		"title": "Unprocessable Entity",
+		"status": 422,
+		"detail": "the request has invalid fields",
+		"instance": "/products/1",
+		"code": "validation_failed",
+		"request_id": "request-1",
+		"errors": [
+			{"field": "name", "code": "required", "message": "the field 'name' is required"},
+			{"field": "price.amount", "code": "gt", "param": "0", "message": "the field 'amount' is gt"}
+		]
+	}`, recorder.Body.String())
+}
+
+func TestGivenAcceptXML_WhenAnUnknownErrorIsReturned_ThenShouldReceiveAnInternalProblemAsXML(t *testing.T) {
+	recorder := serveError("application/xml", errors.New("connection refused"))
+
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.Equal(t, MIMEApplicationProblemXML, recorder.Header().Get(echo.HeaderContentType))
+	assert.Contains(t, recorder.Body.String(), `<problem xmlns="urn:ietf:rfc:7807"`)
+	assert.Contains(t, recorder.Body.String(), `<code>internal_error</code>`)
+	assert.NotContains(t, recorder.Body.String(), "connection refused")
+}
+
+func TestGivenADomainErrorRefusingFields_WhenTheHandlerReturnsIt_ThenShouldReceiveTheFieldMessages(t *testing.T) {
+	recorder := serveError("application/json", entity.ErrInvalidCurrency)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `{"field":"price.currency","code":"currency","message":"the field 'currency' is currency"}`)
+	assert.Empty(t, entity.ErrInvalidCurrency.Fields[0].Message)
+}
+
+func TestGivenAcceptLanguage_WhenAProblemIsRendered_ThenShouldReceiveItTranslated(t *testing.T) {
+	catalog, err := i18n.NewCatalog()
+	assert.NoError(t, err)
+
+	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandler
+	e.Use(i18n.Localize(catalog))
+	e.GET("/products", func(c echo.Context) error {
+		return entity.Validation("invalid_price", "invalid price").WithFields(entity.FieldError{Field: "price", Code: "gt", Param: "0"})
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/products", nil)
+	request.Header.Set("Accept-Language", "pt-BR, en;q=0.5")
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.Equal(t, "pt-BR", recorder.Header().Get(HeaderContentLanguage))
+	assert.Contains(t, recorder.Header().Values(echo.HeaderVary), "Accept-Language")
+	assert.JSONEq(t, `{
+		"type": "urn:eulabs:problem:invalid_price",
+		"title": "Entidade não processável",
+		"status": 422,
+		"detail": "preço inválido",
+		"instance": "/products",
+		"code": "invalid_price",
+		"errors": [
+			{"field": "price", "code": "gt", "param": "0", "message": "o campo 'price' deve ser maior que 0"}
+		]
+	}`, recorder.Body.String())
+}
@@ -1,25 +1,13 @@
 package requests
 
-import "github.com/waldrey/eulabs/internal/entity"
-
-type TypeErrorResponse struct {
-	Data struct {
-		Error string `json:"error"`
-	} `json:"data"`
-}
+import (
+	"github.com/waldrey/eulabs/internal/dto"
+	"github.com/waldrey/eulabs/internal/entity"
+)
 
 type TypeSuccessResponse struct {
 	Data interface{} `json:"data"`
-}
-
-func ErrorResponse(message string) TypeErrorResponse {
-	return TypeErrorResponse{
-		Data: struct {
-			Error string `json:"error"`
-		}{
-			Error: message,
-		},
-	}
+	Meta interface{} `json:"meta,omitempty"`
 }
 
 func SuccessResponse(product entity.Product) TypeSuccessResponse {
@@ -33,3 +21,34 @@ func SuccessListResponse(products []entity.Product) TypeSuccessResponse {
 		Data: products,
 	}
 }
+
+func SuccessPageResponse(products interface{}, meta interface{}) TypeSuccessResponse {
+	return TypeSuccessResponse{
+		Data: products,
+		Meta: meta,
+	}
+}
+
+func SuccessDataResponse(data interface{}) TypeSuccessResponse {
+	return TypeSuccessResponse{
+		Data: data,
+	}
+}
+
+func SuccessSearchResponse(results []dto.ProductSearchResult) TypeSuccessResponse {
+	return TypeSuccessResponse{
+		Data: results,
+	}
+}
+
+func SuccessSuggestResponse(suggestions []dto.ProductSuggestion) TypeSuccessResponse {
+	return TypeSuccessResponse{
+		Data: suggestions,
+	}
+}
+
+func SuccessStatsResponse(stats dto.ProductStatsResponse) TypeSuccessResponse {
+	return TypeSuccessResponse{
+		Data: stats,
+	}
+}
@@ -0,0 +1,197 @@
+package requests
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/waldrey/eulabs/pkg/jsontree"
+)
+
+const (
+	// xmlRoot wraps the responses, requests may use any root element
+	xmlRoot = "response"
+
+	// xmlItem is the element of every array item, elements whose children
+	// are all items are read as arrays
+	xmlItem = "item"
+
+	xmlSchemaInstance = "http://www.w3.org/2001/XMLSchema-instance"
+)
+
+var ErrInvalidXML = errors.New("invalid XML document")
+
+// renderXML writes the JSON document as XML: members become elements of the
+// same name, array items <item> elements and null an empty element with
+// xsi:nil="true".
+func renderXML(value interface{}) ([]byte, bool, error) {
+	body, err := xmlDocument(xml.Name{Local: xmlRoot}, value)
+	return body, err == nil, err
+}
+
+// xmlDocument writes the value in the root element, its namespace becomes the
+// default one of the document.
+func xmlDocument(root xml.Name, value interface{}) ([]byte, error) {
+	var buffer bytes.Buffer
+	buffer.WriteString(xml.Header)
+
+	start := xml.StartElement{Name: xml.Name{Local: root.Local}}
+	if root.Space != "" {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "xmlns"}, Value: root.Space})
+	}
+	start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "xmlns:xsi"}, Value: xmlSchemaInstance})
+
+	encoder := xml.NewEncoder(&buffer)
+	if err := encodeXML(encoder, start, value); err != nil {
+		return nil, err
+	}
+
+	if err := encoder.Flush(); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func encodeXML(encoder *xml.Encoder, start xml.StartElement, value interface{}) error {
+	if value == nil {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "xsi:nil"}, Value: "true"})
+	}
+
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
+
+	switch value := value.(type) {
+	case nil:
+	case jsontree.Object:
+		for _, member := range value {
+			if err := encodeXML(encoder, xml.StartElement{Name: xml.Name{Local: xmlName(member.Key)}}, member.Value); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range value {
+			if err := encodeXML(encoder, xml.StartElement{Name: xml.Name{Local: xmlItem}}, item); err != nil {
+				return err
+			}
+		}
+	default:
+		if err := encoder.EncodeToken(xml.CharData(scalarText(value))); err != nil {
+			return err
+		}
+	}
+
+	return encoder.EncodeToken(start.End())
+}
+
+// xmlName replaces the characters not allowed in element names, JSON keys are
+// usually valid names already.
+func xmlName(key string) string {
+	name := []rune(key)
+	for i, r := range name {
+		valid := r == '_' || unicode.IsLetter(r) || (i > 0 && (r == '-' || r == '.' || unicode.IsDigit(r)))
+		if !valid {
+			name[i] = '_'
+		}
+	}
+
+	if len(name) == 0 || strings.HasPrefix(strings.ToLower(string(name)), "xml") {
+		return "_" + string(name)
+	}
+
+	return string(name)
+}
+
+func scalarText(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case json.Number:
+		return value.String()
+	case bool:
+		if value {
+			return "true"
+		}
+		return "false"
+	}
+
+	return fmt.Sprint(value)
+}
+
+// decodeXML reads a document written like the responses of renderXML in
+// jsontree values, leaving every scalar as a string: see coerce for the
+// conversion to the types of the request.
+func decodeXML(data []byte) (interface{}, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidXML, err)
+		}
+
+		if start, ok := token.(xml.StartElement); ok {
+			value, err := decodeElement(decoder, start)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidXML, err)
+			}
+			return value, nil
+		}
+	}
+}
+
+func decodeElement(decoder *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	null := false
+	for _, attr := range start.Attr {
+		if attr.Name.Local == "nil" && attr.Value == "true" {
+			null = true
+		}
+	}
+
+	var text strings.Builder
+	var children jsontree.Object
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			value, err := decodeElement(decoder, token)
+			if err != nil {
+				return nil, err
+			}
+			children = append(children, jsontree.Member{Key: token.Name.Local, Value: value})
+		case xml.CharData:
+			text.Write(token)
+		case xml.EndElement:
+			switch {
+			case null:
+				return nil, nil
+			case len(children) == 0:
+				return text.String(), nil
+			}
+			return elementValue(children), nil
+		}
+	}
+}
+
+func elementValue(children jsontree.Object) interface{} {
+	items := make([]interface{}, 0, len(children))
+	for _, child := range children {
+		if child.Key != xmlItem {
+			return children
+		}
+		items = append(items, child.Value)
+	}
+
+	return items
+}
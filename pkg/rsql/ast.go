@@ -0,0 +1,86 @@
+package rsql
+
+import "fmt"
+
+type Operator string
+
+const (
+	Equal          Operator = "=="
+	NotEqual       Operator = "!="
+	Greater        Operator = "=gt="
+	GreaterOrEqual Operator = "=ge="
+	Less           Operator = "=lt="
+	LessOrEqual    Operator = "=le="
+	In             Operator = "=in="
+	NotIn          Operator = "=out="
+	Like           Operator = "=like="
+)
+
+var operators = map[string]Operator{
+	"==":     Equal,
+	"!=":     NotEqual,
+	"=gt=":   Greater,
+	"=ge=":   GreaterOrEqual,
+	"=lt=":   Less,
+	"=le=":   LessOrEqual,
+	"=in=":   In,
+	"=out=":  NotIn,
+	"=like=": Like,
+}
+
+type LogicalOperator string
+
+const (
+	And LogicalOperator = "AND"
+	Or  LogicalOperator = "OR"
+)
+
+// Node is either a *Logical or a *Comparison.
+type Node interface {
+	node()
+}
+
+type Logical struct {
+	Operator LogicalOperator
+	Children []Node
+}
+
+type Comparison struct {
+	Selector         string
+	Operator         Operator
+	Arguments        []Argument
+	Position         int
+	OperatorPosition int
+
+	// filled by Schema.Validate
+	column    string
+	values    []interface{}
+	scope     string
+	scopeArgs []interface{}
+}
+
+type Argument struct {
+	Value    string
+	Quoted   bool
+	Position int
+}
+
+func (*Logical) node()    {}
+func (*Comparison) node() {}
+
+// IsNull reports whether the argument is the unquoted null keyword.
+func (a Argument) IsNull() bool {
+	return !a.Quoted && a.Value == "null"
+}
+
+// Error points at the token of the filter expression that could not be parsed
+// or validated, the position is the byte offset of the token in the input.
+type Error struct {
+	Position int    `json:"position"`
+	Token    string `json:"token"`
+	Message  string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s at position %d near %q", e.Message, e.Position, e.Token)
+}
@@ -0,0 +1,275 @@
+package rsql
+
+import "strings"
+
+const reservedChars = "\"'();,=!~<>"
+
+type parser struct {
+	input string
+	pos   int
+}
+
+// Parse reads an RSQL/FIQL expression such as
+// `price=gt=100;name=like=*Pro*,description==null` where ";" is AND, "," is
+// OR and parentheses group constraints.
+func Parse(input string) (Node, error) {
+	p := &parser{input: input}
+
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipSpaces()
+	if p.pos < len(p.input) {
+		return nil, p.errorAt(p.pos, "unexpected token")
+	}
+
+	return node, nil
+}
+
+func (p *parser) parseOr() (Node, error) {
+	return p.parseLogical(Or, ',', p.parseAnd)
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	return p.parseLogical(And, ';', p.parseConstraint)
+}
+
+func (p *parser) parseLogical(operator LogicalOperator, separator byte, next func() (Node, error)) (Node, error) {
+	first, err := next()
+	if err != nil {
+		return nil, err
+	}
+
+	children := []Node{first}
+	for p.skipSpaces(); p.peek(separator); p.skipSpaces() {
+		p.pos++
+
+		child, err := next()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+	}
+
+	if len(children) == 1 {
+		return first, nil
+	}
+
+	return &Logical{Operator: operator, Children: children}, nil
+}
+
+func (p *parser) parseConstraint() (Node, error) {
+	p.skipSpaces()
+	if !p.peek('(') {
+		return p.parseComparison()
+	}
+	p.pos++
+
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipSpaces()
+	if !p.peek(')') {
+		return nil, p.errorAt(p.pos, "expected ')'")
+	}
+	p.pos++
+
+	return node, nil
+}
+
+func (p *parser) parseComparison() (Node, error) {
+	p.skipSpaces()
+	position := p.pos
+
+	selector := p.readUnreserved()
+	if selector == "" {
+		return nil, p.errorAt(position, "expected field name")
+	}
+
+	p.skipSpaces()
+	operatorPosition := p.pos
+
+	operator, ok := p.readOperator()
+	if !ok {
+		return nil, &Error{Position: operatorPosition, Token: p.operatorToken(), Message: "unknown operator"}
+	}
+
+	arguments, err := p.parseArguments()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Comparison{
+		Selector:         selector,
+		Operator:         operator,
+		Arguments:        arguments,
+		Position:         position,
+		OperatorPosition: operatorPosition,
+	}, nil
+}
+
+func (p *parser) parseArguments() ([]Argument, error) {
+	p.skipSpaces()
+	if !p.peek('(') {
+		argument, err := p.parseArgument()
+		if err != nil {
+			return nil, err
+		}
+		return []Argument{argument}, nil
+	}
+	p.pos++
+
+	var arguments []Argument
+	for {
+		argument, err := p.parseArgument()
+		if err != nil {
+			return nil, err
+		}
+		arguments = append(arguments, argument)
+
+		p.skipSpaces()
+		if p.peek(')') {
+			p.pos++
+			return arguments, nil
+		}
+
+		if !p.peek(',') {
+			return nil, p.errorAt(p.pos, "expected ',' or ')'")
+		}
+		p.pos++
+	}
+}
+
+func (p *parser) parseArgument() (Argument, error) {
+	p.skipSpaces()
+	position := p.pos
+
+	if p.peek('"') || p.peek('\'') {
+		value, err := p.readQuoted()
+		if err != nil {
+			return Argument{}, err
+		}
+		return Argument{Value: value, Quoted: true, Position: position}, nil
+	}
+
+	value := p.readUnreserved()
+	if value == "" {
+		return Argument{}, p.errorAt(position, "expected argument")
+	}
+
+	return Argument{Value: value, Position: position}, nil
+}
+
+func (p *parser) readOperator() (Operator, bool) {
+	rest := p.input[p.pos:]
+	if strings.HasPrefix(rest, "==") || strings.HasPrefix(rest, "!=") {
+		p.pos += 2
+		return operators[rest[:2]], true
+	}
+
+	if !strings.HasPrefix(rest, "=") {
+		return "", false
+	}
+
+	end := strings.IndexByte(rest[1:], '=')
+	if end < 0 {
+		return "", false
+	}
+
+	operator, ok := operators[rest[:end+2]]
+	if ok {
+		p.pos += end + 2
+	}
+
+	return operator, ok
+}
+
+// operatorToken returns the text that looks like an operator at the current
+// position, used to report unknown operators such as "=gx=".
+func (p *parser) operatorToken() string {
+	rest := p.input[p.pos:]
+	if strings.HasPrefix(rest, "=") {
+		if end := strings.IndexByte(rest[1:], '='); end >= 0 {
+			return rest[:end+2]
+		}
+	}
+
+	end := 1
+	for end < len(rest) && !isReserved(rest[end]) {
+		end++
+	}
+
+	return rest[:min(end, len(rest))]
+}
+
+func (p *parser) readQuoted() (string, error) {
+	start := p.pos
+	quote := p.input[p.pos]
+	p.pos++
+
+	var value strings.Builder
+	for p.pos < len(p.input) {
+		char := p.input[p.pos]
+		switch {
+		case char == '\\' && p.pos+1 < len(p.input):
+			value.WriteByte(p.input[p.pos+1])
+			p.pos += 2
+		case char == quote:
+			p.pos++
+			return value.String(), nil
+		default:
+			value.WriteByte(char)
+			p.pos++
+		}
+	}
+
+	return "", &Error{Position: start, Token: p.input[start:], Message: "unterminated quoted argument"}
+}
+
+func (p *parser) readUnreserved() string {
+	start := p.pos
+	for p.pos < len(p.input) && !isReserved(p.input[p.pos]) {
+		p.pos++
+	}
+
+	return p.input[start:p.pos]
+}
+
+func (p *parser) skipSpaces() {
+	for p.pos < len(p.input) && isSpace(p.input[p.pos]) {
+		p.pos++
+	}
+}
+
+func (p *parser) peek(char byte) bool {
+	return p.pos < len(p.input) && p.input[p.pos] == char
+}
+
+func (p *parser) errorAt(position int, message string) *Error {
+	if position >= len(p.input) {
+		return &Error{Position: position, Message: message + ", unexpected end of filter"}
+	}
+
+	end := position + 1
+	if !isReserved(p.input[position]) {
+		for end < len(p.input) && !isReserved(p.input[end]) {
+			end++
+		}
+	}
+
+	return &Error{Position: position, Token: p.input[position:end], Message: message}
+}
+
+func isReserved(char byte) bool {
+	return strings.IndexByte(reservedChars, char) >= 0 || isSpace(char)
+}
+
+// isSpace only matches ASCII whitespace, the bytes of a multi-byte UTF-8
+// character are part of the argument.
+func isSpace(char byte) bool {
+	return char == ' ' || char == '\t' || char == '\n' || char == '\r'
+}
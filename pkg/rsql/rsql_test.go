@@ -0,0 +1,96 @@
+package rsql
+
+import (
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testSchema = Schema{
+	"name":        {Column: "name", Kind: String},
+	"description": {Column: "description", Kind: String},
+	"price":       {Column: "price", Kind: Number},
+}
+
+func TestGivenAValidExpression_WhenIParseAndCompile_ThenShouldReceiveAParameterizedCondition(t *testing.T) {
+	node, err := Parse("price=gt=100;name=like=*Pro*,description==null")
+	assert.NoError(t, err)
+	assert.NoError(t, testSchema.Validate(node))
+
+	sql, args, err := Where(node)
+	assert.NoError(t, err)
+	assert.Equal(t, "((price > ? AND name LIKE ?) OR description IS NULL)", sql)
+	assert.Equal(t, []interface{}{100.0, "%Pro%"}, args)
+}
+
+func TestGivenGroupsAndLists_WhenIParseAndCompile_ThenShouldKeepThePrecedence(t *testing.T) {
+	node, err := Parse(`price=in=(10, 20);(name=="Macbook Pro",name!='iPhone')`)
+	assert.NoError(t, err)
+	assert.NoError(t, testSchema.Validate(node))
+
+	sql, args, err := Where(node)
+	assert.NoError(t, err)
+	assert.Equal(t, "(price IN (?, ?) AND (name = ? OR name <> ?))", sql)
+	assert.Equal(t, []interface{}{10.0, 20.0, "Macbook Pro", "iPhone"}, args)
+}
+
+func TestGivenAScopedField_WhenIParseAndCompile_ThenShouldJoinTheScopeToItsComparisons(t *testing.T) {
+	schema := Schema{
+		"name":  {Column: "name", Kind: String},
+		"price": {Column: "price_amount", Kind: Number, Scope: "price_currency = ?", ScopeArgs: []interface{}{"BRL"}},
+	}
+
+	node, err := Parse("price=gt=100,name==Pro")
+	assert.NoError(t, err)
+	assert.NoError(t, schema.Validate(node))
+
+	sql, args, err := Where(node)
+	assert.NoError(t, err)
+	assert.Equal(t, "((price_currency = ? AND price_amount > ?) OR name = ?)", sql)
+	assert.Equal(t, []interface{}{"BRL", 100.0, "Pro"}, args)
+}
+
+func TestGivenAccentedArguments_WhenIParseAndCompile_ThenShouldKeepTheirCharacters(t *testing.T) {
+	// à ends in 0xA0 and … in 0x85, bytes that are spaces when read alone
+	node, err := Parse("name==à;description=like=*açúcar…*,name=in=(café, 日本語, 'pão de queijo', naïve\u00a0x)")
+	assert.NoError(t, err)
+	assert.NoError(t, testSchema.Validate(node))
+
+	_, args, err := Where(node)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"à", "%açúcar…%", "café", "日本語", "pão de queijo", "naïve\u00a0x"}, args)
+	for _, arg := range args {
+		assert.True(t, utf8.ValidString(arg.(string)))
+	}
+}
+
+func TestGivenAnUnknownOperator_WhenIParse_ThenShouldReceiveTheTokenPosition(t *testing.T) {
+	_, err := Parse("price=gx=100")
+	assert.Equal(t, &Error{Position: 5, Token: "=gx=", Message: "unknown operator"}, err)
+}
+
+func TestGivenAnUnclosedGroup_WhenIParse_ThenShouldReceiveAnError(t *testing.T) {
+	_, err := Parse("(price=gt=100")
+	assert.Equal(t, &Error{Position: 13, Message: "expected ')', unexpected end of filter"}, err)
+}
+
+func TestGivenAnUnknownField_WhenIValidate_ThenShouldReceiveTheFieldPosition(t *testing.T) {
+	node, err := Parse("price=gt=100;password==secret")
+	assert.NoError(t, err)
+	assert.Equal(t, &Error{Position: 13, Token: "password", Message: "unknown field"}, testSchema.Validate(node))
+}
+
+func TestGivenAnInvalidNumber_WhenIValidate_ThenShouldReceiveTheArgumentPosition(t *testing.T) {
+	node, err := Parse("price=ge=cheap")
+	assert.NoError(t, err)
+	assert.Equal(t, &Error{Position: 9, Token: "cheap", Message: "invalid number"}, testSchema.Validate(node))
+}
+
+func TestGivenANotValidatedTree_WhenICompile_ThenShouldReceiveAnError(t *testing.T) {
+	node, err := Parse("price=gt=100")
+	assert.NoError(t, err)
+
+	_, _, err = Where(node)
+	assert.ErrorIs(t, err, ErrNotValidated)
+}
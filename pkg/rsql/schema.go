@@ -0,0 +1,126 @@
+package rsql
+
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+var (
+	errInvalidNumber = errors.New("invalid number")
+	errInvalidTime   = errors.New("invalid date time, expected RFC 3339")
+)
+
+type Kind int
+
+const (
+	String Kind = iota
+	Number
+	Time
+)
+
+type Field struct {
+	Column string
+	Kind   Kind
+
+	// Parse overrides the conversion of the arguments done by Kind
+	Parse func(value string) (interface{}, error)
+
+	// Scope is a condition joined to every comparison of the field, like the
+	// currency a price is compared in, its arguments are ScopeArgs
+	Scope     string
+	ScopeArgs []interface{}
+}
+
+// Schema is the allow-list of fields a filter may reference, keyed by the
+// name used in the expression.
+type Schema map[string]Field
+
+// Validate checks every comparison of the tree against the schema and resolves
+// their columns and typed arguments, only validated trees can be compiled by
+// Where.
+func (s Schema) Validate(node Node) error {
+	switch n := node.(type) {
+	case *Logical:
+		for _, child := range n.Children {
+			if err := s.Validate(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *Comparison:
+		return s.validateComparison(n)
+	}
+
+	return nil
+}
+
+func (s Schema) validateComparison(comparison *Comparison) error {
+	field, ok := s[comparison.Selector]
+	if !ok {
+		return &Error{Position: comparison.Position, Token: comparison.Selector, Message: "unknown field"}
+	}
+
+	operatorError := &Error{
+		Position: comparison.OperatorPosition,
+		Token:    string(comparison.Operator),
+	}
+
+	if comparison.Operator == Like && field.Kind != String {
+		operatorError.Message = "operator is only supported by text fields"
+		return operatorError
+	}
+
+	multiple := comparison.Operator == In || comparison.Operator == NotIn
+	if !multiple && len(comparison.Arguments) > 1 {
+		operatorError.Message = "operator expects a single argument"
+		return operatorError
+	}
+
+	values := make([]interface{}, 0, len(comparison.Arguments))
+	for _, argument := range comparison.Arguments {
+		if argument.IsNull() {
+			if comparison.Operator != Equal && comparison.Operator != NotEqual {
+				return &Error{Position: argument.Position, Token: argument.Value, Message: "null is only supported by == and !="}
+			}
+			values = append(values, nil)
+			continue
+		}
+
+		value, err := field.convert(argument.Value)
+		if err != nil {
+			return &Error{Position: argument.Position, Token: argument.Value, Message: err.Error()}
+		}
+		values = append(values, value)
+	}
+
+	comparison.column = field.Column
+	comparison.values = values
+	comparison.scope = field.Scope
+	comparison.scopeArgs = field.ScopeArgs
+
+	return nil
+}
+
+func (f Field) convert(value string) (interface{}, error) {
+	if f.Parse != nil {
+		return f.Parse(value)
+	}
+
+	switch f.Kind {
+	case Number:
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, errInvalidNumber
+		}
+		return number, nil
+	case Time:
+		date, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, errInvalidTime
+		}
+		return date, nil
+	}
+
+	return value, nil
+}
@@ -0,0 +1,110 @@
+package rsql
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrNotValidated = errors.New("filter was not validated against a schema")
+
+var comparisonOperators = map[Operator]string{
+	Equal:          "=",
+	NotEqual:       "<>",
+	Greater:        ">",
+	GreaterOrEqual: ">=",
+	Less:           "<",
+	LessOrEqual:    "<=",
+	Like:           "LIKE",
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`, "*", "%")
+
+// Where compiles a validated tree into a parameterized SQL condition, columns
+// come only from the schema so no user input reaches the SQL text.
+func Where(node Node) (string, []interface{}, error) {
+	var args []interface{}
+	var sql strings.Builder
+
+	if err := write(&sql, &args, node); err != nil {
+		return "", nil, err
+	}
+
+	return sql.String(), args, nil
+}
+
+func write(sql *strings.Builder, args *[]interface{}, node Node) error {
+	switch n := node.(type) {
+	case *Logical:
+		sql.WriteString("(")
+		for i, child := range n.Children {
+			if i > 0 {
+				sql.WriteString(" " + string(n.Operator) + " ")
+			}
+			if err := write(sql, args, child); err != nil {
+				return err
+			}
+		}
+		sql.WriteString(")")
+		return nil
+	case *Comparison:
+		return writeComparison(sql, args, n)
+	}
+
+	return ErrNotValidated
+}
+
+func writeComparison(sql *strings.Builder, args *[]interface{}, comparison *Comparison) error {
+	if comparison.column == "" {
+		return ErrNotValidated
+	}
+
+	if comparison.scope == "" {
+		writeCondition(sql, args, comparison)
+		return nil
+	}
+
+	sql.WriteString("(" + comparison.scope + " AND ")
+	*args = append(*args, comparison.scopeArgs...)
+	writeCondition(sql, args, comparison)
+	sql.WriteString(")")
+
+	return nil
+}
+
+func writeCondition(sql *strings.Builder, args *[]interface{}, comparison *Comparison) {
+	sql.WriteString(comparison.column)
+
+	if comparison.Operator == In || comparison.Operator == NotIn {
+		if comparison.Operator == NotIn {
+			sql.WriteString(" NOT")
+		}
+		sql.WriteString(" IN (")
+		for i, value := range comparison.values {
+			if i > 0 {
+				sql.WriteString(", ")
+			}
+			sql.WriteString("?")
+			*args = append(*args, value)
+		}
+		sql.WriteString(")")
+		return
+	}
+
+	value := comparison.values[0]
+	if value == nil {
+		if comparison.Operator == NotEqual {
+			sql.WriteString(" IS NOT NULL")
+		} else {
+			sql.WriteString(" IS NULL")
+		}
+		return
+	}
+
+	if comparison.Operator == Like {
+		value = likeEscaper.Replace(value.(string))
+	}
+
+	sql.WriteString(" " + comparisonOperators[comparison.Operator] + " ?")
+	*args = append(*args, value)
+
+}
@@ -0,0 +1,96 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	highlightOpen  = "<em>"
+	highlightClose = "</em>"
+	ellipsis       = "…"
+)
+
+// Highlight returns a snippet of about size bytes around the first match of
+// the query terms, wrapping every match in <em> tags. The rest of the text is
+// HTML escaped so the snippet can be rendered as is. An empty string is
+// returned when nothing matches.
+func Highlight(text string, query string, size int) string {
+	terms := map[string]bool{}
+	for _, term := range Tokenize(query) {
+		terms[term] = true
+	}
+
+	var matches []token
+	for _, t := range tokens(text) {
+		if terms[t.Term] {
+			matches = append(matches, t)
+		}
+	}
+
+	if len(matches) == 0 {
+		return ""
+	}
+
+	start, end := window(text, matches[0], size)
+
+	var snippet strings.Builder
+	if start > 0 {
+		snippet.WriteString(ellipsis)
+	}
+
+	cursor := start
+	for _, match := range matches {
+		if match.Start < start || match.End > end {
+			continue
+		}
+		snippet.WriteString(html.EscapeString(text[cursor:match.Start]))
+		snippet.WriteString(highlightOpen)
+		snippet.WriteString(html.EscapeString(text[match.Start:match.End]))
+		snippet.WriteString(highlightClose)
+		cursor = match.End
+	}
+	snippet.WriteString(html.EscapeString(text[cursor:end]))
+
+	if end < len(text) {
+		snippet.WriteString(ellipsis)
+	}
+
+	return snippet.String()
+}
+
+// window picks the bounds of the snippet, starting a little before the first
+// match and snapped to spaces so words are not cut. Without a space the bounds
+// are snapped to the start of a rune, so no character is cut either.
+func window(text string, first token, size int) (int, int) {
+	if len(text) <= size {
+		return 0, len(text)
+	}
+
+	start := max(first.Start-size/4, 0)
+	for start > 0 && !utf8.RuneStart(text[start]) {
+		start--
+	}
+	if start > 0 {
+		if space := strings.IndexByte(text[start:first.Start], ' '); space >= 0 {
+			start += space + 1
+		} else {
+			start = first.Start
+		}
+	}
+
+	end := min(start+size, len(text))
+	if end < len(text) {
+		if space := strings.LastIndexByte(text[first.End:end], ' '); space >= 0 {
+			end = first.End + space
+		} else {
+			end = max(first.End, end)
+		}
+	}
+	for end < len(text) && !utf8.RuneStart(text[end]) {
+		end++
+	}
+
+	return start, end
+}
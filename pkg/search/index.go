@@ -0,0 +1,151 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// BM25 parameters
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+type Hit struct {
+	ID    uint
+	Score float64
+}
+
+// Index is a thread safe in-memory inverted index ranking documents by BM25.
+type Index struct {
+	mu          sync.RWMutex
+	postings    map[string]map[uint]int
+	lengths     map[uint]int
+	totalLength int
+
+	// terms lists the distinct terms of each document, so removing it only
+	// touches its own postings
+	terms map[uint][]string
+}
+
+func NewIndex() *Index {
+	return &Index{
+		postings: map[string]map[uint]int{},
+		lengths:  map[uint]int{},
+		terms:    map[uint][]string{},
+	}
+}
+
+// Add indexes the texts of a document, replacing its previous version.
+func (i *Index) Add(id uint, texts ...string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.remove(id)
+
+	length := 0
+	var terms []string
+	for _, text := range texts {
+		for _, term := range Tokenize(text) {
+			if i.postings[term] == nil {
+				i.postings[term] = map[uint]int{}
+			}
+			if i.postings[term][id] == 0 {
+				terms = append(terms, term)
+			}
+			i.postings[term][id]++
+			length++
+		}
+	}
+
+	i.lengths[id] = length
+	i.terms[id] = terms
+	i.totalLength += length
+}
+
+func (i *Index) Remove(id uint) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.remove(id)
+}
+
+func (i *Index) remove(id uint) {
+	length, ok := i.lengths[id]
+	if !ok {
+		return
+	}
+
+	for _, term := range i.terms[id] {
+		documents := i.postings[term]
+		delete(documents, id)
+		if len(documents) == 0 {
+			delete(i.postings, term)
+		}
+	}
+
+	delete(i.lengths, id)
+	delete(i.terms, id)
+	i.totalLength -= length
+}
+
+// Search returns up to limit documents matching any term of the query, the
+// most relevant first.
+func (i *Index) Search(query string, limit int) []Hit {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	if len(i.lengths) == 0 {
+		return nil
+	}
+
+	documents := float64(len(i.lengths))
+	averageLength := float64(i.totalLength) / documents
+
+	scores := map[uint]float64{}
+	for _, term := range unique(Tokenize(query)) {
+		postings := i.postings[term]
+		if len(postings) == 0 {
+			continue
+		}
+
+		idf := math.Log(1 + (documents-float64(len(postings))+0.5)/(float64(len(postings))+0.5))
+		for id, frequency := range postings {
+			tf := float64(frequency)
+			norm := k1 * (1 - b + b*float64(i.lengths[id])/averageLength)
+			scores[id] += idf * tf * (k1 + 1) / (tf + norm)
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, Hit{ID: id, Score: score})
+	}
+
+	sort.Slice(hits, func(a, b int) bool {
+		if hits[a].Score == hits[b].Score {
+			return hits[a].ID < hits[b].ID
+		}
+		return hits[a].Score > hits[b].Score
+	})
+
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+
+	return hits
+}
+
+func unique(terms []string) []string {
+	seen := map[string]bool{}
+	result := terms[:0]
+	for _, term := range terms {
+		if !seen[term] {
+			seen[term] = true
+			result = append(result, term)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,81 @@
+package search
+
+import (
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGivenAText_WhenITokenize_ThenShouldReceiveFoldedTerms(t *testing.T) {
+	assert.Equal(t, []string{"frigideira", "antiaderente", "coracao", "4x"}, Tokenize("Frigideira - Antiaderente, Coração 4x!"))
+}
+
+func TestGivenIndexedDocuments_WhenISearch_ThenShouldReceiveTheMostRelevantFirst(t *testing.T) {
+	index := NewIndex()
+	index.Add(1, "Macbook Pro", "O poderoso computador da Apple")
+	index.Add(2, "iPhone 15 Pro Max", "O celular da Apple")
+	index.Add(3, "Lego McLaren F1", "A poderosa McLaren F1 de 2022")
+
+	hits := index.Search("macbook apple", 10)
+	assert.Len(t, hits, 2)
+	assert.Equal(t, uint(1), hits[0].ID)
+	assert.Equal(t, uint(2), hits[1].ID)
+}
+
+func TestGivenARemovedDocument_WhenISearch_ThenShouldNotReceiveIt(t *testing.T) {
+	index := NewIndex()
+	index.Add(1, "Macbook Pro")
+	index.Add(2, "Macbook Air")
+	index.Remove(1)
+
+	hits := index.Search("macbook", 10)
+	assert.Len(t, hits, 1)
+	assert.Equal(t, uint(2), hits[0].ID)
+}
+
+func TestGivenAnUpdatedDocument_WhenISearch_ThenShouldMatchOnlyTheNewText(t *testing.T) {
+	index := NewIndex()
+	index.Add(1, "Macbook Pro")
+	index.Add(1, "iPhone")
+
+	assert.Empty(t, index.Search("macbook", 10))
+	assert.Len(t, index.Search("iphone", 10), 1)
+}
+
+func TestGivenAMatchingText_WhenIHighlight_ThenShouldWrapTheMatches(t *testing.T) {
+	snippet := Highlight("O poderoso computador da Apple", "apple computador", 100)
+	assert.Equal(t, "O poderoso <em>computador</em> da <em>Apple</em>", snippet)
+}
+
+func TestGivenALongText_WhenIHighlight_ThenShouldReceiveASnippetAroundTheMatch(t *testing.T) {
+	text := "Agora 4x mais forte, as Frigideiras Antiaderente TNS Pro da Le Creuset oferecem resultados superiores e facilidade de uso diário."
+
+	snippet := Highlight(text, "creuset", 40)
+	assert.Equal(t, "…da Le <em>Creuset</em> oferecem resultados…", snippet)
+}
+
+func TestGivenATextWithoutMatches_WhenIHighlight_ThenShouldReceiveAnEmptySnippet(t *testing.T) {
+	assert.Empty(t, Highlight("Macbook Pro", "iphone", 100))
+}
+
+func TestGivenAnAccentedTextWithoutSpaces_WhenIHighlight_ThenShouldNotCutACharacter(t *testing.T) {
+	snippet := Highlight("café-ééééééééééééééé", "cafe", 11)
+
+	assert.True(t, utf8.ValidString(snippet))
+	assert.Equal(t, "<em>café</em>-ééé…", snippet)
+}
+
+func TestGivenRemovedDocuments_WhenTheIndexIsEmpty_ThenShouldHoldNoPostings(t *testing.T) {
+	index := NewIndex()
+	index.Add(1, "Macbook Pro", "O poderoso computador da Apple")
+	index.Add(2, "Macbook Air", "O leve computador da Apple")
+	index.Add(1, "Macbook Pro M3")
+
+	index.Remove(1)
+	index.Remove(2)
+
+	assert.Empty(t, index.postings)
+	assert.Empty(t, index.terms)
+	assert.Zero(t, index.totalLength)
+}
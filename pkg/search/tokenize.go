@@ -0,0 +1,63 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+type token struct {
+	Term  string
+	Start int
+	End   int
+}
+
+// Tokenize splits the text in lower case terms without accents, so "Coração"
+// and "coracao" match the same term.
+func Tokenize(text string) []string {
+	var terms []string
+	for _, t := range tokens(text) {
+		terms = append(terms, t.Term)
+	}
+
+	return terms
+}
+
+// tokens returns the terms of the text with their byte offsets in it.
+func tokens(text string) []token {
+	var result []token
+
+	start := -1
+	for i, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+
+		if start >= 0 {
+			result = append(result, token{Term: fold(text[start:i]), Start: start, End: i})
+			start = -1
+		}
+	}
+
+	if start >= 0 {
+		result = append(result, token{Term: fold(text[start:]), Start: start, End: len(text)})
+	}
+
+	return result
+}
+
+func fold(word string) string {
+	var folded strings.Builder
+	for _, r := range norm.NFD.String(word) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		folded.WriteRune(unicode.ToLower(r))
+	}
+
+	return folded.String()
+}
@@ -0,0 +1,257 @@
+package suggest
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/waldrey/eulabs/pkg/search"
+)
+
+const (
+	// DefaultMaxEntries bounds how many products the index keeps in memory.
+	DefaultMaxEntries = 100000
+
+	maxWordLength = 32
+	maxCandidates = 500
+	maxVisits     = 50000
+)
+
+type Suggestion struct {
+	ID       uint
+	Name     string
+	Distance int
+}
+
+// Index suggests product names from a typed prefix, tolerating one typo on
+// words up to five letters and two typos on longer ones.
+type Index struct {
+	mu         sync.RWMutex
+	root       *node
+	names      map[uint]string
+	words      map[uint][][]rune
+	maxEntries int
+}
+
+func NewIndex(maxEntries int) *Index {
+	return &Index{
+		root:       &node{},
+		names:      map[uint]string{},
+		words:      map[uint][][]rune{},
+		maxEntries: maxEntries,
+	}
+}
+
+// Add indexes or replaces the name of a product, it reports false when the
+// index is full and the product was not added.
+func (i *Index) Add(id uint, name string) bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if _, ok := i.names[id]; !ok && len(i.names) >= i.maxEntries {
+		return false
+	}
+	i.remove(id)
+
+	var words [][]rune
+	for _, term := range search.Tokenize(name) {
+		word := []rune(term)
+		if len(word) > maxWordLength {
+			word = word[:maxWordLength]
+		}
+		words = append(words, word)
+		i.root.insert(word, id)
+	}
+
+	i.names[id] = name
+	i.words[id] = words
+
+	return true
+}
+
+func (i *Index) Remove(id uint) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.remove(id)
+}
+
+func (i *Index) remove(id uint) {
+	for _, word := range i.words[id] {
+		i.root.remove(word, id)
+	}
+
+	delete(i.names, id)
+	delete(i.words, id)
+}
+
+func (i *Index) Len() int {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	return len(i.names)
+}
+
+// Suggest returns up to limit product names matching the prefix, the last
+// word is matched as a prefix and every other word must also match a word of
+// the name. Closest matches come first.
+func (i *Index) Suggest(prefix string, limit int) []Suggestion {
+	terms := search.Tokenize(prefix)
+	if len(terms) == 0 || limit <= 0 {
+		return nil
+	}
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	last := []rune(terms[len(terms)-1])
+	candidates := i.candidates(last)
+
+	suggestions := make([]Suggestion, 0, len(candidates))
+	for id, distance := range candidates {
+		total, ok := i.matchOthers(id, terms[:len(terms)-1])
+		if !ok {
+			continue
+		}
+		suggestions = append(suggestions, Suggestion{ID: id, Name: i.names[id], Distance: distance + total})
+	}
+
+	sort.Slice(suggestions, func(a, b int) bool {
+		if suggestions[a].Distance != suggestions[b].Distance {
+			return suggestions[a].Distance < suggestions[b].Distance
+		}
+		if len(suggestions[a].Name) != len(suggestions[b].Name) {
+			return len(suggestions[a].Name) < len(suggestions[b].Name)
+		}
+		return suggestions[a].Name < suggestions[b].Name
+	})
+
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+
+	return suggestions
+}
+
+// candidates walks the trie allowing more edits on each pass, so exact
+// prefixes fill the candidates before the fuzzy ones.
+func (i *Index) candidates(term []rune) map[uint]int {
+	candidates := map[uint]int{}
+	for edits := 0; edits <= maxEdits(term); edits++ {
+		w := walker{term: term, maxEdits: edits, found: candidates}
+
+		row := make([]int, len(term)+1)
+		for j := range row {
+			row[j] = j
+		}
+		w.walk(i.root, row, row[len(term)])
+
+		if len(candidates) >= maxCandidates {
+			break
+		}
+	}
+
+	return candidates
+}
+
+func (i *Index) matchOthers(id uint, terms []string) (int, bool) {
+	total := 0
+	for _, term := range terms {
+		runes := []rune(term)
+
+		best := -1
+		for _, word := range i.words[id] {
+			if distance := prefixDistance(runes, word); best < 0 || distance < best {
+				best = distance
+			}
+		}
+
+		if best < 0 || best > maxEdits(runes) {
+			return 0, false
+		}
+		total += best
+	}
+
+	return total, true
+}
+
+type walker struct {
+	term     []rune
+	maxEdits int
+	found    map[uint]int
+	visits   int
+}
+
+// walk runs a Levenshtein DP row down the trie, best is the smallest distance
+// between the whole term and any prefix of the current path.
+func (w *walker) walk(n *node, row []int, best int) {
+	w.visits++
+	if w.visits > maxVisits || len(w.found) >= maxCandidates {
+		return
+	}
+
+	if best <= w.maxEdits {
+		for id := range n.ids {
+			if distance, ok := w.found[id]; !ok || best < distance {
+				w.found[id] = best
+			}
+		}
+	}
+
+	for _, e := range n.children {
+		next := make([]int, len(row))
+		next[0] = row[0] + 1
+		smallest := next[0]
+
+		for j := 1; j < len(row); j++ {
+			cost := 1
+			if w.term[j-1] == e.char {
+				cost = 0
+			}
+			next[j] = min(row[j]+1, next[j-1]+1, row[j-1]+cost)
+			smallest = min(smallest, next[j])
+		}
+
+		nextBest := min(best, next[len(next)-1])
+		if smallest > w.maxEdits && nextBest > w.maxEdits {
+			continue
+		}
+		w.walk(e.node, next, nextBest)
+	}
+}
+
+func maxEdits(term []rune) int {
+	switch {
+	case len(term) <= 2:
+		return 0
+	case len(term) <= 5:
+		return 1
+	}
+
+	return 2
+}
+
+// prefixDistance is the edit distance between the term and the closest prefix
+// of the word.
+func prefixDistance(term []rune, word []rune) int {
+	row := make([]int, len(term)+1)
+	for j := range row {
+		row[j] = j
+	}
+	best := row[len(term)]
+
+	for _, char := range word {
+		next := make([]int, len(row))
+		next[0] = row[0] + 1
+		for j := 1; j < len(row); j++ {
+			cost := 1
+			if term[j-1] == char {
+				cost = 0
+			}
+			next[j] = min(row[j]+1, next[j-1]+1, row[j-1]+cost)
+		}
+		row = next
+		best = min(best, row[len(term)])
+	}
+
+	return best
+}
@@ -0,0 +1,65 @@
+package suggest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestIndex() *Index {
+	index := NewIndex(DefaultMaxEntries)
+	index.Add(1, "Macbook Pro")
+	index.Add(2, "Macbook Air")
+	index.Add(3, "iPhone 15 Pro Max")
+	index.Add(4, "Lego McLaren F1")
+	return index
+}
+
+func names(suggestions []Suggestion) []string {
+	var result []string
+	for _, suggestion := range suggestions {
+		result = append(result, suggestion.Name)
+	}
+	return result
+}
+
+func TestGivenAnExactPrefix_WhenISuggest_ThenShouldReceiveTheMatchingNames(t *testing.T) {
+	assert.Equal(t, []string{"Macbook Air", "Macbook Pro"}, names(newTestIndex().Suggest("macb", 10)))
+}
+
+func TestGivenAPrefixWithATypo_WhenISuggest_ThenShouldReceiveTheMatchingNames(t *testing.T) {
+	assert.Equal(t, []string{"Lego McLaren F1"}, names(newTestIndex().Suggest("mclran", 10)))
+	assert.Equal(t, []string{"Macbook Air", "Macbook Pro"}, names(newTestIndex().Suggest("mavbook", 10)))
+}
+
+func TestGivenManyWords_WhenISuggest_ThenShouldMatchEveryWord(t *testing.T) {
+	assert.Equal(t, []string{"Macbook Pro"}, names(newTestIndex().Suggest("macbok pr", 10)))
+}
+
+func TestGivenExactAndFuzzyMatches_WhenISuggest_ThenShouldReceiveTheExactFirst(t *testing.T) {
+	index := NewIndex(DefaultMaxEntries)
+	index.Add(1, "Prox")
+	index.Add(2, "Pro")
+
+	suggestions := index.Suggest("prox", 10)
+	assert.Equal(t, []string{"Prox", "Pro"}, names(suggestions))
+	assert.Equal(t, 0, suggestions[0].Distance)
+	assert.Equal(t, 1, suggestions[1].Distance)
+}
+
+func TestGivenARemovedProduct_WhenISuggest_ThenShouldNotReceiveIt(t *testing.T) {
+	index := newTestIndex()
+	index.Remove(1)
+
+	assert.Equal(t, []string{"Macbook Air"}, names(index.Suggest("macbook", 10)))
+	assert.Equal(t, 3, index.Len())
+}
+
+func TestGivenAFullIndex_WhenIAdd_ThenShouldNotIndexTheProduct(t *testing.T) {
+	index := NewIndex(1)
+	assert.True(t, index.Add(1, "Macbook Pro"))
+	assert.False(t, index.Add(2, "Macbook Air"))
+	assert.True(t, index.Add(1, "Macbook Pro M3"))
+
+	assert.Equal(t, []string{"Macbook Pro M3"}, names(index.Suggest("macbook", 10)))
+}
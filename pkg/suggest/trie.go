@@ -0,0 +1,65 @@
+package suggest
+
+import "sort"
+
+type edge struct {
+	char rune
+	node *node
+}
+
+// node of the trie, children are kept sorted so walks are deterministic.
+type node struct {
+	children []edge
+	ids      map[uint]struct{}
+}
+
+func (n *node) child(char rune) *node {
+	i := sort.Search(len(n.children), func(i int) bool { return n.children[i].char >= char })
+	if i < len(n.children) && n.children[i].char == char {
+		return n.children[i].node
+	}
+
+	return nil
+}
+
+func (n *node) insert(word []rune, id uint) {
+	current := n
+	for _, char := range word {
+		next := current.child(char)
+		if next == nil {
+			next = &node{}
+			i := sort.Search(len(current.children), func(i int) bool { return current.children[i].char >= char })
+			current.children = append(current.children, edge{})
+			copy(current.children[i+1:], current.children[i:])
+			current.children[i] = edge{char: char, node: next}
+		}
+		current = next
+	}
+
+	if current.ids == nil {
+		current.ids = map[uint]struct{}{}
+	}
+	current.ids[id] = struct{}{}
+}
+
+// remove deletes the id from the word and prunes the branches left empty, it
+// reports whether the node itself became empty.
+func (n *node) remove(word []rune, id uint) bool {
+	if len(word) == 0 {
+		delete(n.ids, id)
+		return len(n.ids) == 0 && len(n.children) == 0
+	}
+
+	for i, e := range n.children {
+		if e.char != word[0] {
+			continue
+		}
+
+		if e.node.remove(word[1:], id) {
+			n.children = append(n.children[:i], n.children[i+1:]...)
+		}
+		break
+	}
+
+	return len(n.ids) == 0 && len(n.children) == 0
+}
@@ -0,0 +1,60 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGivenRows_WhenIWriteAWorkbook_ThenShouldReceiveTheSheetWithTypedCells(t *testing.T) {
+	var buffer bytes.Buffer
+	writer, err := NewWriter(&buffer, "Products & Prices")
+	assert.NoError(t, err)
+
+	assert.NoError(t, writer.WriteRow("id", "name", "price"))
+	assert.NoError(t, writer.WriteRow(uint(1), "Macbook <Pro>", Number("23000.00"), nil, time.Date(2024, 5, 2, 12, 0, 0, 0, time.UTC)))
+	assert.NoError(t, writer.Close())
+
+	archive, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	assert.NoError(t, err)
+
+	files := map[string]string{}
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		assert.NoError(t, err)
+		content, err := io.ReadAll(reader)
+		assert.NoError(t, err)
+		files[file.Name] = string(content)
+	}
+
+	assert.Contains(t, files, "[Content_Types].xml")
+	assert.Contains(t, files, "_rels/.rels")
+	assert.Contains(t, files, "xl/_rels/workbook.xml.rels")
+	assert.Contains(t, files["xl/workbook.xml"], `<sheet name="Products &amp; Prices" sheetId="1" r:id="rId1"/>`)
+	assert.Equal(t, sheetStart+
+		`<row r="1">`+
+		`<c r="A1" t="inlineStr"><is><t xml:space="preserve">id</t></is></c>`+
+		`<c r="B1" t="inlineStr"><is><t xml:space="preserve">name</t></is></c>`+
+		`<c r="C1" t="inlineStr"><is><t xml:space="preserve">price</t></is></c>`+
+		`</row>`+
+		`<row r="2">`+
+		`<c r="A2"><v>1</v></c>`+
+		`<c r="B2" t="inlineStr"><is><t xml:space="preserve">Macbook &lt;Pro&gt;</t></is></c>`+
+		`<c r="C2"><v>23000.00</v></c>`+
+		`<c r="E2" t="inlineStr"><is><t xml:space="preserve">2024-05-02T12:00:00Z</t></is></c>`+
+		`</row>`+
+		sheetEnd, files["xl/worksheets/sheet1.xml"])
+}
+
+func TestGivenAColumnIndex_WhenICallColumn_ThenShouldReceiveItsLetters(t *testing.T) {
+	assert.Equal(t, "A", column(0))
+	assert.Equal(t, "Z", column(25))
+	assert.Equal(t, "AA", column(26))
+	assert.Equal(t, "AZ", column(51))
+	assert.Equal(t, "BA", column(52))
+	assert.Equal(t, "XFD", column(16383))
+}
@@ -0,0 +1,41 @@
+package mock
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/waldrey/eulabs/internal/entity"
+)
+
+type IdempotencyRepositoryMock struct {
+	mock.Mock
+}
+
+func (i *IdempotencyRepositoryMock) Reserve(ctx context.Context, record *entity.IdempotencyKey) (*entity.IdempotencyKey, bool, error) {
+	args := i.Called(ctx, record)
+	if stored, ok := args.Get(0).(*entity.IdempotencyKey); ok {
+		return stored, args.Bool(1), args.Error(2)
+	}
+	return nil, args.Bool(1), args.Error(2)
+}
+
+func (i *IdempotencyRepositoryMock) Complete(ctx context.Context, record *entity.IdempotencyKey) error {
+	args := i.Called(ctx, record)
+	return args.Error(0)
+}
+
+func (i *IdempotencyRepositoryMock) Extend(ctx context.Context, key string, expiresAt time.Time) error {
+	args := i.Called(ctx, key, expiresAt)
+	return args.Error(0)
+}
+
+func (i *IdempotencyRepositoryMock) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	args := i.Called(ctx, now)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (i *IdempotencyRepositoryMock) Release(ctx context.Context, key string) error {
+	args := i.Called(ctx, key)
+	return args.Error(0)
+}
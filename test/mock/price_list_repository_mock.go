@@ -0,0 +1,54 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/waldrey/eulabs/internal/entity"
+)
+
+type PriceListRepositoryMock struct {
+	mock.Mock
+}
+
+func (p *PriceListRepositoryMock) FindProductPrices(ctx context.Context, productID int) ([]entity.ProductPrice, error) {
+	args := p.Called(ctx, productID)
+	if prices, ok := args.Get(0).([]entity.ProductPrice); ok {
+		return prices, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (p *PriceListRepositoryMock) FindProductPricesIn(ctx context.Context, productIDs []uint, currency string) ([]entity.ProductPrice, error) {
+	args := p.Called(ctx, productIDs, currency)
+	if prices, ok := args.Get(0).([]entity.ProductPrice); ok {
+		return prices, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (p *PriceListRepositoryMock) ReplaceProductPrices(ctx context.Context, productID int, prices []entity.ProductPrice) error {
+	args := p.Called(ctx, productID, prices)
+	return args.Error(0)
+}
+
+func (p *PriceListRepositoryMock) FindExchangeRates(ctx context.Context) ([]entity.ExchangeRate, error) {
+	args := p.Called(ctx)
+	if rates, ok := args.Get(0).([]entity.ExchangeRate); ok {
+		return rates, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (p *PriceListRepositoryMock) FindExchangeRate(ctx context.Context, base string, quote string) (*entity.ExchangeRate, error) {
+	args := p.Called(ctx, base, quote)
+	if rate, ok := args.Get(0).(*entity.ExchangeRate); ok {
+		return rate, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (p *PriceListRepositoryMock) SaveExchangeRates(ctx context.Context, rates []entity.ExchangeRate) error {
+	args := p.Called(ctx, rates)
+	return args.Error(0)
+}
@@ -1,16 +1,20 @@
 package mock
 
 import (
+	"context"
+	"time"
+
 	"github.com/stretchr/testify/mock"
 	"github.com/waldrey/eulabs/internal/entity"
+	"github.com/waldrey/eulabs/internal/infra/database"
 )
 
 type ProductRepositoryMock struct {
 	mock.Mock
 }
 
-func (p *ProductRepositoryMock) Create(product *entity.Product) (*entity.Product, error) {
-	args := p.Called(product)
+func (p *ProductRepositoryMock) Create(ctx context.Context, product *entity.Product) (*entity.Product, error) {
+	args := p.Called(ctx, product)
 	if result, ok := args.Get(0).(*entity.Product); ok {
 		result.ID = 1
 		return result, args.Error(1)
@@ -18,28 +22,159 @@ func (p *ProductRepositoryMock) Create(product *entity.Product) (*entity.Product
 	return nil, args.Error(1)
 }
 
-func (p *ProductRepositoryMock) Delete(product *entity.Product) error {
-	args := p.Called(product)
+func (p *ProductRepositoryMock) Delete(ctx context.Context, product *entity.Product) error {
+	args := p.Called(ctx, product)
 	return args.Error(0)
 }
 
-func (p *ProductRepositoryMock) FindAll() ([]entity.Product, error) {
-	args := p.Called()
+func (p *ProductRepositoryMock) FindAll(ctx context.Context) ([]entity.Product, error) {
+	args := p.Called(ctx)
+	if products, ok := args.Get(0).([]entity.Product); ok {
+		return products, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (p *ProductRepositoryMock) List(ctx context.Context, options database.ListOptions) ([]entity.Product, int64, error) {
+	args := p.Called(ctx, options)
+	if products, ok := args.Get(0).([]entity.Product); ok {
+		return products, args.Get(1).(int64), args.Error(2)
+	}
+	return nil, 0, args.Error(2)
+}
+
+func (p *ProductRepositoryMock) ListAfter(ctx context.Context, options database.ListOptions) ([]entity.Product, error) {
+	args := p.Called(ctx, options)
+	if products, ok := args.Get(0).([]entity.Product); ok {
+		return products, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+// Export calls each with the products given to Return.
+func (p *ProductRepositoryMock) Export(ctx context.Context, options database.ListOptions, each func(product entity.Product) error) error {
+	args := p.Called(ctx, options, each)
+	if products, ok := args.Get(0).([]entity.Product); ok {
+		for _, product := range products {
+			if err := each(product); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+func (p *ProductRepositoryMock) FindByID(ctx context.Context, id int) (*entity.Product, error) {
+	args := p.Called(ctx, id)
+	if product, ok := args.Get(0).(*entity.Product); ok {
+		return product, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (p *ProductRepositoryMock) FindByIDForUpdate(ctx context.Context, id int) (*entity.Product, error) {
+	args := p.Called(ctx, id)
+	if product, ok := args.Get(0).(*entity.Product); ok {
+		return product, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (p *ProductRepositoryMock) CatalogState(ctx context.Context) (database.CatalogState, error) {
+	args := p.Called(ctx)
+	return args.Get(0).(database.CatalogState), args.Error(1)
+}
+
+func (p *ProductRepositoryMock) FindByIDFields(ctx context.Context, id int, fields []string) (*entity.Product, error) {
+	args := p.Called(ctx, id, fields)
+	if product, ok := args.Get(0).(*entity.Product); ok {
+		return product, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (p *ProductRepositoryMock) FindByIDs(ctx context.Context, ids []uint) ([]entity.Product, error) {
+	args := p.Called(ctx, ids)
+	if products, ok := args.Get(0).([]entity.Product); ok {
+		return products, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (p *ProductRepositoryMock) FindByNames(ctx context.Context, names []string) ([]entity.Product, error) {
+	args := p.Called(ctx, names)
 	if products, ok := args.Get(0).([]entity.Product); ok {
 		return products, args.Error(1)
 	}
 	return nil, args.Error(1)
 }
 
-func (p *ProductRepositoryMock) FindByID(id int) (*entity.Product, error) {
-	args := p.Called(id)
+func (p *ProductRepositoryMock) BulkWrite(ctx context.Context, writes database.BulkWrites) error {
+	args := p.Called(ctx, writes)
+	return args.Error(0)
+}
+
+func (p *ProductRepositoryMock) Update(ctx context.Context, product *entity.Product) error {
+	args := p.Called(ctx, product)
+	return args.Error(0)
+}
+
+func (p *ProductRepositoryMock) Search(ctx context.Context, query string, limit int) ([]database.SearchResult, error) {
+	args := p.Called(ctx, query, limit)
+	if results, ok := args.Get(0).([]database.SearchResult); ok {
+		return results, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (p *ProductRepositoryMock) PriceStats(ctx context.Context, filter database.ProductFilter) (database.PriceStats, error) {
+	args := p.Called(ctx, filter)
+	return args.Get(0).(database.PriceStats), args.Error(1)
+}
+
+func (p *ProductRepositoryMock) PricesAt(ctx context.Context, filter database.ProductFilter, offset int, limit int) ([]int64, error) {
+	args := p.Called(ctx, filter, offset, limit)
+	if prices, ok := args.Get(0).([]int64); ok {
+		return prices, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (p *ProductRepositoryMock) PriceHistogram(ctx context.Context, filter database.ProductFilter, from int64, width int64) (map[int]int64, error) {
+	args := p.Called(ctx, filter, from, width)
+	if histogram, ok := args.Get(0).(map[int]int64); ok {
+		return histogram, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (p *ProductRepositoryMock) ListTrashed(ctx context.Context, offset int, limit int) ([]entity.Product, int64, error) {
+	args := p.Called(ctx, offset, limit)
+	if products, ok := args.Get(0).([]entity.Product); ok {
+		return products, args.Get(1).(int64), args.Error(2)
+	}
+	return nil, 0, args.Error(2)
+}
+
+func (p *ProductRepositoryMock) FindWithTrashedForUpdate(ctx context.Context, id int) (*entity.Product, error) {
+	args := p.Called(ctx, id)
 	if product, ok := args.Get(0).(*entity.Product); ok {
 		return product, args.Error(1)
 	}
 	return nil, args.Error(1)
 }
 
-func (p *ProductRepositoryMock) Update(product *entity.Product) error {
-	args := p.Called(product)
+func (p *ProductRepositoryMock) Restore(ctx context.Context, product *entity.Product) error {
+	args := p.Called(ctx, product)
 	return args.Error(0)
 }
+
+func (p *ProductRepositoryMock) Purge(ctx context.Context, product *entity.Product) error {
+	args := p.Called(ctx, product)
+	return args.Error(0)
+}
+
+func (p *ProductRepositoryMock) PurgeTrashed(ctx context.Context, before time.Time) (int64, error) {
+	args := p.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
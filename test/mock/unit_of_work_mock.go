@@ -0,0 +1,35 @@
+package mock
+
+import (
+	"context"
+	"sync"
+)
+
+type workKey struct{}
+
+// UnitOfWork is the in-memory unit of work, it runs one work at a time, as if
+// every one locked the same rows, and counts the works committed and rolled
+// back. A work started inside another one joins it.
+type UnitOfWork struct {
+	mutex      sync.Mutex
+	Committed  int
+	RolledBack int
+}
+
+func (u *UnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(workKey{}) == u {
+		return fn(ctx)
+	}
+
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	err := fn(context.WithValue(ctx, workKey{}, u))
+	if err != nil {
+		u.RolledBack++
+		return err
+	}
+
+	u.Committed++
+	return nil
+}
@@ -1,13 +1,14 @@
 package tools
 
 import (
-	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
 
+	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
+	"github.com/waldrey/eulabs/pkg/i18n"
 	"github.com/waldrey/eulabs/pkg/requests"
 )
 
@@ -15,37 +16,23 @@ func ValidateRequest(c echo.Context) (int, error) {
 	idParam := c.Param("id")
 	id, err := strconv.Atoi(idParam)
 	if err != nil {
-		errResponse := requests.ErrorResponse("ID must be an integer")
-		return 0, c.JSON(http.StatusBadRequest, errResponse)
+		return 0, requests.NewProblem(http.StatusBadRequest, "invalid_id", "ID must be an integer")
 	}
 
 	if id <= 0 {
-		errResponse := requests.ErrorResponse("ID must be a positive integer")
-		return 0, c.JSON(http.StatusBadRequest, errResponse)
+		return 0, requests.NewProblem(http.StatusBadRequest, "invalid_id", "ID must be a positive integer")
 	}
 
 	return id, nil
 }
 
-func FormatValidationError(err error) []string {
+// FormatValidationError lists the messages of the refused fields in the
+// language of the translator, in English when it is nil.
+func FormatValidationError(err error, translator ut.Translator) []string {
 	var errors []string
 	for _, err := range err.(validator.ValidationErrors) {
-		errors = append(errors, fmt.Sprintf("the field '%s' is %s", strings.ToLower(err.Field()), err.Tag()))
+		errors = append(errors, i18n.FieldMessage(translator, strings.ToLower(err.Field()), err.Tag(), err.Param()))
 	}
 
 	return errors
 }
-
-func SafeDereferenceString(ptr *string) string {
-	if ptr == nil {
-		return ""
-	}
-	return *ptr
-}
-
-func SafeDereferenceFloat64(ptr *float64) float64 {
-	if ptr == nil {
-		return 0.0
-	}
-	return *ptr
-}
@@ -0,0 +1,106 @@
+package tools
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/waldrey/eulabs/internal/dto"
+	"github.com/waldrey/eulabs/pkg/money"
+	"github.com/waldrey/eulabs/pkg/optional"
+)
+
+// NewValidator returns a validator that reports fields by their json or query
+// name and knows the custom tags used by the dto package.
+func NewValidator() *validator.Validate {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(fieldName)
+
+	// money is validated by its amount, the currency is checked when decoded
+	validate.RegisterCustomTypeFunc(func(field reflect.Value) interface{} {
+		return field.Interface().(money.Money).Amount
+	}, money.Money{})
+
+	// partial updates validate a field only when it was sent with a value, use
+	// omitnil since a zero value sent is still validated
+	validate.RegisterCustomTypeFunc(presentValue[string], optional.Field[string]{})
+	validate.RegisterCustomTypeFunc(presentValue[money.Money], optional.Field[money.Money]{})
+
+	_ = validate.RegisterValidation("sortable", validateSort(dto.ProductSortableFields))
+	_ = validate.RegisterValidation("currency", validateCurrency)
+	_ = validate.RegisterValidation("rate", validateRate)
+	_ = validate.RegisterValidation("rfc3339", validateDateTime)
+	_ = validate.RegisterValidation("gtdatefield", validateDateTimeAfter)
+
+	return validate
+}
+
+func presentValue[T any](field reflect.Value) interface{} {
+	value := field.Interface().(optional.Field[T])
+	if !value.Present() {
+		return (*T)(nil)
+	}
+
+	return value.Value
+}
+
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "query"} {
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+
+	return field.Name
+}
+
+func validateSort(allowed []string) validator.Func {
+	return func(fl validator.FieldLevel) bool {
+		seen := map[string]bool{}
+		for _, field := range dto.ParseSort(fl.Field().String()) {
+			if !slices.Contains(allowed, field.Field) || seen[field.Field] {
+				return false
+			}
+			seen[field.Field] = true
+		}
+
+		return true
+	}
+}
+
+func validateCurrency(fl validator.FieldLevel) bool {
+	return money.IsValidCurrency(strings.ToUpper(fl.Field().String()))
+}
+
+func validateRate(fl validator.FieldLevel) bool {
+	_, err := money.ParseRate(fl.Field().String())
+	return err == nil
+}
+
+func validateDateTime(fl validator.FieldLevel) bool {
+	_, err := dto.ParseDateTime(fl.Field().String())
+	return err == nil
+}
+
+// validateDateTimeAfter checks the date time is after the one of the field of
+// the param, a date time missing or invalid is reported by its own field.
+func validateDateTimeAfter(fl validator.FieldLevel) bool {
+	other, _, _, ok := fl.GetStructFieldOK2()
+	if !ok || other.Kind() != reflect.String {
+		return false
+	}
+
+	value, err := dto.ParseDateTime(fl.Field().String())
+	if err != nil || value.IsZero() {
+		return true
+	}
+
+	after, err := dto.ParseDateTime(other.String())
+	if err != nil || after.IsZero() {
+		return true
+	}
+
+	return value.After(after)
+}
@@ -0,0 +1,106 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/waldrey/eulabs/internal/dto"
+	"github.com/waldrey/eulabs/pkg/i18n"
+	"github.com/waldrey/eulabs/pkg/money"
+	"github.com/waldrey/eulabs/pkg/optional"
+)
+
+func TestGivenAnAllowedSort_WhenIValidateTheListQuery_ThenShouldNotReceiveAnError(t *testing.T) {
+	err := NewValidator().Struct(dto.ListProductsQuery{Sort: "price,-created_at"})
+	assert.NoError(t, err)
+}
+
+func TestGivenAnUnknownSortField_WhenIValidateTheListQuery_ThenShouldReceiveTheQueryFieldName(t *testing.T) {
+	err := NewValidator().Struct(dto.ListProductsQuery{Sort: "price,-password"})
+	assert.Equal(t, []string{"the field 'sort' is sortable"}, FormatValidationError(err, nil))
+}
+
+func TestGivenAPortugueseTranslator_WhenIFormatTheValidationError_ThenShouldReceiveTheTranslatedMessage(t *testing.T) {
+	catalog, err := i18n.NewCatalog()
+	assert.NoError(t, err)
+
+	err = NewValidator().Struct(dto.ListProductsQuery{Sort: "price,-password"})
+	assert.Equal(t, []string{"o campo 'sort' tem um campo de ordenação inválido"}, FormatValidationError(err, catalog.Translator("pt-BR")))
+}
+
+func TestGivenARepeatedSortField_WhenIValidateTheListQuery_ThenShouldReceiveAnError(t *testing.T) {
+	err := NewValidator().Struct(dto.ListProductsQuery{Sort: "price,-price"})
+	assert.Error(t, err)
+}
+
+func TestGivenAMaxPriceBelowTheMinPrice_WhenIValidateTheListQuery_ThenShouldReceiveAnError(t *testing.T) {
+	err := NewValidator().Struct(dto.ListProductsQuery{
+		ProductFilterQuery: dto.ProductFilterQuery{MinPrice: 100, MaxPrice: 50},
+	})
+	assert.Equal(t, []string{"the field 'max_price' is gtefield"}, FormatValidationError(err, nil))
+}
+
+func TestGivenAnUnknownCurrency_WhenIValidateTheListQuery_ThenShouldReceiveAnError(t *testing.T) {
+	err := NewValidator().Struct(dto.ListProductsQuery{CurrencyQuery: dto.CurrencyQuery{Currency: "XYZ"}})
+	assert.Equal(t, []string{"the field 'currency' is currency"}, FormatValidationError(err, nil))
+
+	err = NewValidator().Struct(dto.ListProductsQuery{CurrencyQuery: dto.CurrencyQuery{Currency: "usd"}})
+	assert.NoError(t, err)
+}
+
+func TestGivenRepeatedCurrencies_WhenIValidateTheProductPrices_ThenShouldReceiveAnError(t *testing.T) {
+	err := NewValidator().Struct(dto.ProductPricesRequest{
+		Prices: []money.Money{money.New(1999, "USD"), money.New(1899, "USD")},
+	})
+	assert.Equal(t, []string{"the field 'prices' is unique"}, FormatValidationError(err, nil))
+
+	err = NewValidator().Struct(dto.ProductPricesRequest{
+		Prices: []money.Money{money.New(1999, "USD"), money.New(0, "EUR")},
+	})
+	assert.Error(t, err)
+}
+
+func TestGivenAnExchangeRate_WhenIValidateIt_ThenShouldRequireAPositiveDecimal(t *testing.T) {
+	validate := NewValidator()
+
+	err := validate.Struct(dto.SaveExchangeRatesRequest{
+		Rates: []dto.ExchangeRateRequest{{Base: "USD", Quote: "BRL", Rate: "5.0731"}},
+	})
+	assert.NoError(t, err)
+
+	err = validate.Struct(dto.SaveExchangeRatesRequest{
+		Rates: []dto.ExchangeRateRequest{{Base: "USD", Quote: "BRL", Rate: "-1"}},
+	})
+	assert.Equal(t, []string{"the field 'rate' is rate"}, FormatValidationError(err, nil))
+
+	err = validate.Struct(dto.SaveExchangeRatesRequest{
+		Rates: []dto.ExchangeRateRequest{{Base: "USD", Quote: "USD", Rate: "1"}},
+	})
+	assert.Equal(t, []string{"the field 'quote' is nefield"}, FormatValidationError(err, nil))
+}
+
+func TestGivenAPartialUpdate_WhenIValidateIt_ThenShouldOnlyCheckTheSentFields(t *testing.T) {
+	validate := NewValidator()
+
+	err := validate.Struct(dto.UpdateProductRequest{Name: optional.Of("Macbook Pro")})
+	assert.NoError(t, err)
+
+	err = validate.Struct(dto.UpdateProductRequest{Price: optional.Null[money.Money]()})
+	assert.NoError(t, err)
+
+	err = validate.Struct(dto.UpdateProductRequest{Price: optional.Of(money.New(0, "BRL"))})
+	assert.Equal(t, []string{"the field 'price' is gt"}, FormatValidationError(err, nil))
+}
+
+func TestGivenBulkOperations_WhenIValidateThem_ThenShouldRequireTheIDOnlyOutsideCreate(t *testing.T) {
+	validate := NewValidator()
+
+	err := validate.Struct(dto.BulkProductOperation{Op: dto.BulkCreate, ID: 1, Product: []byte(`{}`)})
+	assert.Equal(t, []string{"the field 'id' is excluded_if"}, FormatValidationError(err, nil))
+
+	err = validate.Struct(dto.BulkProductOperation{Op: dto.BulkDelete})
+	assert.Equal(t, []string{"the field 'id' is required_unless"}, FormatValidationError(err, nil))
+
+	err = validate.Struct(dto.BulkProductOperation{Op: dto.BulkUpdate, ID: 1})
+	assert.Equal(t, []string{"the field 'product' is required_unless"}, FormatValidationError(err, nil))
+}